@@ -111,11 +111,13 @@ var tools = []toolDef{
 	},
 	{
 		Name:        "cg_search",
-		Description: "List entities, optionally filtered by type. Returns all non-deleted entities matching the filter.",
+		Description: "List entities, optionally filtered by type. Returns one page of non-deleted entities, newest first; pass next_cursor back as cursor to continue.",
 		InputSchema: mustJSON(`{
 			"type": "object",
 			"properties": {
-				"entity_type": {"type": "string", "description": "Filter by entity type (e.g. person, project, agent). Omit to list all."}
+				"entity_type": {"type": "string", "description": "Filter by entity type (e.g. person, project, agent). Omit to list all."},
+				"limit": {"type": "integer", "description": "Page size (default 50, max 500)"},
+				"cursor": {"type": "string", "description": "next_cursor from a previous page"}
 			}
 		}`),
 	},
@@ -322,11 +324,13 @@ func handleLookup(ctx context.Context, client *mcpclient.Client, args json.RawMe
 func handleSearch(ctx context.Context, client *mcpclient.Client, args json.RawMessage) toolCallResult {
 	var params struct {
 		EntityType string `json:"entity_type"`
+		Limit      int    `json:"limit"`
+		Cursor     string `json:"cursor"`
 	}
 	if args != nil {
 		_ = json.Unmarshal(args, &params)
 	}
-	result, err := client.ListEntities(ctx, params.EntityType)
+	result, err := client.ListEntitiesPage(ctx, params.EntityType, params.Limit, params.Cursor)
 	if err != nil {
 		return errorResult(err.Error())
 	}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -65,20 +66,65 @@ func main() {
 	}
 	logger.Info("embedding provider initialized", "backend", embedder.Name())
 
-	// Encryption
-	var encryptor *encryption.Encryptor
-	if cfg.EncryptionKey != "" {
-		encryptor, err = encryption.NewEncryptor(cfg.EncryptionKey)
+	// Encryption. Outside development a missing or invalid key is fatal:
+	// secrets written under a throwaway key are lost on restart.
+	encStatus := encryption.Status{Env: cfg.Env}
+	encryptor, err := encryption.New(ctx, encryption.Config{
+		Backend:     cfg.EncryptionBackend,
+		KeyringPath: cfg.KeyringPath,
+		Key:         cfg.EncryptionKey,
+		Vault: encryption.VaultConfig{
+			Addr:      cfg.VaultAddr,
+			Token:     cfg.VaultToken,
+			Namespace: cfg.VaultNamespace,
+			Mount:     cfg.VaultTransitMount,
+			Key:       cfg.VaultTransitKey,
+		},
+		Age: encryption.AgeConfig{
+			Binary:       cfg.AgeBinary,
+			Recipient:    cfg.AgeRecipient,
+			IdentityPath: cfg.AgeIdentityPath,
+		},
+	})
+	if err != nil {
+		if !cfg.Dev() {
+			logger.Error("refusing to start without a valid encryption key", "env", cfg.Env, "error", err)
+			os.Exit(1)
+		}
+		logger.Warn("no valid encryption key configured, using ephemeral key for development", "error", err)
+		key, err := encryption.GenerateKey()
 		if err != nil {
-			logger.Warn("failed to initialize encryptor, secret management disabled", "error", err)
+			logger.Error("failed to generate ephemeral encryption key", "error", err)
+			os.Exit(1)
 		}
+		encryptor, _ = encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{{Key: key.Encode()}}})
+		encStatus.State = encryption.StateEphemeral
 	}
-	if encryptor == nil {
-		logger.Warn("no encryption key configured, using ephemeral key for development")
-		// Generate a temporary key for development
-		key, _ := encryption.GenerateKey()
-		if key != nil {
-			encryptor, _ = encryption.NewEncryptor(key.Encode())
+	encStatus.Backend = encryptor.Backend()
+	encStatus.PrimaryKeyID = encryptor.PrimaryKeyID()
+
+	// Encryption self-test: a wrong key must not go unnoticed until a
+	// secret is read. An ephemeral key is never tested, so it cannot write
+	// a canary that a real key would then fail.
+	if encStatus.State != encryption.StateEphemeral {
+		canary, err := store.NewSecretStore(db).CheckCanary(ctx, encryptor)
+		switch {
+		case err == nil:
+			encStatus.State = encryption.StateOK
+			encStatus.Canary = canary
+			logger.Info("encryption self-test passed", "canary", canary, "backend", encStatus.Backend, "primary_key_id", encStatus.PrimaryKeyID)
+		case errors.Is(err, store.ErrKeyMismatch):
+			if !cfg.Dev() {
+				logger.Error("refusing to start: encryption self-test failed", "env", cfg.Env, "error", err)
+				os.Exit(1)
+			}
+			encStatus.State = encryption.StateKeyMismatch
+			encStatus.Error = err.Error()
+			logger.Warn("encryption self-test failed, stored secrets are unreadable", "error", err)
+		default:
+			encStatus.State = encryption.StateUnverified
+			encStatus.Error = err.Error()
+			logger.Warn("encryption self-test could not run", "error", err)
 		}
 	}
 
@@ -92,16 +138,6 @@ func main() {
 		} else {
 			defer hermesClient.Close()
 			logger.Info("connected to Hermes (NATS)", "url", cfg.NatsURL)
-
-			// Start subscriber for auto-capture
-			knowledgeStore := store.NewKnowledgeStore(db)
-			publisher := hermes.NewPublisher(hermesClient, logger)
-			subscriber := hermes.NewSubscriber(hermesClient, knowledgeStore, embedder, publisher, logger)
-			if err := subscriber.Start(ctx); err != nil {
-				logger.Warn("failed to start Hermes subscriber", "error", err)
-			} else {
-				defer subscriber.Stop()
-			}
 		}
 	}
 
@@ -119,6 +155,50 @@ func main() {
 
 	// Server
 	srv := server.New(cfg, db, hermesClient, embedder, encryptor, resolver, logger)
+	srv.SetEncryptionStatus(encStatus)
+
+	// Hermes subscriber for auto-capture
+	if hermesClient != nil {
+		subscriber := hermes.NewSubscriber(hermesClient, srv.Knowledge, embedder, srv.Publisher, logger)
+		if cfg.WatchEnabled {
+			subscriber.SetWatcher(srv.Watcher)
+		}
+		if err := subscriber.Start(ctx); err != nil {
+			logger.Warn("failed to start Hermes subscriber", "error", err)
+		} else {
+			defer subscriber.Stop()
+		}
+	}
+
+	// Knowledge reaper
+	if cfg.ReaperEnabled {
+		srv.Reaper.Start(ctx)
+	}
+
+	// Knowledge chunker
+	if cfg.ChunkingEnabled {
+		srv.Chunker.Start(ctx)
+	}
+
+	// Knowledge embedding backfill
+	if cfg.EmbeddingBackfillEnabled {
+		srv.Reembed.Start(ctx)
+	}
+
+	// Secret re-wrap jobs run on demand
+	if srv.Rewrap != nil {
+		srv.Rewrap.Start(ctx)
+	}
+
+	// Scheduled secret rotation
+	if cfg.RotationEnabled && srv.Rotation != nil {
+		srv.Rotation.Start(ctx)
+	}
+
+	// Saved search notifications
+	if cfg.WatchEnabled {
+		srv.Watcher.Start(ctx)
+	}
 
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/reembed"
+	"github.com/MikeSquared-Agency/Alexandria/internal/rewrap"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// AdminHandler provides maintenance endpoints. Only warren may call them.
+type AdminHandler struct {
+	reembed *reembed.Worker
+	rewrap  *rewrap.Worker
+	audit   *store.AuditStore
+}
+
+// NewAdminHandler creates a new AdminHandler.
+func NewAdminHandler(reembedder *reembed.Worker, audit *store.AuditStore) *AdminHandler {
+	return &AdminHandler{reembed: reembedder, audit: audit}
+}
+
+// SetRewrap enables the secret re-wrap endpoints.
+func (h *AdminHandler) SetRewrap(rewrapper *rewrap.Worker) {
+	h.rewrap = rewrapper
+}
+
+// Reembed handles POST /admin/reembed. It starts re-embedding every
+// knowledge entry not embedded by the current model, or every entry with
+// ?all=true, and returns the job's progress.
+func (h *AdminHandler) Reembed(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can re-embed knowledge")
+		return
+	}
+	all := r.URL.Query().Get("all") == "true"
+
+	progress, err := h.reembed.StartJob(all)
+	if err != nil {
+		if errors.Is(err, reembed.ErrJobRunning) {
+			writeErrorDetails(w, http.StatusConflict, "REEMBED_RUNNING", "A re-embed job is already running", progress)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start re-embed job")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeReembed, agentID, nil, nil, true, map[string]any{
+		"model": progress.Model,
+		"all":   all,
+		"total": progress.Total,
+	})
+	writeSuccess(w, http.StatusAccepted, progress)
+}
+
+// ReembedStatus handles GET /admin/reembed.
+func (h *AdminHandler) ReembedStatus(w http.ResponseWriter, r *http.Request) {
+	if middleware.AgentIDFromContext(r.Context()) != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can view re-embed progress")
+		return
+	}
+	writeSuccess(w, http.StatusOK, h.reembed.Progress())
+}
+
+// Rewrap handles POST /admin/secrets/rewrap. It starts moving every secret
+// value, current and historical, to the primary master key and returns the
+// job's progress.
+func (h *AdminHandler) Rewrap(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can re-wrap secrets")
+		return
+	}
+	if h.rewrap == nil {
+		writeError(w, http.StatusServiceUnavailable, "ENCRYPTION_UNAVAILABLE", "Secret encryption is not configured")
+		return
+	}
+
+	progress, err := h.rewrap.StartJob(agentID)
+	if err != nil {
+		if errors.Is(err, rewrap.ErrJobRunning) {
+			writeErrorDetails(w, http.StatusConflict, "REWRAP_RUNNING", "A re-wrap job is already running", progress)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start re-wrap job")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSecretRewrap, agentID, nil, nil, true, map[string]any{
+		"key_id": progress.KeyID,
+		"total":  progress.Total,
+	})
+	writeSuccess(w, http.StatusAccepted, progress)
+}
+
+// RewrapStatus handles GET /admin/secrets/rewrap.
+func (h *AdminHandler) RewrapStatus(w http.ResponseWriter, r *http.Request) {
+	if middleware.AgentIDFromContext(r.Context()) != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can view re-wrap progress")
+		return
+	}
+	if h.rewrap == nil {
+		writeError(w, http.StatusServiceUnavailable, "ENCRYPTION_UNAVAILABLE", "Secret encryption is not configured")
+		return
+	}
+	writeSuccess(w, http.StatusOK, h.rewrap.Progress())
+}
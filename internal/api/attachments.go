@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/blob"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// maxFilenameLen bounds stored attachment filenames.
+const maxFilenameLen = 255
+
+// errUploadTooLarge is returned by quotaReader once the allowance is used up.
+var errUploadTooLarge = errors.New("upload exceeds attachment quota")
+
+// AttachmentHandler provides knowledge attachment endpoints. Attachments
+// inherit the parent entry's access rules: anyone who can read the entry can
+// list and download them, and only its owner or admin can add or remove them.
+type AttachmentHandler struct {
+	knowledge   *store.KnowledgeStore
+	attachments *store.AttachmentStore
+	blobs       blob.Store
+	audit       *store.AuditStore
+	quota       store.AttachmentQuota
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler.
+func NewAttachmentHandler(knowledge *store.KnowledgeStore, attachments *store.AttachmentStore, blobs blob.Store, audit *store.AuditStore, quota store.AttachmentQuota) *AttachmentHandler {
+	return &AttachmentHandler{
+		knowledge:   knowledge,
+		attachments: attachments,
+		blobs:       blobs,
+		audit:       audit,
+		quota:       quota,
+	}
+}
+
+// List handles GET /knowledge/{id}/attachments.
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if !h.readable(w, r, id, agentID) {
+		return
+	}
+
+	attachments, err := h.attachments.List(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list attachments")
+		return
+	}
+	writeSuccess(w, http.StatusOK, attachments)
+}
+
+// Upload handles POST /knowledge/{id}/attachments. The body is either
+// multipart/form-data with a "file" part, or the raw bytes with the name in
+// the filename query parameter.
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	remaining, err := h.attachments.Remaining(r.Context(), id, agentID, h.quota)
+	if err != nil {
+		h.writeAttachmentError(w, id, err)
+		return
+	}
+
+	src, filename, declared, msg := uploadSource(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if filename == "" || filename == "." || filename == "/" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "A filename is required")
+		return
+	}
+	if len(filename) > maxFilenameLen {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Filename exceeds 255 characters")
+		return
+	}
+
+	br := bufio.NewReaderSize(src, 512)
+	head, _ := br.Peek(512)
+	contentType := blob.SniffContentType(head, declared, filename)
+
+	hash := sha256.New()
+	body := io.TeeReader(&quotaReader{r: br, remaining: remaining}, hash)
+
+	attachmentID := uuid.NewString()
+	key := id + "/" + attachmentID
+	size, err := h.blobs.Put(r.Context(), key, body)
+	if err != nil {
+		_ = h.blobs.Delete(r.Context(), key)
+		if errors.Is(err, errUploadTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "ATTACHMENT_TOO_LARGE",
+				"Attachment exceeds the remaining quota of "+strconv.FormatInt(remaining, 10)+" bytes")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store attachment")
+		return
+	}
+
+	attachment, err := h.attachments.Create(r.Context(), agentID, store.Attachment{
+		ID:          attachmentID,
+		KnowledgeID: id,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}, h.quota)
+	if err != nil {
+		_ = h.blobs.Delete(r.Context(), key)
+		h.writeAttachmentError(w, id, err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionAttachmentUpload, agentID, &id, nil, true, map[string]any{
+		"attachment_id": attachment.ID,
+		"size_bytes":    attachment.SizeBytes,
+		"content_type":  attachment.ContentType,
+	})
+	writeSuccess(w, http.StatusCreated, attachment)
+}
+
+// Download handles GET /knowledge/{id}/attachments/{attachment_id}.
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachment_id")
+
+	if !h.readable(w, r, id, agentID) {
+		return
+	}
+
+	attachment, err := h.attachments.Get(r.Context(), id, attachmentID)
+	if err != nil {
+		h.writeAttachmentError(w, id, err)
+		return
+	}
+
+	rc, err := h.blobs.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "ATTACHMENT_NOT_FOUND", "Attachment content is missing")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read attachment")
+		return
+	}
+	defer rc.Close()
+
+	_ = h.audit.Log(r.Context(), store.ActionAttachmentDownload, agentID, &id, nil, true, map[string]any{
+		"attachment_id": attachment.ID,
+	})
+
+	// Always a download, never rendered inline.
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, rc)
+}
+
+// Delete handles DELETE /knowledge/{id}/attachments/{attachment_id}.
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachment_id")
+
+	attachment, err := h.attachments.Delete(r.Context(), id, attachmentID, agentID)
+	if err != nil {
+		h.writeAttachmentError(w, id, err)
+		return
+	}
+	// The record is gone, so a leftover blob is unreachable; nothing to undo.
+	_ = h.blobs.Delete(r.Context(), attachment.StorageKey)
+
+	_ = h.audit.Log(r.Context(), store.ActionAttachmentDelete, agentID, &id, nil, true, map[string]any{
+		"attachment_id": attachment.ID,
+	})
+	writeSuccess(w, http.StatusOK, map[string]string{"deleted": attachment.ID})
+}
+
+// readable writes a 404 and returns false unless agentID can read entry id.
+func (h *AttachmentHandler) readable(w http.ResponseWriter, r *http.Request, id, agentID string) bool {
+	entry, err := h.knowledge.GetByID(r.Context(), id, agentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get knowledge entry")
+		return false
+	}
+	if entry == nil {
+		writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+		return false
+	}
+	return true
+}
+
+func (h *AttachmentHandler) writeAttachmentError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, store.ErrKnowledgeNotFound):
+		writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+	case errors.Is(err, store.ErrAccessDenied):
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can change attachments")
+	case errors.Is(err, store.ErrAttachmentNotFound):
+		writeError(w, http.StatusNotFound, "ATTACHMENT_NOT_FOUND", "No such attachment on knowledge entry '"+id+"'")
+	case errors.Is(err, store.ErrAttachmentQuota):
+		writeError(w, http.StatusRequestEntityTooLarge, "ATTACHMENT_QUOTA_EXCEEDED", "Knowledge entry '"+id+"' has no attachment quota left")
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process attachment")
+	}
+}
+
+// uploadSource returns the upload body, its filename and declared type. It
+// returns a validation message if the body is unusable.
+func uploadSource(r *http.Request) (io.Reader, string, string, string) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, r.URL.Query().Get("filename"), r.Header.Get("Content-Type"), ""
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", "", "Invalid multipart body"
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", "", `Multipart body has no "file" part`
+		}
+		if err != nil {
+			return nil, "", "", "Invalid multipart body"
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), part.Header.Get("Content-Type"), ""
+		}
+	}
+}
+
+// quotaReader fails with errUploadTooLarge once more than remaining bytes
+// are read. A negative remaining is unlimited.
+type quotaReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	q.read += int64(n)
+	if q.remaining >= 0 && q.read > q.remaining {
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// AuditHandler exposes the access log.
+type AuditHandler struct {
+	audit *store.AuditStore
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(audit *store.AuditStore) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+// List handles GET /audit. Agents only see their own entries; warren may
+// filter by any agent_id.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	var filterAgent *string
+	if v := q.Get("agent_id"); v != "" {
+		filterAgent = &v
+	}
+	if agentID != "warren" {
+		filterAgent = &agentID
+	}
+	var action *store.AccessAction
+	if v := q.Get("action"); v != "" {
+		a := store.AccessAction(v)
+		action = &a
+	}
+
+	entries, info, err := h.audit.QueryPage(r.Context(), filterAgent, action, parsePageRequest(q))
+	if err != nil {
+		writeListError(w, err, "Failed to query audit log")
+		return
+	}
+	writePage(w, entries, info)
+}
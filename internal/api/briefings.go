@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -17,6 +18,7 @@ type BriefingHandler struct {
 	assembler *briefings.Assembler
 	audit     *store.AuditStore
 	publisher *hermes.Publisher
+	ranking   *store.RankingStore
 }
 
 // NewBriefingHandler creates a new BriefingHandler.
@@ -28,6 +30,11 @@ func NewBriefingHandler(assembler *briefings.Assembler, audit *store.AuditStore,
 	}
 }
 
+// SetRanking lets briefings select a named ranking profile.
+func (h *BriefingHandler) SetRanking(ranking *store.RankingStore) {
+	h.ranking = ranking
+}
+
 // Generate handles GET /briefings/{agent_id}.
 func (h *BriefingHandler) Generate(w http.ResponseWriter, r *http.Request) {
 	requestingAgent := middleware.AgentIDFromContext(r.Context())
@@ -48,7 +55,26 @@ func (h *BriefingHandler) Generate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	briefing, err := h.assembler.Generate(r.Context(), targetAgent, since, maxItems)
+	// Items keep their plain relevance unless a profile is asked for
+	var profile *store.RankingProfile
+	if name := r.URL.Query().Get("profile"); name != "" {
+		var err error
+		profile, err = resolveProfile(r.Context(), h.ranking, name)
+		if errors.Is(err, store.ErrProfileNotFound) {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Unknown ranking profile '"+name+"'")
+			return
+		}
+		if err != nil {
+			writeProfileError(w, name, err)
+			return
+		}
+		if profile == nil {
+			defaults := store.BuiltInProfiles(store.DefaultHalfLives())[0]
+			profile = &defaults
+		}
+	}
+
+	briefing, err := h.assembler.Generate(r.Context(), targetAgent, since, maxItems, profile)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate briefing")
 		return
@@ -58,7 +58,21 @@ func (h *DevicesHandler) Create(w http.ResponseWriter, r *http.Request) {
 // List handles GET /devices.
 func (h *DevicesHandler) List(w http.ResponseWriter, r *http.Request) {
 	ownerID := r.URL.Query().Get("owner_id")
-	
+
+	if q := r.URL.Query(); wantsPage(q) {
+		var owner *string
+		if ownerID != "" {
+			owner = &ownerID
+		}
+		devices, info, err := h.devices.ListPage(r.Context(), owner, parsePageRequest(q))
+		if err != nil {
+			writeListError(w, err, "Failed to list devices")
+			return
+		}
+		writePage(w, devices, info)
+		return
+	}
+
 	var devices []store.Device
 	var err error
 
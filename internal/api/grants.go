@@ -94,6 +94,16 @@ func (h *GrantsHandler) List(w http.ResponseWriter, r *http.Request) {
 		subjectID = &si
 	}
 
+	if wantsPage(query) {
+		grants, info, err := h.grants.ListPage(r.Context(), resourceType, resourceID, subjectType, subjectID, parsePageRequest(query))
+		if err != nil {
+			writeListError(w, err, "Failed to list grants")
+			return
+		}
+		writePage(w, grants, info)
+		return
+	}
+
 	grants, err := h.grants.List(r.Context(), resourceType, resourceID, subjectType, subjectID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list grants")
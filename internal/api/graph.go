@@ -32,27 +32,39 @@ func (h *GraphHandler) ListEntities(w http.ResponseWriter, r *http.Request) {
 		entityType = &et
 	}
 
-	limit := 50
-	if v := q.Get("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			limit = n
-		}
-	}
-	offset := 0
+	// Legacy offset paging is kept for existing callers; everything else
+	// uses keyset cursors.
 	if v := q.Get("offset"); v != "" {
+		limit := 50
+		if v := q.Get("limit"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				limit = n
+			}
+		}
+		offset := 0
 		if n, err := strconv.Atoi(v); err == nil {
 			offset = n
 		}
+
+		entities, err := h.graph.ListEntities(r.Context(), entityType, limit, offset)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list entities")
+			return
+		}
+
+		_ = h.audit.Log(r.Context(), store.ActionGraphRead, agentID, nil, nil, true, nil)
+		writeSuccess(w, http.StatusOK, entities)
+		return
 	}
 
-	entities, err := h.graph.ListEntities(r.Context(), entityType, limit, offset)
+	entities, info, err := h.graph.ListEntitiesPage(r.Context(), entityType, parsePageRequest(q))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list entities")
+		writeListError(w, err, "Failed to list entities")
 		return
 	}
 
 	_ = h.audit.Log(r.Context(), store.ActionGraphRead, agentID, nil, nil, true, nil)
-	writeSuccess(w, http.StatusOK, entities)
+	writePage(w, entities, info)
 }
 
 // GetEntity handles GET /graph/entities/{id}.
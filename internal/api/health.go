@@ -6,7 +6,11 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
 	"github.com/MikeSquared-Agency/Alexandria/internal/hermes"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/reaper"
+	"github.com/MikeSquared-Agency/Alexandria/internal/rotation"
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
 )
 
@@ -16,20 +20,35 @@ type HealthHandler struct {
 	knowledge *store.KnowledgeStore
 	secrets   *store.SecretStore
 	hermes    *hermes.Client
+	reaper    *reaper.Reaper
 	startTime time.Time
+
+	encryption *encryption.Status  // set before serving
+	rotation   *rotation.Scheduler // optional
 }
 
 // NewHealthHandler creates a new HealthHandler.
-func NewHealthHandler(db *store.DB, knowledge *store.KnowledgeStore, secrets *store.SecretStore, hermesClient *hermes.Client) *HealthHandler {
+func NewHealthHandler(db *store.DB, knowledge *store.KnowledgeStore, secrets *store.SecretStore, hermesClient *hermes.Client, knowledgeReaper *reaper.Reaper) *HealthHandler {
 	return &HealthHandler{
 		db:        db,
 		knowledge: knowledge,
 		secrets:   secrets,
 		hermes:    hermesClient,
+		reaper:    knowledgeReaper,
 		startTime: time.Now(),
 	}
 }
 
+// SetEncryption sets the encryption status reported by Health.
+func (h *HealthHandler) SetEncryption(status encryption.Status) {
+	h.encryption = &status
+}
+
+// SetRotation makes Stats report the secret rotation scheduler's activity.
+func (h *HealthHandler) SetRotation(s *rotation.Scheduler) {
+	h.rotation = s
+}
+
 // Health returns the service health status.
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -58,20 +77,55 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 		resp["status"] = "degraded"
 	}
 
+	if h.encryption != nil {
+		resp["encryption"] = h.encryption
+		if !h.encryption.Healthy() {
+			resp["status"] = "degraded"
+		}
+	}
+
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// Stats returns detailed service statistics.
+// Stats returns detailed service statistics. GETs need no API key, so only
+// warren sees per-agent usage and the names of overdue secrets; everyone
+// else gets counts.
 func (h *HealthHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	admin := middleware.AgentIDFromContext(ctx) == "warren"
 	knowledgeCount, _ := h.knowledge.Count(ctx)
 	secretCount, _ := h.secrets.Count(ctx)
+	lifecycle, _ := h.knowledge.LifecycleStats(ctx)
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"knowledge_count": knowledgeCount,
-		"secret_count":    secretCount,
-		"uptime_seconds":  int(time.Since(h.startTime).Seconds()),
-	})
+	resp := map[string]any{
+		"knowledge_count":          knowledgeCount,
+		"knowledge_soft_deleted":   lifecycle.SoftDeleted,
+		"knowledge_pending_expiry": lifecycle.PendingExpiry,
+		"secret_count":             secretCount,
+		"uptime_seconds":           int(time.Since(h.startTime).Seconds()),
+	}
+	if admin {
+		if usage, err := h.knowledge.UsageByAgent(ctx); err == nil {
+			resp["knowledge_usage"] = usage
+		}
+	}
+	if h.reaper != nil {
+		resp["reaper"] = h.reaper.Stats()
+	}
+	if overdue, err := h.secrets.ListRotationDue(ctx, nil, 0); err == nil {
+		if overdue == nil {
+			overdue = []store.RotationDue{}
+		}
+		resp["secret_rotation_overdue_count"] = len(overdue)
+		if admin {
+			resp["secret_rotation_overdue"] = overdue
+		}
+	}
+	if h.rotation != nil {
+		resp["secret_rotation"] = h.rotation.Stats()
+	}
+
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // writeJSON writes a JSON response.
@@ -94,12 +148,36 @@ func writeError(w http.ResponseWriter, status int, code, message string) {
 	})
 }
 
-// writeSuccess writes a standard success response.
-func writeSuccess(w http.ResponseWriter, status int, data any) {
+// writeErrorDetails writes a JSON error response with a details payload.
+func writeErrorDetails(w http.ResponseWriter, status int, code, message string, details any) {
 	writeJSON(w, status, map[string]any{
-		"data": data,
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+			"details": details,
+		},
 		"meta": map[string]any{
 			"timestamp": time.Now().Format(time.RFC3339),
 		},
 	})
 }
+
+// writeSuccess writes a standard success response.
+func writeSuccess(w http.ResponseWriter, status int, data any) {
+	writeSuccessMeta(w, status, data, nil)
+}
+
+// writeSuccessMeta writes a success response with extra meta fields
+// alongside the timestamp.
+func writeSuccessMeta(w http.ResponseWriter, status int, data any, extra map[string]any) {
+	meta := map[string]any{
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	for k, v := range extra {
+		meta[k] = v
+	}
+	writeJSON(w, status, map[string]any{
+		"data": data,
+		"meta": meta,
+	})
+}
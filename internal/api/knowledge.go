@@ -2,14 +2,24 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	pgvector "github.com/pgvector/pgvector-go"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/chunking"
 	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
 	"github.com/MikeSquared-Agency/Alexandria/internal/hermes"
 	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+	"github.com/MikeSquared-Agency/Alexandria/internal/watch"
 )
 
 // KnowledgeHandler provides knowledge CRUD and search endpoints.
@@ -18,6 +28,9 @@ type KnowledgeHandler struct {
 	audit     *store.AuditStore
 	embedder  embeddings.Provider
 	publisher *hermes.Publisher
+	chunker   *chunking.Indexer
+	ranking   *store.RankingStore
+	watcher   *watch.Watcher
 }
 
 // NewKnowledgeHandler creates a new KnowledgeHandler.
@@ -30,6 +43,35 @@ func NewKnowledgeHandler(knowledge *store.KnowledgeStore, audit *store.AuditStor
 	}
 }
 
+// SetChunker makes writes queue the entry for re-chunking.
+func (h *KnowledgeHandler) SetChunker(chunker *chunking.Indexer) {
+	h.chunker = chunker
+}
+
+// SetRanking lets searches select a named ranking profile.
+func (h *KnowledgeHandler) SetRanking(ranking *store.RankingStore) {
+	h.ranking = ranking
+}
+
+// SetWatcher checks new entries against agents' saved searches.
+func (h *KnowledgeHandler) SetWatcher(watcher *watch.Watcher) {
+	h.watcher = watcher
+}
+
+// watchMatches queues a new entry to be checked against saved searches.
+func (h *KnowledgeHandler) watchMatches(entry *store.KnowledgeEntry) {
+	if h.watcher != nil {
+		h.watcher.Enqueue(entry)
+	}
+}
+
+// rechunk queues an entry whose content may have changed for chunking.
+func (h *KnowledgeHandler) rechunk(id string) {
+	if h.chunker != nil {
+		h.chunker.Enqueue(id)
+	}
+}
+
 // CreateRequest is the request body for creating knowledge.
 type CreateRequest struct {
 	Content        string                  `json:"content"`
@@ -41,25 +83,20 @@ type CreateRequest struct {
 	Metadata       map[string]any          `json:"metadata,omitempty"`
 	Confidence     *float64                `json:"confidence,omitempty"`
 	RelevanceDecay *store.RelevanceDecay   `json:"relevance_decay,omitempty"`
+	Dedupe         store.DedupePolicy      `json:"dedupe,omitempty"` // overrides the server's dedupe policy
 }
 
-// Create handles POST /knowledge.
-func (h *KnowledgeHandler) Create(w http.ResponseWriter, r *http.Request) {
-	agentID := middleware.AgentIDFromContext(r.Context())
-
-	var req CreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
-		return
-	}
-
+// buildCreateInput validates req and applies the defaults for confidence,
+// decay, scope and category. It returns a validation message if req is invalid.
+func buildCreateInput(req CreateRequest, agentID string) (store.KnowledgeCreateInput, string) {
 	if req.Content == "" {
-		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Content is required")
-		return
+		return store.KnowledgeCreateInput{}, "Content is required"
 	}
 	if len(req.Content) > 102400 {
-		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Content exceeds 100KB limit")
-		return
+		return store.KnowledgeCreateInput{}, "Content exceeds 100KB limit"
+	}
+	if req.Dedupe != "" && !req.Dedupe.Valid() {
+		return store.KnowledgeCreateInput{}, "dedupe must be one of none, reject, merge_tags, bump_confidence"
 	}
 
 	confidence := 0.8
@@ -77,14 +114,7 @@ func (h *KnowledgeHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Category = store.CategoryDiscovery
 	}
 
-	// Generate embedding
-	embedding, err := h.embedder.Embed(r.Context(), req.Content)
-	if err != nil {
-		// Log but don't fail — store without embedding
-		_ = err
-	}
-
-	input := store.KnowledgeCreateInput{
+	return store.KnowledgeCreateInput{
 		Content:        req.Content,
 		Summary:        req.Summary,
 		SourceAgent:    agentID,
@@ -92,34 +122,126 @@ func (h *KnowledgeHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Scope:          req.Scope,
 		SharedWith:     req.SharedWith,
 		Tags:           req.Tags,
-		Embedding:      embedding,
 		Metadata:       req.Metadata,
 		Confidence:     confidence,
 		RelevanceDecay: decay,
+		Dedupe:         req.Dedupe,
+	}, ""
+}
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header.
+const maxIdempotencyKeyLen = 255
+
+// idempotencyKeyPrefix namespaces Idempotency-Key values in source_event_id
+// so they cannot collide with Hermes event IDs.
+const idempotencyKeyPrefix = "http:"
+
+// idempotencyKey returns the request's Idempotency-Key header as a source
+// event ID, or a validation message if it is too long. It returns "" if
+// there is no key.
+func idempotencyKey(r *http.Request) (string, string) {
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(key) > maxIdempotencyKeyLen {
+		return "", "Idempotency-Key must be at most 255 characters"
+	}
+	if key == "" {
+		return "", ""
+	}
+	return idempotencyKeyPrefix + key, ""
+}
+
+// Create handles POST /knowledge.
+func (h *KnowledgeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	input, msg := buildCreateInput(req, agentID)
+	if msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+	key, msg := idempotencyKey(r)
+	if msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+	if key != "" {
+		input.SourceEventID = &key
 	}
 
-	entry, err := h.knowledge.Create(r.Context(), input)
+	// Generate embedding
+	embedding, err := h.embedder.Embed(r.Context(), req.Content)
 	if err != nil {
+		// Log but don't fail — store without embedding; the backfill retries it
+		_ = err
+	} else {
+		input.EmbeddingModel = embeddings.ModelName(h.embedder)
+	}
+	input.Embedding = embedding
+
+	result, err := h.knowledge.CreateWithOutcome(r.Context(), input)
+	if err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			writeError(w, http.StatusConflict, "DUPLICATE_KNOWLEDGE", "Duplicates knowledge entry '"+dup.Existing.ID+"'")
+			return
+		}
+		if writeQuotaError(w, err) {
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create knowledge entry")
 		return
 	}
+	entry := result.Entry
+
+	if result.Outcome == store.OutcomeReplayed {
+		// Only an unedited original can be compared with the retried body.
+		if entry.Version == 1 && entry.Content != input.Content {
+			writeError(w, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for different content")
+			return
+		}
+		writeSuccessMeta(w, http.StatusOK, entry, map[string]any{"idempotent_replay": true})
+		return
+	}
 
 	// Audit
-	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, &entry.ID, nil, true, nil)
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, &entry.ID, nil, true, map[string]any{
+		"outcome": result.Outcome,
+	})
+
+	if result.Outcome == store.OutcomeCreated {
+		h.rechunk(entry.ID)
+		h.watchMatches(entry)
+	}
 
 	// Publish event
 	if h.publisher != nil {
-		_ = h.publisher.KnowledgeCreated(r.Context(), entry)
+		if result.Outcome == store.OutcomeCreated {
+			_ = h.publisher.KnowledgeCreated(r.Context(), entry)
+		} else {
+			_ = h.publisher.KnowledgeUpdated(r.Context(), entry)
+		}
 	}
 
+	if result.Outcome != store.OutcomeCreated {
+		writeSuccessMeta(w, http.StatusOK, entry, map[string]any{"dedupe": result.Outcome})
+		return
+	}
+	if result.DuplicateOf != "" {
+		writeSuccessMeta(w, http.StatusCreated, entry, map[string]any{"duplicate_of": result.DuplicateOf})
+		return
+	}
 	writeSuccess(w, http.StatusCreated, entry)
 }
 
-// List handles GET /knowledge.
-func (h *KnowledgeHandler) List(w http.ResponseWriter, r *http.Request) {
-	agentID := middleware.AgentIDFromContext(r.Context())
-	q := r.URL.Query()
-
+// parseKnowledgeFilter reads the filter parameters shared by GET /knowledge
+// and GET /knowledge/facets. It returns a validation message if q is invalid.
+func parseKnowledgeFilter(q url.Values, agentID string) (store.KnowledgeFilter, string) {
 	filter := store.KnowledgeFilter{
 		AgentID: agentID,
 		Limit:   50,
@@ -142,25 +264,109 @@ func (h *KnowledgeHandler) List(w http.ResponseWriter, r *http.Request) {
 	if v := q["tags"]; len(v) > 0 {
 		filter.Tags = append(filter.Tags, v...)
 	}
-	if v := q.Get("limit"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			filter.Limit = n
+	filter.TagsAll = q["tags_all"]
+	filter.TagsNone = q["tags_none"]
+
+	for param, dest := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, param + " must be an RFC3339 timestamp"
+			}
+			*dest = &t
 		}
 	}
+	if v := q.Get("min_confidence"); v != "" {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil || c < 0 || c > 1 {
+			return filter, "min_confidence must be a number between 0 and 1"
+		}
+		filter.MinConfidence = &c
+	}
+	for _, v := range q["metadata"] {
+		p, err := store.ParseMetadataPredicate(v)
+		if err != nil {
+			return filter, err.Error()
+		}
+		filter.Metadata = append(filter.Metadata, p)
+	}
+	return filter, ""
+}
+
+// List handles GET /knowledge.
+func (h *KnowledgeHandler) List(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	filter, msg := parseKnowledgeFilter(q, agentID)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	// Legacy offset paging is kept for existing callers; everything else
+	// uses keyset cursors.
 	if v := q.Get("offset"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			filter.Offset = n
 		}
+		if v := q.Get("limit"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				filter.Limit = n
+			}
+		}
+
+		entries, err := h.knowledge.List(r.Context(), filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list knowledge")
+			return
+		}
+
+		_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, nil, nil, true, nil)
+		writeSuccess(w, http.StatusOK, entries)
+		return
 	}
 
-	entries, err := h.knowledge.List(r.Context(), filter)
+	entries, info, err := h.knowledge.ListPage(r.Context(), filter, parsePageRequest(q))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list knowledge")
+		writeListError(w, err, "Failed to list knowledge")
 		return
 	}
 
 	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, nil, nil, true, nil)
-	writeSuccess(w, http.StatusOK, entries)
+	writePage(w, entries, info)
+}
+
+// Facets handles GET /knowledge/facets. It accepts the same filters as List
+// and counts the matching entries per tag, category, scope and source agent.
+func (h *KnowledgeHandler) Facets(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	filter, msg := parseKnowledgeFilter(q, agentID)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	tagLimit := 100
+	if v := q.Get("tag_limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			tagLimit = n
+		}
+	}
+
+	facets, err := h.knowledge.Facets(r.Context(), filter, tagLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to count knowledge facets")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, nil, nil, true, map[string]any{
+		"facets": true,
+	})
+	writeSuccess(w, http.StatusOK, facets)
 }
 
 // Get handles GET /knowledge/{id}.
@@ -198,13 +404,14 @@ func (h *KnowledgeHandler) Update(w http.ResponseWriter, r *http.Request) {
 		emb, err := h.embedder.Embed(r.Context(), *input.Content)
 		if err == nil {
 			input.Embedding = &emb
+			input.EmbeddingModel = embeddings.ModelName(h.embedder)
 		}
 	}
 
 	entry, err := h.knowledge.Update(r.Context(), id, agentID, input)
 	if err != nil {
-		if err.Error() == "access denied: only owner or admin can update" {
-			writeError(w, http.StatusForbidden, "ACCESS_DENIED", err.Error())
+		if errors.Is(err, store.ErrAccessDenied) {
+			writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can update this entry")
 			return
 		}
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update knowledge entry")
@@ -216,6 +423,7 @@ func (h *KnowledgeHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, &id, nil, true, nil)
+	h.rechunk(id)
 
 	if h.publisher != nil {
 		_ = h.publisher.KnowledgeUpdated(r.Context(), entry)
@@ -230,11 +438,11 @@ func (h *KnowledgeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	if err := h.knowledge.Delete(r.Context(), id, agentID); err != nil {
-		if err.Error() == "access denied" {
+		if errors.Is(err, store.ErrAccessDenied) {
 			writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can delete this entry")
 			return
 		}
-		if err.Error() == "not found" {
+		if errors.Is(err, store.ErrKnowledgeNotFound) {
 			writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
 			return
 		}
@@ -246,73 +454,551 @@ func (h *KnowledgeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	writeSuccess(w, http.StatusOK, map[string]string{"deleted": id})
 }
 
-// SearchRequest is the request body for semantic search.
+// Trash handles GET /knowledge/trash. Agents see their own deleted entries;
+// warren sees all and may filter by source_agent.
+func (h *KnowledgeHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	filter := store.TrashFilter{AgentID: agentID}
+	if v := q.Get("source_agent"); v != "" {
+		filter.SourceAgent = &v
+	}
+	if v := q.Get("deleted_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "deleted_after must be an RFC3339 timestamp")
+			return
+		}
+		filter.DeletedAfter = &t
+	}
+
+	entries, info, err := h.knowledge.ListTrash(r.Context(), filter, parsePageRequest(q))
+	if err != nil {
+		writeListError(w, err, "Failed to list deleted knowledge")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeTrash, agentID, nil, nil, true, map[string]any{
+		"count": len(entries),
+	})
+	writePage(w, entries, info)
+}
+
+// Restore handles POST /knowledge/{id}/restore.
+func (h *KnowledgeHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	entry, err := h.knowledge.Restore(r.Context(), id, agentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrKnowledgeNotFound):
+			writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No deleted knowledge entry with ID '"+id+"'")
+		case errors.Is(err, store.ErrAccessDenied):
+			_ = h.audit.Log(r.Context(), store.ActionKnowledgeRestore, agentID, &id, nil, false, nil)
+			writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can restore this entry")
+		case errors.Is(err, store.ErrQuotaExceeded):
+			writeQuotaError(w, err)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to restore knowledge entry")
+		}
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRestore, agentID, &id, nil, true, nil)
+	if h.publisher != nil {
+		_ = h.publisher.KnowledgeUpdated(r.Context(), entry)
+	}
+	writeSuccess(w, http.StatusOK, entry)
+}
+
+// writeVersionError maps knowledge version store errors to API responses.
+func writeVersionError(w http.ResponseWriter, err error, id string) {
+	switch {
+	case errors.Is(err, store.ErrKnowledgeNotFound):
+		writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+	case errors.Is(err, store.ErrAccessDenied):
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can access this entry's history")
+	case errors.Is(err, store.ErrVersionNotFound):
+		writeError(w, http.StatusNotFound, "VERSION_NOT_FOUND", "No such version for knowledge entry '"+id+"'")
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to access knowledge history")
+	}
+}
+
+// Versions handles GET /knowledge/{id}/versions.
+func (h *KnowledgeHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	versions, err := h.knowledge.ListVersions(r.Context(), id, agentID)
+	if err != nil {
+		writeVersionError(w, err, id)
+		return
+	}
+	if versions == nil {
+		versions = []store.KnowledgeVersion{}
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, &id, nil, true, map[string]any{
+		"history": true,
+	})
+	writeSuccess(w, http.StatusOK, versions)
+}
+
+// GetVersion handles GET /knowledge/{id}/versions/{n}.
+func (h *KnowledgeHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Version must be a positive integer")
+		return
+	}
+
+	version, err := h.knowledge.GetVersion(r.Context(), id, agentID, n)
+	if err != nil {
+		writeVersionError(w, err, id)
+		return
+	}
+	if version == nil {
+		writeVersionError(w, store.ErrVersionNotFound, id)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, &id, nil, true, map[string]any{
+		"version": n,
+	})
+	writeSuccess(w, http.StatusOK, version)
+}
+
+// Revert handles POST /knowledge/{id}/revert/{n}.
+func (h *KnowledgeHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Version must be a positive integer")
+		return
+	}
+
+	entry, err := h.knowledge.Revert(r.Context(), id, agentID, n)
+	if err != nil {
+		writeVersionError(w, err, id)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, &id, nil, true, map[string]any{
+		"reverted_to": n,
+	})
+	h.rechunk(id)
+
+	if h.publisher != nil {
+		_ = h.publisher.KnowledgeUpdated(r.Context(), entry)
+	}
+
+	writeSuccess(w, http.StatusOK, entry)
+}
+
+// Supersede handles POST /knowledge/{id}/supersede. The body is a CreateRequest
+// for the replacement; category, scope, sharing and tags default to the
+// original entry's values when omitted.
+func (h *KnowledgeHandler) Supersede(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	original, err := h.knowledge.GetByID(r.Context(), id, agentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get knowledge entry")
+		return
+	}
+	if original == nil {
+		writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+		return
+	}
+	if req.Category == "" {
+		req.Category = original.Category
+	}
+	if req.Scope == "" {
+		req.Scope = original.Scope
+		if req.SharedWith == nil {
+			req.SharedWith = original.SharedWith
+		}
+	}
+	if req.Tags == nil {
+		req.Tags = original.Tags
+	}
+
+	input, msg := buildCreateInput(req, agentID)
+	if msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+	if embedding, err := h.embedder.Embed(r.Context(), req.Content); err == nil {
+		input.Embedding = embedding
+		input.EmbeddingModel = embeddings.ModelName(h.embedder)
+	}
+
+	old, replacement, err := h.knowledge.Supersede(r.Context(), id, agentID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrKnowledgeNotFound):
+			writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+		case errors.Is(err, store.ErrAccessDenied):
+			writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only the owner or admin can supersede this entry")
+		case errors.Is(err, store.ErrAlreadySuperseded):
+			writeError(w, http.StatusConflict, "ALREADY_SUPERSEDED", "Knowledge entry '"+id+"' has already been superseded")
+		case errors.Is(err, store.ErrQuotaExceeded):
+			writeQuotaError(w, err)
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to supersede knowledge entry")
+		}
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, &replacement.ID, nil, true, map[string]any{
+		"supersedes": id,
+	})
+	h.rechunk(replacement.ID)
+	h.watchMatches(replacement)
+
+	if h.publisher != nil {
+		_ = h.publisher.KnowledgeCreated(r.Context(), replacement)
+		_ = h.publisher.KnowledgeSuperseded(r.Context(), old, replacement, agentID)
+	}
+
+	writeSuccess(w, http.StatusCreated, map[string]any{
+		"superseded":  old,
+		"replacement": replacement,
+	})
+}
+
+// Lineage handles GET /knowledge/{id}/lineage.
+func (h *KnowledgeHandler) Lineage(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	chain, err := h.knowledge.Lineage(r.Context(), id, agentID)
+	if err != nil {
+		if errors.Is(err, store.ErrKnowledgeNotFound) {
+			writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get knowledge lineage")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeRead, agentID, &id, nil, true, map[string]any{
+		"lineage": len(chain),
+	})
+	writeSuccess(w, http.StatusOK, chain)
+}
+
+// Limits on the inputs combined into one search.
+const (
+	maxSearchQueries  = 10
+	maxSearchExamples = 20
+)
+
+// SearchRequest is the request body for semantic search. A search needs a
+// query, queries, or positive example IDs. Several queries and examples are
+// combined into a single query vector; negative examples steer away from
+// their content.
 type SearchRequest struct {
 	Query          string                    `json:"query"`
+	Queries        []string                  `json:"queries,omitempty"`
+	PositiveIDs    []string                  `json:"positive_ids,omitempty"`
+	NegativeIDs    []string                  `json:"negative_ids,omitempty"`
+	Mode           store.SearchMode          `json:"mode,omitempty"`
 	Limit          int                       `json:"limit,omitempty"`
 	Scope          *store.KnowledgeScope     `json:"scope,omitempty"`
 	Categories     []store.KnowledgeCategory `json:"categories,omitempty"`
-	MinRelevance   float64                   `json:"min_relevance,omitempty"`
+	MinRelevance   float64                   `json:"min_relevance,omitempty"` // hybrid: least cosine similarity of a vector match
 	IncludeExpired bool                      `json:"include_expired,omitempty"`
+	// Explain adds a breakdown of each result's relevance.
+	Explain bool `json:"explain,omitempty"`
+	// Profile names the ranking profile; empty means the default.
+	Profile string `json:"profile,omitempty"`
 }
 
-// Search handles POST /knowledge/search.
+// Search handles POST /api/v1/knowledge/search.
 func (h *KnowledgeHandler) Search(w http.ResponseWriter, r *http.Request) {
-	agentID := middleware.AgentIDFromContext(r.Context())
-
 	var req SearchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
 		return
 	}
+	h.search(w, r, req, "")
+}
 
-	if req.Query == "" {
+// Similar handles POST /api/v1/knowledge/{id}/similar: a search using the
+// entry's stored embedding as a positive example, so nothing is re-embedded
+// unless the optional body adds queries. The body takes the same fields as
+// Search. The entry itself is left out of the results.
+func (h *KnowledgeHandler) Similar(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+		return
+	}
+
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	req.PositiveIDs = append([]string{id}, req.PositiveIDs...)
+	h.search(w, r, req, id)
+}
+
+// search runs req for the requesting agent. similarTo is the entry a
+// similar search started from, if any.
+func (h *KnowledgeHandler) search(w http.ResponseWriter, r *http.Request, req SearchRequest, similarTo string) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+
+	var texts []string
+	for _, q := range append([]string{req.Query}, req.Queries...) {
+		if q = strings.TrimSpace(q); q != "" {
+			texts = append(texts, q)
+		}
+	}
+	if len(texts) == 0 && len(req.PositiveIDs) == 0 {
+		if len(req.NegativeIDs) > 0 {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "negative_ids need a query or positive_ids")
+			return
+		}
 		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Query is required")
 		return
 	}
+	if len(texts) > maxSearchQueries {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "At most "+strconv.Itoa(maxSearchQueries)+" queries are allowed")
+		return
+	}
+	examples := append(append([]string{}, req.PositiveIDs...), req.NegativeIDs...)
+	if len(examples) > maxSearchExamples {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "At most "+strconv.Itoa(maxSearchExamples)+" example IDs are allowed")
+		return
+	}
+	for _, id := range examples {
+		if _, err := uuid.Parse(id); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Invalid example ID '"+id+"'")
+			return
+		}
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = store.SearchModeVector
+	case store.SearchModeVector, store.SearchModeLexical, store.SearchModeHybrid:
+	default:
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "mode must be 'vector', 'lexical', or 'hybrid'")
+		return
+	}
+	if req.Mode == store.SearchModeLexical && len(examples) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Example IDs need 'vector' or 'hybrid' mode")
+		return
+	}
+	// Examples alone have no text to match lexically.
+	if req.Mode == store.SearchModeHybrid && len(texts) == 0 {
+		req.Mode = store.SearchModeVector
+	}
 
-	// Generate query embedding
-	queryEmbedding, err := h.embedder.Embed(r.Context(), req.Query)
+	profile, err := resolveProfile(r.Context(), h.ranking, req.Profile)
+	if errors.Is(err, store.ErrProfileNotFound) {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Unknown ranking profile '"+req.Profile+"'")
+		return
+	}
 	if err != nil {
-		writeError(w, http.StatusBadGateway, "EMBEDDING_FAILED", "Failed to generate query embedding")
+		writeProfileError(w, req.Profile, err)
 		return
 	}
 
+	// Build the query embedding (lexical search matches on text alone)
+	var queryEmbedding pgvector.Vector
+	if req.Mode != store.SearchModeLexical {
+		var ok bool
+		queryEmbedding, ok = h.queryEmbedding(w, r, agentID, texts, req.PositiveIDs, req.NegativeIDs)
+		if !ok {
+			return
+		}
+	}
+
+	// websearch_to_tsquery treats "or" as alternation.
+	queryText := strings.Join(texts, " or ")
 	results, err := h.knowledge.Search(r.Context(), store.SearchInput{
 		QueryEmbedding: queryEmbedding,
+		QueryText:      queryText,
+		Mode:           req.Mode,
 		Limit:          req.Limit,
 		Scope:          req.Scope,
 		Categories:     req.Categories,
 		AgentID:        agentID,
 		MinRelevance:   req.MinRelevance,
 		IncludeExpired: req.IncludeExpired,
+		Explain:        req.Explain,
+		Profile:        profile,
+		ExcludeIDs:     examples,
 	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Search failed")
 		return
 	}
 
-	_ = h.audit.Log(r.Context(), store.ActionKnowledgeSearch, agentID, nil, nil, true, map[string]any{
-		"query":        req.Query,
+	meta := map[string]any{
+		"query":        queryText,
+		"mode":         req.Mode,
 		"result_count": len(results),
-	})
+	}
+	if len(texts) > 1 {
+		meta["queries"] = texts
+	}
+	if len(req.PositiveIDs) > 0 {
+		meta["positive_ids"] = req.PositiveIDs
+	}
+	if len(req.NegativeIDs) > 0 {
+		meta["negative_ids"] = req.NegativeIDs
+	}
+	var target *string
+	if similarTo != "" {
+		meta["similar_to"] = similarTo
+		target = &similarTo
+	}
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeSearch, agentID, target, nil, true, meta)
 
 	if h.publisher != nil {
-		_ = h.publisher.KnowledgeSearched(r.Context(), agentID, req.Query, len(results))
+		_ = h.publisher.KnowledgeSearched(r.Context(), agentID, queryText, len(results))
 	}
 
-	writeSuccess(w, http.StatusOK, map[string]any{
+	data := map[string]any{
 		"results":       results,
 		"total_results": len(results),
-	})
+		"mode":          req.Mode,
+	}
+	if similarTo != "" {
+		data["similar_to"] = similarTo
+	}
+	writeSuccess(w, http.StatusOK, data)
+}
+
+// queryEmbedding combines the embeddings of texts and of the positive
+// examples, less those of the negative examples, into one query vector.
+// Examples use their stored embeddings and must be visible to agentID. On
+// failure it writes the error response and returns false.
+func (h *KnowledgeHandler) queryEmbedding(w http.ResponseWriter, r *http.Request, agentID string, texts, positiveIDs, negativeIDs []string) (pgvector.Vector, bool) {
+	var positive, negative []pgvector.Vector
+	if len(texts) > 0 {
+		vecs, err := embeddings.EmbedAll(r.Context(), h.embedder, texts)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "EMBEDDING_FAILED", "Failed to generate query embedding")
+			return pgvector.Vector{}, false
+		}
+		positive = vecs
+	}
+
+	if len(positiveIDs)+len(negativeIDs) > 0 {
+		stored, err := h.knowledge.ExampleEmbeddings(r.Context(), append(append([]string{}, positiveIDs...), negativeIDs...), agentID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load example entries")
+			return pgvector.Vector{}, false
+		}
+		collect := func(ids []string) ([]pgvector.Vector, bool) {
+			vecs := make([]pgvector.Vector, 0, len(ids))
+			for _, id := range ids {
+				vec, found := stored[id]
+				if !found {
+					writeError(w, http.StatusNotFound, "KNOWLEDGE_NOT_FOUND", "No knowledge entry with ID '"+id+"'")
+					return nil, false
+				}
+				if vec == nil {
+					writeError(w, http.StatusUnprocessableEntity, "EMBEDDING_MISSING", "Knowledge entry '"+id+"' has no embedding yet")
+					return nil, false
+				}
+				vecs = append(vecs, *vec)
+			}
+			return vecs, true
+		}
+		vecs, ok := collect(positiveIDs)
+		if !ok {
+			return pgvector.Vector{}, false
+		}
+		positive = append(positive, vecs...)
+		if negative, ok = collect(negativeIDs); !ok {
+			return pgvector.Vector{}, false
+		}
+	}
+
+	if len(positive) == 1 && len(negative) == 0 {
+		return positive[0], true
+	}
+	combined, err := embeddings.Combine(positive, negative)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Cannot combine query embeddings: "+err.Error())
+		return pgvector.Vector{}, false
+	}
+	return combined, true
 }
 
 // BatchCreateRequest is the request body for batch knowledge creation.
 type BatchCreateRequest struct {
 	Entries []CreateRequest `json:"entries"`
+	// Atomic validates every entry up front and stores all of them in one
+	// transaction, or none.
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+// BatchItemResult reports what happened to one batch entry.
+type BatchItemResult struct {
+	Index  int               `json:"index"`
+	Status string            `json:"status"` // created, merged, linked, replayed, error
+	ID     string            `json:"id,omitempty"`
+	Error  *BatchItemFailure `json:"error,omitempty"`
 }
 
-// BatchCreate handles POST /knowledge/batch.
+// BatchItemFailure is the error for a failed batch entry.
+type BatchItemFailure struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchFailure maps a store error for one entry to an error code.
+func batchFailure(err error) *BatchItemFailure {
+	var dup *store.DuplicateError
+	if errors.As(err, &dup) {
+		return &BatchItemFailure{Code: "DUPLICATE_KNOWLEDGE", Message: "Duplicates knowledge entry '" + dup.Existing.ID + "'"}
+	}
+	var quota *store.QuotaError
+	if errors.As(err, &quota) {
+		return &BatchItemFailure{Code: "QUOTA_EXCEEDED", Message: quota.Error()}
+	}
+	return &BatchItemFailure{Code: "INTERNAL_ERROR", Message: "Failed to create knowledge entry"}
+}
+
+// batchFailureStatus is the HTTP status for a batch failure code when it
+// fails the whole request.
+func batchFailureStatus(code string) int {
+	switch code {
+	case "VALIDATION_ERROR":
+		return http.StatusUnprocessableEntity
+	case "DUPLICATE_KNOWLEDGE":
+		return http.StatusConflict
+	case "QUOTA_EXCEEDED":
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// BatchCreate handles POST /knowledge/batch. A non-atomic batch answers 201
+// when every entry was stored, 207 Multi-Status when only some were, and
+// fails like a single create when none were.
 func (h *KnowledgeHandler) BatchCreate(w http.ResponseWriter, r *http.Request) {
 	agentID := middleware.AgentIDFromContext(r.Context())
 
@@ -331,47 +1017,140 @@ func (h *KnowledgeHandler) BatchCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var created []*store.KnowledgeEntry
-	for _, entry := range req.Entries {
-		confidence := 0.8
-		if entry.Confidence != nil {
-			confidence = *entry.Confidence
+	key, msg := idempotencyKey(r)
+	if msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+
+	// Validate everything up front with the same rules as Create.
+	results := make([]BatchItemResult, len(req.Entries))
+	inputs := make([]store.KnowledgeCreateInput, 0, len(req.Entries))
+	indexes := make([]int, 0, len(req.Entries))
+	invalid := 0
+	for i, entry := range req.Entries {
+		results[i].Index = i
+		input, msg := buildCreateInput(entry, agentID)
+		if msg != "" {
+			results[i].Status = "error"
+			results[i].Error = &BatchItemFailure{Code: "VALIDATION_ERROR", Message: msg}
+			invalid++
+			continue
 		}
-		decay := store.DecaySlow
-		if entry.RelevanceDecay != nil {
-			decay = *entry.RelevanceDecay
+		if key != "" {
+			// Each entry gets its own key so a retried batch replays entry by entry.
+			itemKey := key + ":" + strconv.Itoa(i)
+			input.SourceEventID = &itemKey
 		}
+		inputs = append(inputs, input)
+		indexes = append(indexes, i)
+	}
 
-		embedding, _ := h.embedder.Embed(r.Context(), entry.Content)
+	if req.Atomic && invalid > 0 {
+		writeErrorDetails(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "One or more entries are invalid; nothing was stored", results)
+		return
+	}
+	if invalid == len(req.Entries) {
+		writeErrorDetails(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "No entry is valid; nothing was stored", results)
+		return
+	}
 
-		input := store.KnowledgeCreateInput{
-			Content:        entry.Content,
-			Summary:        entry.Summary,
-			SourceAgent:    agentID,
-			Category:       entry.Category,
-			Scope:          entry.Scope,
-			SharedWith:     entry.SharedWith,
-			Tags:           entry.Tags,
-			Embedding:      embedding,
-			Metadata:       entry.Metadata,
-			Confidence:     confidence,
-			RelevanceDecay: decay,
+	// One embedding call for the whole batch; entries are stored without
+	// embeddings if it fails, as in Create.
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		texts[i] = input.Content
+	}
+	if vecs, err := embeddings.EmbedAll(r.Context(), h.embedder, texts); err == nil {
+		model := embeddings.ModelName(h.embedder)
+		for i := range inputs {
+			inputs[i].Embedding = vecs[i]
+			inputs[i].EmbeddingModel = model
 		}
+	}
 
-		result, err := h.knowledge.Create(r.Context(), input)
+	var stored []store.KnowledgeCreateResult
+	if req.Atomic {
+		var err error
+		stored, err = h.knowledge.CreateBatch(r.Context(), inputs)
 		if err != nil {
-			continue // skip failed entries in batch
+			var itemErr *store.BatchItemError
+			if errors.As(err, &itemErr) {
+				idx := indexes[itemErr.Index]
+				results[idx].Status = "error"
+				results[idx].Error = batchFailure(itemErr.Err)
+				writeErrorDetails(w, batchFailureStatus(results[idx].Error.Code), results[idx].Error.Code, "Entry "+strconv.Itoa(idx)+" failed; nothing was stored", []BatchItemResult{results[idx]})
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create knowledge entries")
+			return
+		}
+	} else {
+		stored = make([]store.KnowledgeCreateResult, len(inputs))
+		for i, input := range inputs {
+			result, err := h.knowledge.CreateWithOutcome(r.Context(), input)
+			if err != nil {
+				idx := indexes[i]
+				results[idx].Status = "error"
+				results[idx].Error = batchFailure(err)
+				continue
+			}
+			stored[i] = *result
+		}
+	}
+
+	created := []*store.KnowledgeEntry{}
+	failed := invalid
+	for i, result := range stored {
+		idx := indexes[i]
+		if result.Entry == nil {
+			failed++
+			continue
+		}
+		results[idx].Status = string(result.Outcome)
+		results[idx].ID = result.Entry.ID
+		if result.Outcome == store.OutcomeCreated || result.Outcome == store.OutcomeReplayed {
+			created = append(created, result.Entry)
+		}
+		if result.Outcome == store.OutcomeCreated {
+			h.rechunk(result.Entry.ID)
+			h.watchMatches(result.Entry)
 		}
-		created = append(created, result)
 	}
 
 	_ = h.audit.Log(r.Context(), store.ActionKnowledgeWrite, agentID, nil, nil, true, map[string]any{
 		"batch_size": len(req.Entries),
 		"created":    len(created),
+		"failed":     failed,
+		"atomic":     req.Atomic,
 	})
 
-	writeSuccess(w, http.StatusCreated, map[string]any{
+	if failed == len(req.Entries) {
+		// Nothing was stored: report it as an error, with the common code
+		// if every entry failed the same way.
+		code := results[0].Error.Code
+		for _, result := range results[1:] {
+			if result.Error.Code != code {
+				code = "BATCH_FAILED"
+				break
+			}
+		}
+		status := http.StatusUnprocessableEntity
+		if code != "BATCH_FAILED" {
+			status = batchFailureStatus(code)
+		}
+		writeErrorDetails(w, status, code, "No entries were stored", results)
+		return
+	}
+
+	status := http.StatusCreated
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	writeSuccess(w, status, map[string]any{
 		"created": created,
 		"count":   len(created),
+		"failed":  failed,
+		"results": results,
 	})
 }
@@ -0,0 +1,411 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	pgvector "github.com/pgvector/pgvector-go"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+const (
+	// importChunkSize is how many import records are embedded per provider call.
+	importChunkSize = 100
+	// maxImportLine bounds one NDJSON record: 100KB of content plus an embedding.
+	maxImportLine = 4 << 20
+	// maxImportErrors caps the per-line errors echoed back by an import.
+	maxImportErrors = 100
+	// bulkIdleTimeout is how long an export or import may go without
+	// progress before its connection deadlines pass. These routes run
+	// outside the request timeout and extend the deadlines as they go.
+	bulkIdleTimeout = time.Minute
+)
+
+// ExportSummary is the last line of every complete export, written as
+// {"export_summary": {...}}. Import refuses input without it, so a stream
+// that was cut off is not half imported.
+type ExportSummary struct {
+	Count  int    `json:"count"`  // records before the summary
+	SHA256 string `json:"sha256"` // hex digest of those record lines, newlines included
+}
+
+// exportTrailer is the line an ExportSummary is written as.
+type exportTrailer struct {
+	Summary *ExportSummary `json:"export_summary"`
+}
+
+// exportTrailerPrefix starts the trailer line and no record line.
+var exportTrailerPrefix = []byte(`{"export_summary"`)
+
+// extendDeadlines pushes the connection's read and write deadlines
+// bulkIdleTimeout ahead. Writers that don't support deadlines are left as
+// they are.
+func extendDeadlines(rc *http.ResponseController) {
+	deadline := time.Now().Add(bulkIdleTimeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
+
+// ImportLineError reports a record that could not be imported.
+type ImportLineError struct {
+	Line    int    `json:"line"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportSummary is the response body of POST /knowledge/import.
+type ImportSummary struct {
+	Inserted  int               `json:"inserted"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Errors    []ImportLineError `json:"errors,omitempty"`
+}
+
+func (s *ImportSummary) fail(line int, id, msg string) {
+	s.Failed++
+	if len(s.Errors) < maxImportErrors {
+		s.Errors = append(s.Errors, ImportLineError{Line: line, ID: id, Message: msg})
+	}
+}
+
+// Export handles GET /knowledge/export. It streams one JSON entry per line
+// and ends with an ExportSummary line once every entry has been written.
+func (h *KnowledgeHandler) Export(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	filter := store.ExportFilter{
+		AgentID:        agentID,
+		WithEmbeddings: q.Get("include_embeddings") == "true",
+	}
+	if v := q.Get("source_agent"); v != "" {
+		filter.SourceAgent = &v
+	}
+	if v := q.Get("category"); v != "" {
+		cat := store.KnowledgeCategory(v)
+		filter.Category = &cat
+	}
+	if v := q.Get("tag"); v != "" {
+		filter.Tags = []string{v}
+	}
+	if v := q["tags"]; len(v) > 0 {
+		filter.Tags = append(filter.Tags, v...)
+	}
+	for param, dest := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", param+" must be an RFC3339 timestamp")
+				return
+			}
+			*dest = &t
+		}
+	}
+
+	var model string
+	if filter.WithEmbeddings && h.embedder != nil {
+		model = embeddings.ModelName(h.embedder)
+	}
+
+	// Headers are sent with the first record so a failed query can still
+	// return a normal error response.
+	rc := http.NewResponseController(w)
+	extendDeadlines(rc)
+	digest := sha256.New()
+	enc := json.NewEncoder(io.MultiWriter(w, digest))
+	count := 0
+	err := h.knowledge.ExportKnowledge(r.Context(), filter, func(rec *store.KnowledgeExportRecord) error {
+		if count == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		// Vectors from before models were recorded are assumed current.
+		if len(rec.Vector) > 0 && rec.EmbeddingModel == "" {
+			rec.EmbeddingModel = model
+		}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+		count++
+		if count%importChunkSize == 0 {
+			_ = rc.Flush()
+			extendDeadlines(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		if count == 0 {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export knowledge")
+			return
+		}
+		// The stream is already under way; the missing summary line tells
+		// the client it was cut short.
+		slog.Error("knowledge export interrupted", "agent_id", agentID, "written", count, "error", err)
+	} else {
+		if count == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		_ = json.NewEncoder(w).Encode(exportTrailer{Summary: &ExportSummary{
+			Count:  count,
+			SHA256: hex.EncodeToString(digest.Sum(nil)),
+		}})
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeExport, agentID, nil, nil, err == nil, map[string]any{
+		"count":              count,
+		"include_embeddings": filter.WithEmbeddings,
+	})
+}
+
+// spoolImport copies an import body to a temporary file and checks it
+// against its ExportSummary line. The caller removes the file. A non-empty
+// message means the body is incomplete or corrupt and nothing may be
+// imported.
+func spoolImport(body io.Reader, rc *http.ResponseController) (*os.File, string, error) {
+	spool, err := os.CreateTemp("", "alexandria-import-*.ndjson")
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := sha256.New()
+	out := bufio.NewWriter(spool)
+	var summary *ExportSummary
+	count := 0
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	for line := 1; scanner.Scan(); line++ {
+		if line%importChunkSize == 0 {
+			extendDeadlines(rc)
+		}
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			_ = out.WriteByte('\n') // keeps line numbers
+			continue
+		}
+		if summary != nil {
+			return spool, fmt.Sprintf("line %d: records after the export summary", line), nil
+		}
+		if bytes.HasPrefix(text, exportTrailerPrefix) {
+			var trailer exportTrailer
+			if err := json.Unmarshal(text, &trailer); err != nil || trailer.Summary == nil {
+				return spool, fmt.Sprintf("line %d: invalid export summary", line), nil
+			}
+			summary = trailer.Summary
+			continue
+		}
+		count++
+		digest.Write(text)
+		digest.Write([]byte{'\n'})
+		out.Write(text)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return spool, "reading body: " + err.Error(), nil
+	}
+	if err := out.Flush(); err != nil {
+		return spool, "", err
+	}
+
+	switch {
+	case summary == nil:
+		return spool, "missing export summary line; the input may be truncated", nil
+	case summary.Count != count:
+		return spool, fmt.Sprintf("export summary counts %d records, found %d", summary.Count, count), nil
+	case summary.SHA256 != "" && summary.SHA256 != hex.EncodeToString(digest.Sum(nil)):
+		return spool, "export summary checksum does not match the records", nil
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return spool, "", err
+	}
+	return spool, "", nil
+}
+
+// Import handles POST /knowledge/import. The body is NDJSON in the export
+// format, ending with its ExportSummary line; entries are upserted by ID.
+// The body is checked against the summary before anything is stored. Only
+// warren may import, since records keep their original owner.
+func (h *KnowledgeHandler) Import(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can import knowledge")
+		return
+	}
+	reembed := r.URL.Query().Get("reembed") == "true"
+
+	rc := http.NewResponseController(w)
+	extendDeadlines(rc)
+	spool, msg, err := spoolImport(r.Body, rc)
+	if spool != nil {
+		defer func() {
+			spool.Close()
+			os.Remove(spool.Name())
+		}()
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to buffer import")
+		return
+	}
+	if msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+
+	var model string
+	if h.embedder != nil {
+		model = embeddings.ModelName(h.embedder)
+	}
+
+	type pending struct {
+		line int
+		rec  store.KnowledgeExportRecord
+	}
+	var summary ImportSummary
+	supersede := map[string]string{} // id -> replacement, linked after every record is in
+
+	flush := func(chunk []pending) {
+		// Vectors are kept unless re-embedding was asked for, they are
+		// missing, or they came from a different model.
+		var texts []string
+		var needs []int
+		for i, p := range chunk {
+			if reembed || len(p.rec.Vector) != embeddings.Dimensions ||
+				(p.rec.EmbeddingModel != "" && p.rec.EmbeddingModel != model) {
+				texts = append(texts, p.rec.Content)
+				needs = append(needs, i)
+			}
+		}
+		vecs := make([]pgvector.Vector, len(chunk))
+		for i, p := range chunk {
+			if len(p.rec.Vector) == embeddings.Dimensions {
+				vecs[i] = pgvector.NewVector(p.rec.Vector)
+			}
+		}
+		if len(texts) > 0 && h.embedder != nil {
+			embedded, err := embeddings.EmbedAll(r.Context(), h.embedder, texts)
+			if err == nil {
+				for j, i := range needs {
+					vecs[i] = embedded[j]
+				}
+			} else {
+				// Better unembedded than embedded by another model.
+				for _, i := range needs {
+					vecs[i] = pgvector.Vector{}
+				}
+			}
+		}
+
+		for i, p := range chunk {
+			entry, outcome, err := h.knowledge.ImportKnowledge(r.Context(), p.rec, vecs[i], agentID)
+			if err != nil {
+				summary.fail(p.line, p.rec.ID, err.Error())
+				continue
+			}
+			switch outcome {
+			case store.ImportInserted:
+				summary.Inserted++
+				h.rechunk(entry.ID)
+			case store.ImportUpdated:
+				summary.Updated++
+				h.rechunk(entry.ID)
+			case store.ImportUnchanged:
+				summary.Unchanged++
+			}
+			if p.rec.SupersededBy != nil && entry.SupersededBy == nil {
+				supersede[p.rec.ID] = *p.rec.SupersededBy
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(spool)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	var chunk []pending
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec store.KnowledgeExportRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			summary.fail(line, "", "invalid JSON: "+err.Error())
+			continue
+		}
+		if msg := validateImportRecord(&rec); msg != "" {
+			summary.fail(line, rec.ID, msg)
+			continue
+		}
+		chunk = append(chunk, pending{line: line, rec: rec})
+		if len(chunk) == importChunkSize {
+			flush(chunk)
+			chunk = nil
+			extendDeadlines(rc)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		summary.fail(line+1, "", "reading body: "+err.Error())
+	}
+	if len(chunk) > 0 {
+		flush(chunk)
+	}
+
+	for id, replacement := range supersede {
+		if err := h.knowledge.LinkSuperseded(r.Context(), id, replacement); err != nil {
+			summary.fail(0, id, "superseded_by "+replacement+" was not imported")
+		}
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionKnowledgeImport, agentID, nil, nil, summary.Failed == 0, map[string]any{
+		"inserted":  summary.Inserted,
+		"updated":   summary.Updated,
+		"unchanged": summary.Unchanged,
+		"failed":    summary.Failed,
+		"reembed":   reembed,
+	})
+
+	writeSuccess(w, http.StatusOK, summary)
+}
+
+// validateImportRecord checks the fields an import cannot default and fills
+// in the rest the way Create does. It returns a validation message if rec is
+// invalid.
+func validateImportRecord(rec *store.KnowledgeExportRecord) string {
+	switch {
+	case rec.ID == "":
+		return "id is required"
+	case rec.Content == "":
+		return "content is required"
+	case len(rec.Content) > 102400:
+		return "content exceeds 100KB limit"
+	case rec.SourceAgent == "":
+		return "source_agent is required"
+	}
+	if rec.Scope == "" {
+		rec.Scope = store.ScopePublic
+	}
+	if rec.Category == "" {
+		rec.Category = store.CategoryDiscovery
+	}
+	if rec.RelevanceDecay == "" {
+		rec.RelevanceDecay = store.DecaySlow
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// parsePageRequest reads limit, cursor and include_total query parameters.
+func parsePageRequest(q url.Values) store.PageRequest {
+	page := store.PageRequest{
+		Cursor:       q.Get("cursor"),
+		IncludeTotal: q.Get("include_total") == "true",
+	}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			page.Limit = n
+		}
+	}
+	return page
+}
+
+// wantsPage reports whether the caller asked for cursor pagination on an
+// endpoint that historically returned every row.
+func wantsPage(q url.Values) bool {
+	return q.Has("cursor") || q.Has("limit") || q.Has("include_total")
+}
+
+// writePage writes a list response with next_cursor and total in meta.
+func writePage(w http.ResponseWriter, data any, info store.PageInfo) {
+	meta := map[string]any{}
+	if info.NextCursor != "" {
+		meta["next_cursor"] = info.NextCursor
+	}
+	if info.Total != nil {
+		meta["total"] = *info.Total
+	}
+	writeSuccessMeta(w, http.StatusOK, data, meta)
+}
+
+// writeListError maps a paginated listing error to a response.
+func writeListError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, store.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "INVALID_CURSOR", "Cursor is malformed or expired")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+}
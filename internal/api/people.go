@@ -57,6 +57,16 @@ func (h *PeopleHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // List handles GET /people.
 func (h *PeopleHandler) List(w http.ResponseWriter, r *http.Request) {
+	if q := r.URL.Query(); wantsPage(q) {
+		people, info, err := h.people.ListPage(r.Context(), parsePageRequest(q))
+		if err != nil {
+			writeListError(w, err, "Failed to list people")
+			return
+		}
+		writePage(w, people, info)
+		return
+	}
+
 	people, err := h.people.List(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list people")
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// RankingHandler manages named ranking profiles. Any agent may read them;
+// only warren may change them.
+type RankingHandler struct {
+	ranking *store.RankingStore
+	audit   *store.AuditStore
+}
+
+// NewRankingHandler creates a new RankingHandler.
+func NewRankingHandler(ranking *store.RankingStore, audit *store.AuditStore) *RankingHandler {
+	return &RankingHandler{ranking: ranking, audit: audit}
+}
+
+// List handles GET /ranking-profiles.
+func (h *RankingHandler) List(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.ranking.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list ranking profiles")
+		return
+	}
+	writeSuccess(w, http.StatusOK, profiles)
+}
+
+// Get handles GET /ranking-profiles/{name}.
+func (h *RankingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	profile, err := h.ranking.Get(r.Context(), name)
+	if err != nil {
+		writeProfileError(w, name, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, profile)
+}
+
+// Put handles PUT /ranking-profiles/{name}.
+func (h *RankingHandler) Put(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can change ranking profiles")
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	var profile store.RankingProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	profile.Name = name
+
+	saved, err := h.ranking.Put(r.Context(), profile, agentID)
+	if err != nil {
+		writeProfileError(w, name, err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionRankingWrite, agentID, &name, nil, true, nil)
+	writeSuccess(w, http.StatusOK, saved)
+}
+
+// Delete handles DELETE /ranking-profiles/{name}. Deleting a saved
+// override restores the built-in profile of that name.
+func (h *RankingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can change ranking profiles")
+		return
+	}
+	name := chi.URLParam(r, "name")
+
+	if err := h.ranking.Delete(r.Context(), name); err != nil {
+		writeProfileError(w, name, err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionRankingWrite, agentID, &name, nil, true, map[string]any{"deleted": true})
+	writeSuccess(w, http.StatusOK, map[string]string{"deleted": name})
+}
+
+// resolveProfile looks up a requested ranking profile. Without a ranking
+// store only the built-in default is available, returned as nil.
+func resolveProfile(ctx context.Context, ranking *store.RankingStore, name string) (*store.RankingProfile, error) {
+	if ranking == nil {
+		if name == "" || name == store.DefaultProfileName {
+			return nil, nil
+		}
+		return nil, store.ErrProfileNotFound
+	}
+	return ranking.Get(ctx, name)
+}
+
+func writeProfileError(w http.ResponseWriter, name string, err error) {
+	switch {
+	case errors.Is(err, store.ErrProfileNotFound):
+		writeError(w, http.StatusNotFound, "PROFILE_NOT_FOUND", "No ranking profile named '"+name+"'")
+	case errors.Is(err, store.ErrInvalidProfile):
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load ranking profile")
+	}
+}
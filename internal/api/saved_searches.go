@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// SavedSearchHandler manages an agent's saved searches. Agents only see
+// and change their own.
+type SavedSearchHandler struct {
+	searches *store.SavedSearchStore
+	audit    *store.AuditStore
+	embedder embeddings.Provider
+}
+
+// NewSavedSearchHandler creates a new SavedSearchHandler.
+func NewSavedSearchHandler(searches *store.SavedSearchStore, audit *store.AuditStore, embedder embeddings.Provider) *SavedSearchHandler {
+	return &SavedSearchHandler{searches: searches, audit: audit, embedder: embedder}
+}
+
+// List handles GET /saved-searches.
+func (h *SavedSearchHandler) List(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	searches, err := h.searches.List(r.Context(), agentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list saved searches")
+		return
+	}
+	writeSuccess(w, http.StatusOK, searches)
+}
+
+// Get handles GET /saved-searches/{id}.
+func (h *SavedSearchHandler) Get(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+	search, err := h.searches.Get(r.Context(), id, agentID)
+	if err != nil {
+		writeSavedSearchError(w, id, err)
+		return
+	}
+	writeSuccess(w, http.StatusOK, search)
+}
+
+// Create handles POST /saved-searches.
+func (h *SavedSearchHandler) Create(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	input, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	search, err := h.searches.Create(r.Context(), agentID, input)
+	if err != nil {
+		writeSavedSearchError(w, "", err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSavedSearchWrite, agentID, &search.ID, nil, true, map[string]any{
+		"name": search.Name,
+	})
+	writeSuccess(w, http.StatusCreated, search)
+}
+
+// Update handles PUT /saved-searches/{id}, replacing the saved search. An
+// omitted webhook_secret keeps the current one; "" removes it.
+func (h *SavedSearchHandler) Update(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+	input, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	search, err := h.searches.Update(r.Context(), id, agentID, input)
+	if err != nil {
+		writeSavedSearchError(w, id, err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSavedSearchWrite, agentID, &id, nil, true, map[string]any{
+		"name": search.Name,
+	})
+	writeSuccess(w, http.StatusOK, search)
+}
+
+// Delete handles DELETE /saved-searches/{id}.
+func (h *SavedSearchHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.searches.Delete(r.Context(), id, agentID); err != nil {
+		writeSavedSearchError(w, id, err)
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSavedSearchWrite, agentID, &id, nil, true, map[string]any{"deleted": true})
+	writeSuccess(w, http.StatusOK, map[string]string{"deleted": id})
+}
+
+// decodeInput reads and validates a saved search body and embeds its query.
+// If embedding fails the search is saved without one and the watcher embeds
+// it later. On failure it writes the error response and returns false.
+func (h *SavedSearchHandler) decodeInput(w http.ResponseWriter, r *http.Request) (store.SavedSearchInput, bool) {
+	var input store.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return input, false
+	}
+	if err := input.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return input, false
+	}
+	if input.Mode != store.SearchModeLexical {
+		if embedding, err := h.embedder.Embed(r.Context(), input.Query); err == nil {
+			input.QueryEmbedding = embedding
+			input.EmbeddingModel = embeddings.ModelName(h.embedder)
+		}
+	}
+	return input, true
+}
+
+func savedSearchID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		writeError(w, http.StatusNotFound, "SAVED_SEARCH_NOT_FOUND", "No saved search with ID '"+id+"'")
+		return "", false
+	}
+	return id, true
+}
+
+func writeSavedSearchError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, store.ErrSavedSearchNotFound):
+		writeError(w, http.StatusNotFound, "SAVED_SEARCH_NOT_FOUND", "No saved search with ID '"+id+"'")
+	case errors.Is(err, store.ErrSavedSearchExists):
+		writeError(w, http.StatusConflict, "SAVED_SEARCH_EXISTS", err.Error())
+	case errors.Is(err, store.ErrInvalidSavedSearch):
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save search")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
 	"github.com/MikeSquared-Agency/Alexandria/internal/hermes"
 	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/rotation"
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
 )
 
@@ -16,12 +17,13 @@ type SecretHandler struct {
 	secrets   *store.SecretStore
 	grants    *store.GrantStore
 	audit     *store.AuditStore
-	encryptor *encryption.Encryptor
+	encryptor encryption.Encryptor
 	publisher *hermes.Publisher
+	rotation  *rotation.Scheduler // optional; validates rotation generators
 }
 
 // NewSecretHandler creates a new SecretHandler.
-func NewSecretHandler(secrets *store.SecretStore, grants *store.GrantStore, audit *store.AuditStore, encryptor *encryption.Encryptor, publisher *hermes.Publisher) *SecretHandler {
+func NewSecretHandler(secrets *store.SecretStore, grants *store.GrantStore, audit *store.AuditStore, encryptor encryption.Encryptor, publisher *hermes.Publisher) *SecretHandler {
 	return &SecretHandler{
 		secrets:   secrets,
 		grants:    grants,
@@ -31,15 +33,35 @@ func NewSecretHandler(secrets *store.SecretStore, grants *store.GrantStore, audi
 	}
 }
 
+// SetRotation makes the handler reject rotation generators the scheduler
+// does not know.
+func (h *SecretHandler) SetRotation(s *rotation.Scheduler) {
+	h.rotation = s
+}
+
 // SecretCreateRequest is the request body for creating a secret.
 type SecretCreateRequest struct {
-	Name                 string   `json:"name"`
-	Value                string   `json:"value"`
-	Description          *string  `json:"description,omitempty"`
-	Scope                []string `json:"scope,omitempty"` // Kept for backward compatibility
-	RotationIntervalDays *int     `json:"rotation_interval_days,omitempty"`
-	OwnerType            *string  `json:"owner_type,omitempty"` // New: 'agent', 'person', 'device'
-	OwnerID              *string  `json:"owner_id,omitempty"`   // New: owner UUID or agent name
+	Name                 string         `json:"name"`
+	Value                string         `json:"value"`
+	Description          *string        `json:"description,omitempty"`
+	Scope                []string       `json:"scope,omitempty"` // Kept for backward compatibility
+	RotationIntervalDays *int           `json:"rotation_interval_days,omitempty"`
+	RotationGenerator    *string        `json:"rotation_generator,omitempty"` // generator for scheduled rotation
+	RotationOptions      map[string]any `json:"rotation_options,omitempty"`
+	OwnerType            *string        `json:"owner_type,omitempty"` // New: 'agent', 'person', 'device'
+	OwnerID              *string        `json:"owner_id,omitempty"`   // New: owner UUID or agent name
+}
+
+// validateRotation checks a rotation interval and generator, returning a
+// message if they are invalid.
+func (h *SecretHandler) validateRotation(intervalDays *int, generator *string) string {
+	if intervalDays != nil && *intervalDays < 1 {
+		return "rotation_interval_days must be at least 1"
+	}
+	if generator != nil && *generator != "" && h.rotation != nil && !h.rotation.HasGenerator(*generator) {
+		return "Unknown rotation_generator '" + *generator + "'"
+	}
+	return ""
 }
 
 // getSubjectFromHeaders extracts subject info from request headers.
@@ -91,12 +113,20 @@ func (h *SecretHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Name and value are required")
 		return
 	}
+	if req.Name == store.CanarySecretName {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Secret name '"+req.Name+"' is reserved")
+		return
+	}
 	if len(req.Value) > 10240 {
 		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Secret value exceeds 10KB limit")
 		return
 	}
+	if msg := h.validateRotation(req.RotationIntervalDays, req.RotationGenerator); msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
 
-	encrypted, err := h.encryptor.Encrypt(req.Value)
+	sealed, err := h.encryptor.Seal(r.Context(), req.Value)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ENCRYPTION_FAILED", "Failed to encrypt secret")
 		return
@@ -114,10 +144,12 @@ func (h *SecretHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	secret, err := h.secrets.Create(r.Context(), store.SecretCreateInput{
 		Name:                 req.Name,
-		EncryptedValue:       encrypted,
+		Value:                sealed,
 		Description:          req.Description,
 		Scope:                req.Scope, // Kept for backward compatibility
 		RotationIntervalDays: req.RotationIntervalDays,
+		RotationGenerator:    req.RotationGenerator,
+		RotationOptions:      req.RotationOptions,
 		CreatedBy:            agentID,
 		OwnerType:            &ownerType,
 		OwnerID:              &ownerID,
@@ -187,7 +219,7 @@ func (h *SecretHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Decrypt
-	value, err := h.encryptor.Decrypt(secret.EncryptedValue)
+	value, err := h.encryptor.Open(r.Context(), secret.Sealed())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ENCRYPTION_FAILED", "Failed to decrypt secret")
 		return
@@ -237,13 +269,13 @@ func (h *SecretHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encrypted, err := h.encryptor.Encrypt(req.Value)
+	sealed, err := h.encryptor.Seal(r.Context(), req.Value)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ENCRYPTION_FAILED", "Failed to encrypt secret")
 		return
 	}
 
-	if err := h.secrets.Update(r.Context(), name, encrypted); err != nil {
+	if err := h.secrets.Update(r.Context(), name, sealed, agentID); err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update secret")
 		return
 	}
@@ -323,13 +355,13 @@ func (h *SecretHandler) Rotate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encrypted, err := h.encryptor.Encrypt(req.NewValue)
+	sealed, err := h.encryptor.Seal(r.Context(), req.NewValue)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "ENCRYPTION_FAILED", "Failed to encrypt new value")
 		return
 	}
 
-	if err := h.secrets.Rotate(r.Context(), name, encrypted, agentID); err != nil {
+	if err := h.secrets.Rotate(r.Context(), name, sealed, agentID); err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to rotate secret")
 		return
 	}
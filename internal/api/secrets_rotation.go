@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+// RotationPolicyRequest is the request body for setting a secret's
+// scheduled rotation. Omitted or null fields are cleared.
+type RotationPolicyRequest struct {
+	RotationIntervalDays *int           `json:"rotation_interval_days"`
+	RotationGenerator    *string        `json:"rotation_generator"`
+	RotationOptions      map[string]any `json:"rotation_options"`
+}
+
+// SetRotationPolicy handles PUT /secrets/{name}/rotation. It needs admin
+// permission, since the generator decides the secret's future values.
+func (h *SecretHandler) SetRotationPolicy(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	name := chi.URLParam(r, "name")
+
+	var req RotationPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if req.RotationGenerator != nil && *req.RotationGenerator == "" {
+		req.RotationGenerator = nil
+	}
+	if msg := h.validateRotation(req.RotationIntervalDays, req.RotationGenerator); msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", msg)
+		return
+	}
+
+	secret, err := h.secrets.GetByName(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get secret")
+		return
+	}
+	if secret == nil {
+		writeError(w, http.StatusNotFound, "SECRET_NOT_FOUND", "No secret with name '"+name+"'")
+		return
+	}
+
+	hasAccess, _ := h.checkSecretAccess(r, secret, "admin")
+	if !hasAccess {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Subject not authorized to change this secret's rotation")
+		return
+	}
+
+	policy := store.RotationPolicy{
+		IntervalDays: req.RotationIntervalDays,
+		Generator:    req.RotationGenerator,
+		Options:      req.RotationOptions,
+	}
+	if err := h.secrets.SetRotationPolicy(r.Context(), name, policy); err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to set rotation policy")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSecretWrite, agentID, &name, nil, true, map[string]any{
+		"rotation_interval_days": req.RotationIntervalDays,
+		"rotation_generator":     req.RotationGenerator,
+	})
+	writeSuccess(w, http.StatusOK, policy)
+}
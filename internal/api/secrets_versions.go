@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// checkSecretAdmin reports whether the subject holds admin permission on a
+// secret. Unlike checkSecretAccess, the secret's legacy scope is not enough:
+// it only grants the current value. warren is always admin.
+func (h *SecretHandler) checkSecretAdmin(r *http.Request, secret *store.Secret) (bool, string) {
+	subjectType, subjectID := h.getSubjectFromHeaders(r)
+	if subjectType == "agent" && subjectID == "warren" {
+		return true, subjectID
+	}
+	hasAccess, err := h.grants.CheckAccessWithPermission(r.Context(), subjectType, subjectID, "secret", secret.Name, "admin")
+	if err != nil {
+		return false, subjectID
+	}
+	return hasAccess, subjectID
+}
+
+// versionParam parses the {n} URL parameter, writing a 400 if it is not a
+// positive integer.
+func versionParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Version must be a positive integer")
+		return 0, false
+	}
+	return n, true
+}
+
+// Versions handles GET /secrets/{name}/versions — lists previous versions
+// without their values.
+func (h *SecretHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	name := chi.URLParam(r, "name")
+
+	secret, err := h.secrets.GetByName(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get secret")
+		return
+	}
+	if secret == nil {
+		writeError(w, http.StatusNotFound, "SECRET_NOT_FOUND", "No secret with name '"+name+"'")
+		return
+	}
+
+	hasAccess, _ := h.checkSecretAccess(r, secret, "read")
+	if !hasAccess {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Subject not authorized to access this secret")
+		return
+	}
+
+	versions, err := h.secrets.ListVersions(r.Context(), secret.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list secret versions")
+		return
+	}
+	if versions == nil {
+		versions = []store.SecretVersion{}
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSecretRead, agentID, &name, nil, true, map[string]any{
+		"history": true,
+	})
+	writeSuccess(w, http.StatusOK, map[string]any{
+		"name":     secret.Name,
+		"version":  secret.Version,
+		"versions": versions,
+	})
+}
+
+// GetVersion handles GET /secrets/{name}/versions/{n} — returns a previous
+// value decrypted. Requires admin permission on the secret (audited).
+func (h *SecretHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	name := chi.URLParam(r, "name")
+
+	n, ok := versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := h.secrets.GetByName(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get secret")
+		return
+	}
+	if secret == nil {
+		_ = h.audit.Log(r.Context(), store.ActionSecretRead, agentID, &name, nil, false, map[string]any{"version": n})
+		writeError(w, http.StatusNotFound, "SECRET_NOT_FOUND", "No secret with name '"+name+"'")
+		return
+	}
+
+	hasAccess, subjectID := h.checkSecretAdmin(r, secret)
+	if !hasAccess {
+		_ = h.audit.Log(r.Context(), store.ActionSecretRead, agentID, &name, nil, false, map[string]any{"version": n})
+		if h.publisher != nil {
+			_ = h.publisher.SecretAccessed(r.Context(), subjectID, name, false)
+		}
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Subject not authorized to read this secret's history")
+		return
+	}
+
+	version, err := h.secrets.GetVersion(r.Context(), secret.ID, n)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get secret version")
+		return
+	}
+	if version == nil {
+		writeError(w, http.StatusNotFound, "VERSION_NOT_FOUND", "No such version for secret '"+name+"'")
+		return
+	}
+
+	value, err := h.encryptor.Open(r.Context(), version.Sealed())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "ENCRYPTION_FAILED", "Failed to decrypt secret")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSecretRead, agentID, &name, nil, true, map[string]any{"version": n})
+	if h.publisher != nil {
+		_ = h.publisher.SecretAccessed(r.Context(), subjectID, name, true)
+	}
+
+	writeSuccess(w, http.StatusOK, map[string]any{
+		"name":       secret.Name,
+		"version":    version.Version,
+		"value":      value,
+		"rotated_at": version.RotatedAt,
+		"rotated_by": version.RotatedBy,
+	})
+}
+
+// Rollback handles POST /secrets/{name}/rollback/{n} — makes a previous
+// value current again as a new version.
+func (h *SecretHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	name := chi.URLParam(r, "name")
+
+	n, ok := versionParam(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := h.secrets.GetByName(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get secret")
+		return
+	}
+	if secret == nil {
+		writeError(w, http.StatusNotFound, "SECRET_NOT_FOUND", "No secret with name '"+name+"'")
+		return
+	}
+
+	hasAccess, _ := h.checkSecretAccess(r, secret, "write")
+	if !hasAccess {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Subject not authorized to roll back this secret")
+		return
+	}
+
+	newVersion, err := h.secrets.Rollback(r.Context(), secret.ID, n, agentID)
+	if err != nil {
+		if errors.Is(err, store.ErrVersionNotFound) {
+			writeError(w, http.StatusNotFound, "VERSION_NOT_FOUND", "No such version for secret '"+name+"'")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to roll back secret")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionSecretRotate, agentID, &name, nil, true, map[string]any{
+		"rolled_back_to": n,
+	})
+	if h.publisher != nil {
+		_ = h.publisher.SecretRotated(r.Context(), name, agentID)
+	}
+
+	writeSuccess(w, http.StatusOK, map[string]any{
+		"rolled_back": name,
+		"to_version":  n,
+		"version":     newVersion,
+	})
+}
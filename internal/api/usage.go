@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// UsageHandler reports agents' knowledge storage and manages their quotas.
+type UsageHandler struct {
+	knowledge *store.KnowledgeStore
+	audit     *store.AuditStore
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(knowledge *store.KnowledgeStore, audit *store.AuditStore) *UsageHandler {
+	return &UsageHandler{knowledge: knowledge, audit: audit}
+}
+
+// Usage handles GET /agents/{id}/usage. Agents may read their own usage;
+// warren may read anyone's.
+func (h *UsageHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	target := chi.URLParam(r, "id")
+	if agentID != target && agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Agents can only read their own usage")
+		return
+	}
+
+	usage, err := h.knowledge.AgentUsage(r.Context(), target)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to compute usage")
+		return
+	}
+	writeSuccess(w, http.StatusOK, usage)
+}
+
+// SetQuota handles PUT /agents/{id}/quota, overriding the default limits
+// the body sets. Omitted or null limits keep the default; 0 is unlimited.
+func (h *UsageHandler) SetQuota(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can change quotas")
+		return
+	}
+	target := chi.URLParam(r, "id")
+
+	var override store.QuotaOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if err := h.knowledge.SetAgentQuota(r.Context(), target, override, agentID); err != nil {
+		if errors.Is(err, store.ErrInvalidQuota) {
+			writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to save quota")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionQuotaWrite, agentID, &target, nil, true, map[string]any{
+		"max_entries":       override.MaxEntries,
+		"max_bytes":         override.MaxBytes,
+		"max_daily_creates": override.MaxDailyCreates,
+	})
+
+	usage, err := h.knowledge.AgentUsage(r.Context(), target)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to compute usage")
+		return
+	}
+	writeSuccess(w, http.StatusOK, usage)
+}
+
+// DeleteQuota handles DELETE /agents/{id}/quota, returning the agent to the
+// default quota.
+func (h *UsageHandler) DeleteQuota(w http.ResponseWriter, r *http.Request) {
+	agentID := middleware.AgentIDFromContext(r.Context())
+	if agentID != "warren" {
+		writeError(w, http.StatusForbidden, "ACCESS_DENIED", "Only warren can change quotas")
+		return
+	}
+	target := chi.URLParam(r, "id")
+
+	deleted, err := h.knowledge.DeleteAgentQuota(r.Context(), target)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete quota")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "QUOTA_NOT_FOUND", "Agent '"+target+"' has no quota override")
+		return
+	}
+
+	_ = h.audit.Log(r.Context(), store.ActionQuotaWrite, agentID, &target, nil, true, map[string]any{"deleted": true})
+	writeSuccess(w, http.StatusOK, map[string]string{"deleted": target})
+}
+
+// writeQuotaError writes a QUOTA_EXCEEDED response if err is a quota error
+// and reports whether it did.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quota *store.QuotaError
+	if !errors.As(err, &quota) {
+		return false
+	}
+	writeErrorDetails(w, http.StatusTooManyRequests, "QUOTA_EXCEEDED", quota.Error(), quota)
+	return true
+}
@@ -0,0 +1,188 @@
+// Package blob provides pluggable storage for binary attachments.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store stores opaque blobs under slash-separated keys.
+type Store interface {
+	// Put writes r under key, replacing any existing blob, and returns the
+	// number of bytes written.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+
+	// Get opens the blob stored under key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// Name returns the backend name for logging.
+	Name() string
+}
+
+// New returns the backend named by backend. Only "filesystem" is supported;
+// root is its base directory.
+func New(backend, root string) (Store, error) {
+	switch backend {
+	case "filesystem", "":
+		return NewFilesystem(root), nil
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", backend)
+	}
+}
+
+// Filesystem stores blobs as files under a root directory.
+type Filesystem struct {
+	root string
+}
+
+// NewFilesystem creates a Filesystem rooted at root. Directories are created
+// on first write.
+func NewFilesystem(root string) *Filesystem {
+	return &Filesystem{root: root}
+}
+
+// Name returns the backend name.
+func (f *Filesystem) Name() string {
+	return "filesystem"
+}
+
+// path maps key to a file under root, rejecting keys that would escape it.
+func (f *Filesystem) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes r to a temporary file and renames it into place, so readers
+// never see a partial blob.
+func (f *Filesystem) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return 0, fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("creating blob: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	n, err := io.Copy(tmp, &ctxReader{ctx: ctx, r: r})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("writing blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return 0, fmt.Errorf("storing blob: %w", err)
+	}
+	return n, nil
+}
+
+// Get opens the file for key.
+func (f *Filesystem) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening blob: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes the file for key.
+func (f *Filesystem) Delete(_ context.Context, key string) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting blob: %w", err)
+	}
+	return nil
+}
+
+// ctxReader stops a copy when its context is cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// activeContentTypes are never taken from a client's declaration, since a
+// browser could render them.
+var activeContentTypes = map[string]bool{
+	"text/html":              true,
+	"application/xhtml+xml":  true,
+	"image/svg+xml":          true,
+	"text/javascript":        true,
+	"application/javascript": true,
+	"text/xml":               true,
+	"application/xml":        true,
+}
+
+// SniffContentType determines the content type of an upload from its first
+// 512 bytes. When sniffing only finds generic text or binary, the declared
+// type or the filename extension is used instead, unless it names an active
+// type.
+func SniffContentType(head []byte, declared, filename string) string {
+	sniffed := http.DetectContentType(head)
+	base, _, _ := mime.ParseMediaType(sniffed)
+	if base != "application/octet-stream" && base != "text/plain" {
+		return sniffed
+	}
+
+	for _, candidate := range []string{declared, mime.TypeByExtension(path.Ext(filename))} {
+		t, params, err := mime.ParseMediaType(candidate)
+		if err != nil || activeContentTypes[t] || strings.HasPrefix(t, "multipart/") {
+			continue
+		}
+		// A binary upload can't be declared as text.
+		if base == "application/octet-stream" && strings.HasPrefix(t, "text/") {
+			continue
+		}
+		if base == "text/plain" && params["charset"] == "" && strings.HasPrefix(t, "text/") {
+			params = map[string]string{"charset": "utf-8"}
+		}
+		return mime.FormatMediaType(t, params)
+	}
+	return sniffed
+}
@@ -4,6 +4,7 @@ package briefings
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
@@ -49,11 +50,21 @@ type BriefingItem struct {
 	Relevance float64   `json:"relevance"`
 }
 
-// Generate assembles a wake-up briefing for the given agent.
-func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Time, maxItems int) (*Briefing, error) {
+// Generate assembles a wake-up briefing for the given agent. Without a
+// ranking profile, events and corrections carry their confidence as
+// relevance; with one, every item is scored by the profile and each section
+// is ordered by that score.
+func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Time, maxItems int, profile *store.RankingProfile) (*Briefing, error) {
 	if maxItems <= 0 || maxItems > 100 {
 		maxItems = 50
 	}
+	now := time.Now()
+	relevance := func(e *store.KnowledgeEntry, fallback float64) float64 {
+		if profile == nil {
+			return fallback
+		}
+		return profile.Factors(e, now).Multiplier()
+	}
 
 	// 1. Recent events — knowledge entries created since the agent last slept
 	scopePublic := store.ScopePublic
@@ -85,7 +96,7 @@ func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Tim
 			Timestamp: &ts,
 			Content:   summary,
 			Source:    source,
-			Relevance: e.Confidence,
+			Relevance: relevance(&e, e.Confidence),
 		})
 	}
 
@@ -110,7 +121,7 @@ func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Tim
 		contextItems = append(contextItems, BriefingItem{
 			Content:   summary,
 			Source:    "vault:knowledge",
-			Relevance: 1.0,
+			Relevance: relevance(&e, 1.0),
 		})
 	}
 
@@ -145,7 +156,7 @@ func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Tim
 			Timestamp: &ts,
 			Content:   formatted,
 			Source:    "dredd:correction",
-			Relevance: e.Confidence,
+			Relevance: relevance(&e, e.Confidence),
 		})
 	}
 
@@ -186,6 +197,13 @@ func (a *Assembler) Generate(ctx context.Context, agentID string, since time.Tim
 		})
 	}
 
+	if profile != nil {
+		for _, section := range sections {
+			items := section.Items
+			sort.SliceStable(items, func(i, j int) bool { return items[i].Relevance > items[j].Relevance })
+		}
+	}
+
 	return &Briefing{
 		AgentID:     agentID,
 		GeneratedAt: time.Now(),
@@ -0,0 +1,74 @@
+// Package chunking splits long knowledge content into overlapping chunks and
+// keeps their embeddings in step with the content.
+package chunking
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk is a slice of a text. Start and End are byte offsets.
+type Chunk struct {
+	Index int
+	Start int
+	End   int
+	Text  string
+}
+
+// breaks are the boundaries a chunk prefers to end on, best first.
+var breaks = []string{"\n\n", "\n", ". ", " "}
+
+// Split cuts text into chunks of at most size bytes, each overlapping the
+// previous by about overlap bytes. Chunks end on a paragraph, line, sentence
+// or word boundary in their second half when there is one, and never split a
+// UTF-8 sequence. Text that fits in one chunk is not split and yields nil.
+func Split(text string, size, overlap int) []Chunk {
+	if size <= 0 || len(text) <= size {
+		return nil
+	}
+	if overlap < 0 || overlap >= size/2 {
+		overlap = size / 4
+	}
+
+	var chunks []Chunk
+	start := 0
+	for {
+		end := start + size
+		if end >= len(text) {
+			end = len(text)
+		} else {
+			end = breakBefore(text, start+size/2, end)
+		}
+		chunks = append(chunks, Chunk{Index: len(chunks), Start: start, End: end, Text: text[start:end]})
+		if end == len(text) {
+			return chunks
+		}
+
+		next := end - overlap
+		// Start the overlap on a word so chunks don't open mid-word.
+		if i := strings.IndexAny(text[next:end], " \n"); i >= 0 {
+			next += i + 1
+		}
+		for next < end && !utf8.RuneStart(text[next]) {
+			next++
+		}
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+}
+
+// breakBefore returns the end of the last preferred boundary in text[min:max],
+// or max backed off to a rune boundary if there is none.
+func breakBefore(text string, min, max int) int {
+	for _, sep := range breaks {
+		if i := strings.LastIndex(text[min:max], sep); i >= 0 {
+			return min + i + len(sep)
+		}
+	}
+	for max > min && !utf8.RuneStart(text[max]) {
+		max--
+	}
+	return max
+}
@@ -0,0 +1,139 @@
+package chunking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// Config controls chunk size and how often stale entries are swept.
+type Config struct {
+	Size      int           // max chunk bytes; entries at or under this are not chunked
+	Overlap   int           // bytes shared by consecutive chunks
+	Interval  time.Duration // sweep interval
+	BatchSize int           // entries chunked per sweep
+}
+
+// Indexer keeps knowledge chunks in step with content. Entries written
+// through the API are queued with Enqueue; a periodic sweep catches every
+// other path (Hermes capture, import, revert) and retries failures.
+type Indexer struct {
+	knowledge *store.KnowledgeStore
+	embedder  embeddings.Provider
+	config    Config
+	logger    *slog.Logger
+	queue     chan string
+}
+
+// New creates an Indexer.
+func New(knowledge *store.KnowledgeStore, embedder embeddings.Provider, cfg Config, logger *slog.Logger) *Indexer {
+	return &Indexer{
+		knowledge: knowledge,
+		embedder:  embedder,
+		config:    cfg,
+		logger:    logger,
+		queue:     make(chan string, 256),
+	}
+}
+
+// Enqueue asks for an entry to be re-chunked soon. It never blocks; if the
+// queue is full the next sweep picks the entry up.
+func (x *Indexer) Enqueue(id string) {
+	select {
+	case x.queue <- id:
+	default:
+	}
+}
+
+// Start launches the indexer loop. It runs until ctx is cancelled.
+func (x *Indexer) Start(ctx context.Context) {
+	x.logger.Info("knowledge chunker starting", "chunk_size", x.config.Size, "interval", x.config.Interval)
+	go x.runLoop(ctx)
+}
+
+func (x *Indexer) runLoop(ctx context.Context) {
+	ticker := time.NewTicker(x.config.Interval)
+	defer ticker.Stop()
+
+	// Run once immediately
+	x.sweep(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			x.logger.Info("knowledge chunker shutting down")
+			return
+		case id := <-x.queue:
+			if err := x.IndexEntry(ctx, id); err != nil {
+				x.logger.Warn("chunking knowledge entry", "id", id, "error", err)
+			}
+		case <-ticker.C:
+			x.sweep(ctx)
+		}
+	}
+}
+
+func (x *Indexer) sweep(ctx context.Context) {
+	n, err := x.RunOnce(ctx)
+	if err != nil {
+		x.logger.Warn("knowledge chunker error", "error", err)
+	}
+	if n > 0 {
+		x.logger.Info("knowledge chunker pass", "entries", n)
+	}
+}
+
+// RunOnce re-chunks up to BatchSize entries with missing or stale chunks and
+// returns how many it updated. An entry that fails is logged and retried on
+// the next pass.
+func (x *Indexer) RunOnce(ctx context.Context) (int, error) {
+	candidates, err := x.knowledge.ListChunkCandidates(ctx, x.config.Size, x.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, c := range candidates {
+		ok, err := x.index(ctx, c)
+		if err != nil {
+			x.logger.Warn("chunking knowledge entry", "id", c.ID, "error", err)
+			continue
+		}
+		if ok {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// IndexEntry re-chunks one entry now.
+func (x *Indexer) IndexEntry(ctx context.Context, id string) error {
+	c, err := x.knowledge.GetChunkCandidate(ctx, id)
+	if err != nil || c == nil {
+		return err
+	}
+	_, err = x.index(ctx, *c)
+	return err
+}
+
+func (x *Indexer) index(ctx context.Context, c store.ChunkCandidate) (bool, error) {
+	parts := Split(c.Content, x.config.Size, x.config.Overlap)
+
+	texts := make([]string, len(parts))
+	for i, p := range parts {
+		texts[i] = p.Text
+	}
+	vecs, err := embeddings.EmbedAll(ctx, x.embedder, texts)
+	if err != nil {
+		return false, fmt.Errorf("embedding chunks of %s: %w", c.ID, err)
+	}
+
+	chunks := make([]store.KnowledgeChunk, len(parts))
+	for i, p := range parts {
+		chunks[i] = store.KnowledgeChunk{Index: p.Index, Content: p.Text, Start: p.Start, End: p.End, Embedding: vecs[i]}
+	}
+	return x.knowledge.ReplaceChunks(ctx, c.ID, c.ContentHash, chunks)
+}
@@ -13,6 +13,7 @@ type Config struct {
 	// Server
 	Port     int
 	LogLevel string
+	Env      string // development, staging or production
 
 	// Database (Supabase PostgreSQL)
 	DatabaseURL string
@@ -24,6 +25,29 @@ type Config struct {
 	// Encryption
 	EncryptionKeyPath string
 	EncryptionKey     string // loaded from file or env
+	KeyringPath       string // optional keyring of versioned master keys
+	EncryptionBackend string // fernet, vault-transit or age
+
+	// Vault Transit encryption backend
+	VaultAddr         string
+	VaultToken        string
+	VaultNamespace    string
+	VaultTransitMount string
+	VaultTransitKey   string
+
+	// age encryption backend
+	AgeBinary       string
+	AgeRecipient    string
+	AgeIdentityPath string
+
+	// Secret version history
+	SecretHistoryVersions int // previous values kept per secret; 0 keeps all
+
+	// Scheduled secret rotation
+	RotationEnabled     bool
+	RotationInterval    time.Duration // how often due secrets are checked
+	RotationHook        string        // command behind the "hook" generator; empty disables it
+	RotationHookTimeout time.Duration
 
 	// NATS / Hermes
 	NatsURL string
@@ -46,6 +70,60 @@ type Config struct {
 
 	// Semantic layer
 	SemanticEnabled bool
+
+	// Knowledge reaper
+	ReaperEnabled         bool
+	ReaperInterval        time.Duration // how often expired entries are reaped
+	KnowledgeRetention    time.Duration // how long soft-deleted knowledge is kept
+	EphemeralKnowledgeTTL time.Duration // age at which ephemeral knowledge is reaped; 0 disables
+
+	// Knowledge deduplication
+	KnowledgeDedupePolicy   string  // none, reject, merge_tags, bump_confidence
+	NearDuplicateThreshold  float64 // embedding similarity treated as a duplicate; 0 disables
+
+	// Knowledge attachments
+	AttachmentBackend    string // blob backend; only "filesystem" for now
+	AttachmentDir        string // filesystem backend root
+	AttachmentMaxBytes   int64  // largest single attachment
+	AttachmentEntryBytes int64  // total attachment bytes per knowledge entry
+	AttachmentMaxCount   int    // attachments per knowledge entry
+
+	// Knowledge chunking
+	ChunkingEnabled  bool
+	ChunkSize        int           // max chunk bytes; shorter entries are not chunked
+	ChunkOverlap     int           // bytes shared by consecutive chunks
+	ChunkingInterval time.Duration // how often stale chunks are swept
+
+	// Knowledge embedding backfill
+	EmbeddingBackfillEnabled  bool
+	EmbeddingBackfillInterval time.Duration // how often missing or stale embeddings are redone
+
+	// Per-agent knowledge quotas; 0 is unlimited
+	QuotaMaxEntries      int64
+	QuotaMaxBytes        int64
+	QuotaMaxDailyCreates int64
+
+	// Saved search notifications
+	WatchEnabled        bool
+	WatchWebhookTimeout time.Duration // per webhook delivery
+
+	// Search ranking: half-lives in days used by the built-in profiles
+	HalfLifeSlowDays      float64
+	HalfLifeFastDays      float64
+	HalfLifeEphemeralDays float64
+}
+
+// Deployment environments. Only development may run without a configured
+// encryption key, so it must be chosen explicitly; production is the default.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Dev reports whether the service runs in development mode.
+func (c *Config) Dev() bool {
+	return c.Env == EnvDevelopment
 }
 
 // Load reads configuration from environment variables with sensible defaults.
@@ -53,11 +131,22 @@ func Load() (*Config, error) {
 	c := &Config{
 		Port:               envInt("ALEXANDRIA_PORT", 8500),
 		LogLevel:           envStr("ALEXANDRIA_LOG_LEVEL", "info"),
+		Env:                envStr("ALEXANDRIA_ENV", EnvProduction),
 		DatabaseURL:        envStr("DATABASE_URL", ""),
 		SupabaseURL:        envStr("SUPABASE_URL", "https://uaubofpmokvumbqpeymz.supabase.co"),
 		SupabaseKey:        envStr("SUPABASE_SERVICE_KEY", ""),
 		EncryptionKeyPath:  envStr("ENCRYPTION_KEY_PATH", "/run/secrets/vault_encryption_key"),
 		EncryptionKey:      envStr("ENCRYPTION_KEY", ""),
+		KeyringPath:        envStr("ENCRYPTION_KEYRING_PATH", ""),
+		EncryptionBackend:  envStr("ENCRYPTION_BACKEND", "fernet"),
+		VaultAddr:          envStr("VAULT_ADDR", ""),
+		VaultToken:         envStr("VAULT_TOKEN", ""),
+		VaultNamespace:     envStr("VAULT_NAMESPACE", ""),
+		VaultTransitMount:  envStr("VAULT_TRANSIT_MOUNT", "transit"),
+		VaultTransitKey:    envStr("VAULT_TRANSIT_KEY", "alexandria"),
+		AgeBinary:          envStr("AGE_BINARY", "age"),
+		AgeRecipient:       envStr("AGE_RECIPIENT", ""),
+		AgeIdentityPath:    envStr("AGE_IDENTITY_PATH", ""),
 		NatsURL:            envStr("NATS_URL", "nats://localhost:4222"),
 		EmbeddingBackend:   envStr("EMBEDDING_BACKEND", "local"),
 		EmbeddingSidecarURL: envStr("EMBEDDING_SIDECAR_URL", "http://localhost:8501"),
@@ -70,6 +159,36 @@ func Load() (*Config, error) {
 		JWTSecret:          envStr("JWT_SECRET", ""),
 		APIKey:             envStr("ALEXANDRIA_API_KEY", ""),
 		SemanticEnabled:    envStr("SEMANTIC_ENABLED", "") == "true",
+		ReaperEnabled:         envStr("REAPER_ENABLED", "true") == "true",
+		ReaperInterval:        envDuration("REAPER_INTERVAL", 10*time.Minute),
+		KnowledgeRetention:    envDuration("KNOWLEDGE_TRASH_RETENTION", 30*24*time.Hour),
+		EphemeralKnowledgeTTL: envDuration("KNOWLEDGE_EPHEMERAL_TTL", 7*24*time.Hour),
+		KnowledgeDedupePolicy:  envStr("KNOWLEDGE_DEDUPE_POLICY", "none"),
+		NearDuplicateThreshold: envFloat("KNOWLEDGE_NEAR_DUPLICATE_THRESHOLD", 0.97),
+		AttachmentBackend:      envStr("ATTACHMENT_BACKEND", "filesystem"),
+		AttachmentDir:          envStr("ATTACHMENT_DIR", "/var/lib/alexandria/attachments"),
+		AttachmentMaxBytes:     int64(envInt("ATTACHMENT_MAX_BYTES", 10<<20)),
+		AttachmentEntryBytes:   int64(envInt("ATTACHMENT_ENTRY_QUOTA_BYTES", 50<<20)),
+		AttachmentMaxCount:     envInt("ATTACHMENT_MAX_COUNT", 20),
+		ChunkingEnabled:        envStr("CHUNKING_ENABLED", "true") == "true",
+		ChunkSize:              envInt("CHUNK_SIZE", 1000),
+		ChunkOverlap:           envInt("CHUNK_OVERLAP", 200),
+		ChunkingInterval:       envDuration("CHUNKING_INTERVAL", time.Minute),
+		EmbeddingBackfillEnabled:  envStr("EMBEDDING_BACKFILL_ENABLED", "true") == "true",
+		EmbeddingBackfillInterval: envDuration("EMBEDDING_BACKFILL_INTERVAL", time.Minute),
+		HalfLifeSlowDays:          envFloat("DECAY_HALF_LIFE_SLOW_DAYS", 30),
+		HalfLifeFastDays:          envFloat("DECAY_HALF_LIFE_FAST_DAYS", 7),
+		HalfLifeEphemeralDays:     envFloat("DECAY_HALF_LIFE_EPHEMERAL_DAYS", 1),
+		QuotaMaxEntries:           int64(envInt("KNOWLEDGE_QUOTA_MAX_ENTRIES", 0)),
+		QuotaMaxBytes:             int64(envInt("KNOWLEDGE_QUOTA_MAX_BYTES", 0)),
+		QuotaMaxDailyCreates:      int64(envInt("KNOWLEDGE_QUOTA_DAILY_CREATES", 0)),
+		WatchEnabled:              envStr("WATCH_ENABLED", "true") == "true",
+		WatchWebhookTimeout:       envDuration("WATCH_WEBHOOK_TIMEOUT", 10*time.Second),
+		SecretHistoryVersions:     envInt("SECRET_HISTORY_VERSIONS", 10),
+		RotationEnabled:           envStr("SECRET_ROTATION_ENABLED", "true") == "true",
+		RotationInterval:          envDuration("SECRET_ROTATION_INTERVAL", time.Hour),
+		RotationHook:              envStr("SECRET_ROTATION_HOOK", ""),
+		RotationHookTimeout:       envDuration("SECRET_ROTATION_HOOK_TIMEOUT", 30*time.Second),
 	}
 
 	// Load encryption key from file if not set via env
@@ -81,6 +200,52 @@ func Load() (*Config, error) {
 		// If still empty, generate a warning but don't fail — tests may not need it
 	}
 
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		return nil, fmt.Errorf("ALEXANDRIA_ENV must be one of development, staging, production")
+	}
+
+	switch c.EncryptionBackend {
+	case "fernet":
+	case "vault-transit":
+		if c.VaultAddr == "" || c.VaultToken == "" {
+			return nil, fmt.Errorf("ENCRYPTION_BACKEND=vault-transit requires VAULT_ADDR and VAULT_TOKEN")
+		}
+	case "age":
+		if c.AgeRecipient == "" || c.AgeIdentityPath == "" {
+			return nil, fmt.Errorf("ENCRYPTION_BACKEND=age requires AGE_RECIPIENT and AGE_IDENTITY_PATH")
+		}
+	default:
+		return nil, fmt.Errorf("ENCRYPTION_BACKEND must be one of fernet, vault-transit, age")
+	}
+
+	if c.SecretHistoryVersions < 0 {
+		return nil, fmt.Errorf("SECRET_HISTORY_VERSIONS must not be negative")
+	}
+
+	switch c.KnowledgeDedupePolicy {
+	case "none", "reject", "merge_tags", "bump_confidence":
+	default:
+		return nil, fmt.Errorf("KNOWLEDGE_DEDUPE_POLICY must be one of none, reject, merge_tags, bump_confidence")
+	}
+
+	if c.ChunkSize < 100 || c.ChunkOverlap < 0 || c.ChunkOverlap >= c.ChunkSize/2 {
+		return nil, fmt.Errorf("CHUNK_SIZE must be at least 100 and CHUNK_OVERLAP under half of it")
+	}
+
+	if c.HalfLifeSlowDays <= 0 || c.HalfLifeFastDays <= 0 || c.HalfLifeEphemeralDays <= 0 {
+		return nil, fmt.Errorf("DECAY_HALF_LIFE_*_DAYS must be positive")
+	}
+
+	if c.QuotaMaxEntries < 0 || c.QuotaMaxBytes < 0 || c.QuotaMaxDailyCreates < 0 {
+		return nil, fmt.Errorf("KNOWLEDGE_QUOTA_* must not be negative")
+	}
+
+	if c.AttachmentBackend != "filesystem" {
+		return nil, fmt.Errorf("ATTACHMENT_BACKEND must be filesystem")
+	}
+
 	if c.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
@@ -103,3 +268,21 @@ func envInt(key string, def int) int {
 	}
 	return def
 }
+
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
@@ -3,6 +3,8 @@ package embeddings
 
 import (
 	"context"
+	"fmt"
+	"math"
 
 	pgvector "github.com/pgvector/pgvector-go"
 )
@@ -19,3 +21,108 @@ type Provider interface {
 	// Name returns the provider name for logging.
 	Name() string
 }
+
+// ModelProvider is implemented by providers that can name the model behind
+// their vectors.
+type ModelProvider interface {
+	// Model returns the embedding model name.
+	Model() string
+}
+
+// ModelName identifies the model p embeds with, falling back to the
+// provider name. Vectors from different models are not comparable.
+func ModelName(p Provider) string {
+	if mp, ok := p.(ModelProvider); ok {
+		return mp.Model()
+	}
+	return p.Name()
+}
+
+// BatchProvider is implemented by providers that can embed several texts in
+// one round trip.
+type BatchProvider interface {
+	// EmbedBatch returns one vector per text, in order.
+	EmbedBatch(ctx context.Context, texts []string) ([]pgvector.Vector, error)
+}
+
+// EmbedAll embeds texts using a single batch call when p supports it, and
+// one call per text otherwise.
+func EmbedAll(ctx context.Context, p Provider, texts []string) ([]pgvector.Vector, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if bp, ok := p.(BatchProvider); ok {
+		vecs, err := bp.EmbedBatch(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != len(texts) {
+			return nil, fmt.Errorf("%s returned %d embeddings for %d texts", p.Name(), len(vecs), len(texts))
+		}
+		return vecs, nil
+	}
+	vecs := make([]pgvector.Vector, len(texts))
+	for i, text := range texts {
+		v, err := p.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vecs[i] = v
+	}
+	return vecs, nil
+}
+
+// negativeWeight scales negative examples against positive ones when
+// combining vectors.
+const negativeWeight = 0.5
+
+// Combine builds one query vector from example vectors: the mean of the
+// unit-length positives minus half the mean of the unit-length negatives,
+// scaled back to unit length. At least one positive is required.
+func Combine(positive, negative []pgvector.Vector) (pgvector.Vector, error) {
+	if len(positive) == 0 {
+		return pgvector.Vector{}, fmt.Errorf("combining embeddings: no positive examples")
+	}
+	dim := len(positive[0].Slice())
+	sum := make([]float64, dim)
+	add := func(vecs []pgvector.Vector, weight float64) error {
+		for _, v := range vecs {
+			s := v.Slice()
+			if len(s) != dim {
+				return fmt.Errorf("combining embeddings: dimension %d does not match %d", len(s), dim)
+			}
+			var norm float64
+			for _, x := range s {
+				norm += float64(x) * float64(x)
+			}
+			if norm == 0 {
+				continue
+			}
+			scale := weight / (math.Sqrt(norm) * float64(len(vecs)))
+			for i, x := range s {
+				sum[i] += float64(x) * scale
+			}
+		}
+		return nil
+	}
+	if err := add(positive, 1); err != nil {
+		return pgvector.Vector{}, err
+	}
+	if err := add(negative, -negativeWeight); err != nil {
+		return pgvector.Vector{}, err
+	}
+
+	var norm float64
+	for _, x := range sum {
+		norm += x * x
+	}
+	if norm == 0 {
+		return pgvector.Vector{}, fmt.Errorf("combining embeddings: examples cancel out")
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, dim)
+	for i, x := range sum {
+		out[i] = float32(x / norm)
+	}
+	return pgvector.NewVector(out), nil
+}
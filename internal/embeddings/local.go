@@ -31,6 +31,11 @@ func (p *LocalProvider) Name() string {
 	return "local"
 }
 
+// Model returns the sidecar's embedding model.
+func (p *LocalProvider) Model() string {
+	return "all-MiniLM-L6-v2"
+}
+
 type sidecarRequest struct {
 	Texts []string `json:"texts"`
 }
@@ -41,41 +46,57 @@ type sidecarResponse struct {
 
 // Embed generates an embedding using the local sidecar.
 func (p *LocalProvider) Embed(ctx context.Context, text string) (pgvector.Vector, error) {
-	body, err := json.Marshal(sidecarRequest{Texts: []string{text}})
+	vecs, err := p.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return pgvector.Vector{}, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch embeds several texts in one sidecar request.
+func (p *LocalProvider) EmbedBatch(ctx context.Context, texts []string) ([]pgvector.Vector, error) {
+	body, err := json.Marshal(sidecarRequest{Texts: texts})
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("marshaling request: %w", err)
+		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/embed", bytes.NewReader(body))
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("calling sidecar: %w", err)
+		return nil, fmt.Errorf("calling sidecar: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return pgvector.Vector{}, fmt.Errorf("sidecar returned %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("sidecar returned %d: %s", resp.StatusCode, string(respBody))
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("reading response: %w", err)
+		return nil, fmt.Errorf("reading response: %w", err)
 	}
 
 	var result sidecarResponse
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return pgvector.Vector{}, fmt.Errorf("parsing response: %w", err)
+		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
 	if len(result.Embeddings) == 0 {
-		return pgvector.Vector{}, fmt.Errorf("no embeddings returned")
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("sidecar returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
 	}
 
-	return pgvector.NewVector(result.Embeddings[0]), nil
+	vecs := make([]pgvector.Vector, len(result.Embeddings))
+	for i, e := range result.Embeddings {
+		vecs[i] = pgvector.NewVector(e)
+	}
+	return vecs, nil
 }
@@ -35,14 +35,20 @@ func (p *OpenAIProvider) Name() string {
 	return "openai"
 }
 
+// Model returns the OpenAI embedding model.
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
 type openAIRequest struct {
-	Input      string `json:"input"`
+	Input      any    `json:"input"` // string or []string
 	Model      string `json:"model"`
 	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 type openAIResponse struct {
 	Data []struct {
+		Index     int       `json:"index"`
 		Embedding []float32 `json:"embedding"`
 	} `json:"data"`
 	Error *struct {
@@ -52,45 +58,70 @@ type openAIResponse struct {
 
 // Embed generates an embedding using the OpenAI API.
 func (p *OpenAIProvider) Embed(ctx context.Context, text string) (pgvector.Vector, error) {
+	vecs, err := p.embed(ctx, text, 1)
+	if err != nil {
+		return pgvector.Vector{}, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch embeds several texts in one API request.
+func (p *OpenAIProvider) EmbedBatch(ctx context.Context, texts []string) ([]pgvector.Vector, error) {
+	return p.embed(ctx, texts, len(texts))
+}
+
+// embed calls the embeddings API with input (a string or []string) and
+// returns n vectors ordered by input index.
+func (p *OpenAIProvider) embed(ctx context.Context, input any, n int) ([]pgvector.Vector, error) {
 	body, err := json.Marshal(openAIRequest{
-		Input:      text,
+		Input:      input,
 		Model:      p.model,
 		Dimensions: Dimensions, // request 384 dims to match local model
 	})
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("marshaling request: %w", err)
+		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/embeddings", bytes.NewReader(body))
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.apiKey)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("calling OpenAI: %w", err)
+		return nil, fmt.Errorf("calling OpenAI: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return pgvector.Vector{}, fmt.Errorf("reading response: %w", err)
+		return nil, fmt.Errorf("reading response: %w", err)
 	}
 
 	var result openAIResponse
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return pgvector.Vector{}, fmt.Errorf("parsing response: %w", err)
+		return nil, fmt.Errorf("parsing response: %w", err)
 	}
 
 	if result.Error != nil {
-		return pgvector.Vector{}, fmt.Errorf("OpenAI error: %s", result.Error.Message)
+		return nil, fmt.Errorf("OpenAI error: %s", result.Error.Message)
 	}
 
 	if len(result.Data) == 0 {
-		return pgvector.Vector{}, fmt.Errorf("no embeddings returned")
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+	if len(result.Data) != n {
+		return nil, fmt.Errorf("OpenAI returned %d embeddings for %d inputs", len(result.Data), n)
 	}
 
-	return pgvector.NewVector(result.Data[0].Embedding), nil
+	vecs := make([]pgvector.Vector, n)
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= n {
+			return nil, fmt.Errorf("OpenAI returned out-of-range index %d", d.Index)
+		}
+		vecs[d.Index] = pgvector.NewVector(d.Embedding)
+	}
+	return vecs, nil
 }
@@ -0,0 +1,131 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// AgeConfig configures the age backend.
+type AgeConfig struct {
+	Binary       string // age executable; default "age"
+	Recipient    string // public key data keys are encrypted to
+	IdentityPath string // identity file passed to age --decrypt
+}
+
+// Age wraps data keys by encrypting them to an age recipient with the age
+// command. Alexandria only holds the public recipient and an identity
+// file, which must hold plugin identities (AGE-PLUGIN-...) such as
+// age-plugin-yubikey's: the file is a stub and the private key stays on the
+// PKCS#11 token, which performs every unwrap. A plain AGE-SECRET-KEY
+// identity would leave the master key on disk next to the database
+// credentials, so NewAge refuses it. Master key IDs are
+// age-<recipient fingerprint>.
+type Age struct {
+	config AgeConfig
+	keyID  string
+}
+
+// NewAge creates an age backend.
+func NewAge(cfg AgeConfig) (*Age, error) {
+	cfg.Recipient = strings.TrimSpace(cfg.Recipient)
+	if cfg.Recipient == "" || cfg.IdentityPath == "" {
+		return nil, fmt.Errorf("age backend needs a recipient and an identity file")
+	}
+	if cfg.Binary == "" {
+		cfg.Binary = "age"
+	}
+	if err := checkAgeIdentity(cfg.IdentityPath); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(cfg.Binary); err != nil {
+		return nil, fmt.Errorf("finding age binary: %w", err)
+	}
+	sum := sha256.Sum256([]byte(cfg.Recipient))
+	return &Age{config: cfg, keyID: "age-" + hex.EncodeToString(sum[:8])}, nil
+}
+
+// checkAgeIdentity accepts an identity file that holds only plugin
+// identities.
+func checkAgeIdentity(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading age identity file: %w", err)
+	}
+	plugins := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "AGE-PLUGIN-"):
+			plugins++
+		case strings.HasPrefix(line, "AGE-SECRET-KEY-"):
+			return fmt.Errorf("age identity file %s holds a plain secret key; use a plugin identity so the key stays off disk", path)
+		default:
+			return fmt.Errorf("age identity file %s holds an unrecognised identity", path)
+		}
+	}
+	if plugins == 0 {
+		return fmt.Errorf("age identity file %s holds no plugin identity", path)
+	}
+	return nil
+}
+
+// Name implements KMS.
+func (a *Age) Name() string {
+	return BackendAge
+}
+
+// PrimaryKeyID implements KMS.
+func (a *Age) PrimaryKeyID() string {
+	return a.keyID
+}
+
+// Owns implements KMS. The identity file may hold the identities of
+// earlier recipients, so any age key ID is tried.
+func (a *Age) Owns(keyID string) bool {
+	return strings.HasPrefix(keyID, "age-")
+}
+
+// Refresh implements KMS; the recipient only changes on restart.
+func (a *Age) Refresh(context.Context) error {
+	return nil
+}
+
+// WrapKey implements KMS.
+func (a *Age) WrapKey(ctx context.Context, dataKey []byte) (string, string, error) {
+	out, err := a.run(ctx, dataKey, "--encrypt", "--armor", "--recipient", a.config.Recipient)
+	if err != nil {
+		return "", "", fmt.Errorf("wrapping data key: %w", err)
+	}
+	return string(out), a.keyID, nil
+}
+
+// UnwrapKey implements KMS.
+func (a *Age) UnwrapKey(ctx context.Context, wrapped, keyID string) ([]byte, error) {
+	if !a.Owns(keyID) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	out, err := a.run(ctx, []byte(wrapped), "--decrypt", "--identity", a.config.IdentityPath)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key: %w", err)
+	}
+	return out, nil
+}
+
+func (a *Age) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, a.config.Binary, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", a.config.Binary, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
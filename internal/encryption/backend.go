@@ -0,0 +1,58 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KMS backend names, as set by ENCRYPTION_BACKEND.
+const (
+	BackendFernet       = "fernet"
+	BackendVaultTransit = "vault-transit"
+	BackendAge          = "age"
+)
+
+// Config selects and configures the KMS backend.
+type Config struct {
+	Backend string
+
+	// Fernet keyring. With another backend these keys only unwrap what
+	// they wrapped before, so existing secrets can be re-wrapped.
+	KeyringPath string
+	Key         string
+
+	Vault VaultConfig
+	Age   AgeConfig
+}
+
+// New creates the Encryptor cfg selects. It returns ErrNoKey for the Fernet
+// backend without a key.
+func New(ctx context.Context, cfg Config) (Encryptor, error) {
+	f, err := LoadFernet(cfg.KeyringPath, cfg.Key)
+	if err != nil && !errors.Is(err, ErrNoKey) {
+		return nil, err
+	}
+
+	var primary KMS
+	switch cfg.Backend {
+	case BackendFernet, "":
+		if f == nil {
+			return nil, err
+		}
+		return NewEnvelope(f), nil
+	case BackendVaultTransit:
+		primary, err = NewVaultTransit(ctx, cfg.Vault)
+	case BackendAge:
+		primary, err = NewAge(cfg.Age)
+	default:
+		return nil, fmt.Errorf("unknown encryption backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return NewEnvelope(primary), nil
+	}
+	return NewEnvelope(primary, f), nil
+}
@@ -0,0 +1,188 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fernet/fernet-go"
+)
+
+var (
+	// ErrUnknownKey is returned when a value was sealed under a master key
+	// no configured backend holds.
+	ErrUnknownKey = errors.New("master key not in keyring")
+	// ErrNoLegacyKey is returned when opening a value from before envelope
+	// encryption without a Fernet keyring to decrypt it.
+	ErrNoLegacyKey = errors.New("legacy secret needs a fernet key")
+)
+
+// Encryptor seals and opens secret values.
+type Encryptor interface {
+	// Seal encrypts plaintext under a new data key wrapped by the primary
+	// master key.
+	Seal(ctx context.Context, plaintext string) (Sealed, error)
+	// Open decrypts a sealed value.
+	Open(ctx context.Context, s Sealed) (string, error)
+	// Rewrap returns s with its data key wrapped by the primary master key,
+	// and whether that changed anything.
+	Rewrap(ctx context.Context, s Sealed) (Sealed, bool, error)
+	// PrimaryKeyID returns the ID of the master key Seal wraps with.
+	PrimaryKeyID() string
+	// Backend names the KMS holding the primary master key.
+	Backend() string
+	// Refresh picks up master key rotations made outside Alexandria.
+	Refresh(ctx context.Context) error
+}
+
+// KMS wraps data keys with master keys it holds. The master keys of remote
+// backends never leave them.
+type KMS interface {
+	Name() string
+	PrimaryKeyID() string
+	// Owns reports whether the backend can unwrap data keys wrapped by keyID.
+	Owns(keyID string) bool
+	// WrapKey encrypts dataKey with the primary master key, returning the
+	// wrapped key and the ID of the master key used.
+	WrapKey(ctx context.Context, dataKey []byte) (wrapped, keyID string, err error)
+	UnwrapKey(ctx context.Context, wrapped, keyID string) ([]byte, error)
+	Refresh(ctx context.Context) error
+}
+
+// Sealed is an encrypted secret value. Ciphertext is encrypted with a data
+// key of its own; WrappedKey is that data key encrypted with the master key
+// KeyID. A Sealed with an empty KeyID is a legacy value encrypted directly
+// with a Fernet master key.
+type Sealed struct {
+	Ciphertext string
+	WrappedKey string
+	KeyID      string
+}
+
+// Legacy reports whether s predates envelope encryption.
+func (s Sealed) Legacy() bool {
+	return s.KeyID == ""
+}
+
+// Envelope is the Encryptor: values are encrypted with Fernet data keys
+// wrapped by the primary KMS. Fallback backends still unwrap the data keys
+// they wrapped until a re-wrap job moves them to the primary.
+type Envelope struct {
+	primary   KMS
+	fallbacks []KMS
+	legacy    *Fernet // decrypts values from before envelope encryption
+}
+
+// NewEnvelope creates an Envelope wrapping new data keys with primary.
+func NewEnvelope(primary KMS, fallbacks ...KMS) *Envelope {
+	e := &Envelope{primary: primary, fallbacks: fallbacks}
+	for _, kms := range append([]KMS{primary}, fallbacks...) {
+		if f, ok := kms.(*Fernet); ok {
+			e.legacy = f
+			break
+		}
+	}
+	return e
+}
+
+// PrimaryKeyID implements Encryptor.
+func (e *Envelope) PrimaryKeyID() string {
+	return e.primary.PrimaryKeyID()
+}
+
+// Backend implements Encryptor.
+func (e *Envelope) Backend() string {
+	return e.primary.Name()
+}
+
+// Refresh implements Encryptor.
+func (e *Envelope) Refresh(ctx context.Context) error {
+	return e.primary.Refresh(ctx)
+}
+
+// Seal implements Encryptor.
+func (e *Envelope) Seal(ctx context.Context, plaintext string) (Sealed, error) {
+	dataKey, err := GenerateKey()
+	if err != nil {
+		return Sealed{}, err
+	}
+	ciphertext, err := fernet.EncryptAndSign([]byte(plaintext), dataKey)
+	if err != nil {
+		return Sealed{}, fmt.Errorf("encrypting: %w", err)
+	}
+	sealed := Sealed{Ciphertext: string(ciphertext)}
+	if sealed.WrappedKey, sealed.KeyID, err = e.primary.WrapKey(ctx, dataKey[:]); err != nil {
+		return Sealed{}, err
+	}
+	return sealed, nil
+}
+
+// Open implements Encryptor. Legacy values are decrypted with any Fernet
+// master key.
+func (e *Envelope) Open(ctx context.Context, s Sealed) (string, error) {
+	if s.Legacy() {
+		if e.legacy == nil {
+			return "", ErrNoLegacyKey
+		}
+		return e.legacy.Decrypt(s.Ciphertext)
+	}
+	raw, err := e.unwrap(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := dataKeyFromBytes(raw)
+	if err != nil {
+		return "", err
+	}
+	msg := fernet.VerifyAndDecrypt([]byte(s.Ciphertext), 0, []*fernet.Key{dataKey})
+	if msg == nil {
+		return "", fmt.Errorf("decryption failed: invalid token or data key")
+	}
+	return string(msg), nil
+}
+
+// Rewrap implements Encryptor, leaving the ciphertext as is. A legacy value
+// is resealed under a new data key.
+func (e *Envelope) Rewrap(ctx context.Context, s Sealed) (Sealed, bool, error) {
+	if s.KeyID == e.primary.PrimaryKeyID() {
+		return s, false, nil
+	}
+	if s.Legacy() {
+		plaintext, err := e.Open(ctx, s)
+		if err != nil {
+			return s, false, err
+		}
+		sealed, err := e.Seal(ctx, plaintext)
+		return sealed, err == nil, err
+	}
+
+	dataKey, err := e.unwrap(ctx, s)
+	if err != nil {
+		return s, false, err
+	}
+	rewrapped := Sealed{Ciphertext: s.Ciphertext}
+	if rewrapped.WrappedKey, rewrapped.KeyID, err = e.primary.WrapKey(ctx, dataKey); err != nil {
+		return s, false, err
+	}
+	return rewrapped, true, nil
+}
+
+// unwrap decrypts s's data key with the backend owning its master key.
+func (e *Envelope) unwrap(ctx context.Context, s Sealed) ([]byte, error) {
+	for _, kms := range append([]KMS{e.primary}, e.fallbacks...) {
+		if kms.Owns(s.KeyID) {
+			return kms.UnwrapKey(ctx, s.WrappedKey, s.KeyID)
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, s.KeyID)
+}
+
+// dataKeyFromBytes checks that raw is a Fernet key.
+func dataKeyFromBytes(raw []byte) (*fernet.Key, error) {
+	k := new(fernet.Key)
+	if len(raw) != len(k) {
+		return nil, fmt.Errorf("data key is %d bytes, want %d", len(raw), len(k))
+	}
+	copy(k[:], raw)
+	return k, nil
+}
@@ -1,32 +1,114 @@
-// Package encryption provides Fernet-based symmetric encryption for secrets.
+// Package encryption provides envelope encryption for secrets. Each secret
+// is sealed with a Fernet data key of its own, which is in turn wrapped by a
+// master key held by a KMS backend: a local Fernet keyring, HashiCorp Vault
+// Transit, or an age identity (e.g. on a PKCS#11 token). Master keys can be
+// rotated without re-encrypting every value at once.
 package encryption
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/fernet/fernet-go"
 )
 
-// Encryptor provides encrypt/decrypt operations using Fernet.
-type Encryptor struct {
-	key *fernet.Key
+// Fernet is the local KMS backend: a keyring of Fernet master keys held in
+// memory. It also encrypts and decrypts values directly, as secrets were
+// before envelope encryption.
+type Fernet struct {
+	ring *keyring
 }
 
 // NewEncryptor creates a new Fernet encryptor with the given key string.
 // The key should be a URL-safe base64-encoded 32-byte key.
-func NewEncryptor(keyStr string) (*Encryptor, error) {
+func NewEncryptor(keyStr string) (*Fernet, error) {
 	keyStr = strings.TrimSpace(keyStr)
 	if keyStr == "" {
 		return nil, fmt.Errorf("encryption key is empty")
 	}
 
-	k, err := fernet.DecodeKey(keyStr)
-	if err != nil {
+	if _, err := fernet.DecodeKey(keyStr); err != nil {
 		return nil, fmt.Errorf("decoding fernet key: %w", err)
 	}
 
-	return &Encryptor{key: k}, nil
+	return NewFernet(Keyring{Keys: []MasterKey{{Key: keyStr}}})
+}
+
+// NewFernet creates a Fernet backend holding every key in kr.
+func NewFernet(kr Keyring) (*Fernet, error) {
+	ring, err := parseKeyring(kr)
+	if err != nil {
+		return nil, err
+	}
+	return &Fernet{ring: ring}, nil
+}
+
+// NewKeyringEncryptor creates an Encryptor wrapping data keys with the
+// Fernet keys in kr.
+func NewKeyringEncryptor(kr Keyring) (*Envelope, error) {
+	f, err := NewFernet(kr)
+	if err != nil {
+		return nil, err
+	}
+	return NewEnvelope(f), nil
+}
+
+// Name implements KMS.
+func (e *Fernet) Name() string {
+	return BackendFernet
+}
+
+// PrimaryKeyID returns the ID of the master key new data keys are wrapped with.
+func (e *Fernet) PrimaryKeyID() string {
+	return e.ring.primary
+}
+
+// KeyIDs returns the IDs of every master key, oldest first.
+func (e *Fernet) KeyIDs() []string {
+	return append([]string(nil), e.ring.order...)
+}
+
+// Owns implements KMS.
+func (e *Fernet) Owns(keyID string) bool {
+	_, ok := e.ring.keys[keyID]
+	return ok
+}
+
+// Refresh implements KMS; the keyring only changes on restart.
+func (e *Fernet) Refresh(context.Context) error {
+	return nil
+}
+
+// WrapKey implements KMS, encrypting the encoded data key with the primary
+// master key.
+func (e *Fernet) WrapKey(_ context.Context, dataKey []byte) (string, string, error) {
+	k, err := dataKeyFromBytes(dataKey)
+	if err != nil {
+		return "", "", err
+	}
+	wrapped, err := fernet.EncryptAndSign([]byte(k.Encode()), e.ring.keys[e.ring.primary])
+	if err != nil {
+		return "", "", fmt.Errorf("wrapping data key: %w", err)
+	}
+	return string(wrapped), e.ring.primary, nil
+}
+
+// UnwrapKey implements KMS.
+func (e *Fernet) UnwrapKey(_ context.Context, wrapped, keyID string) ([]byte, error) {
+	master, ok := e.ring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	encoded := fernet.VerifyAndDecrypt([]byte(wrapped), 0, []*fernet.Key{master})
+	if encoded == nil {
+		return nil, fmt.Errorf("unwrapping data key: invalid token or master key %q", keyID)
+	}
+	k, err := fernet.DecodeKey(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decoding data key: %w", err)
+	}
+	return k[:], nil
 }
 
 // GenerateKey creates a new random Fernet key.
@@ -38,18 +120,20 @@ func GenerateKey() (*fernet.Key, error) {
 	return k, nil
 }
 
-// Encrypt encrypts plaintext and returns a Fernet token string.
-func (e *Encryptor) Encrypt(plaintext string) (string, error) {
-	tok, err := fernet.EncryptAndSign([]byte(plaintext), e.key)
+// Encrypt encrypts plaintext directly with the primary master key and
+// returns a Fernet token string. Secrets are sealed by an Envelope instead.
+func (e *Fernet) Encrypt(plaintext string) (string, error) {
+	tok, err := fernet.EncryptAndSign([]byte(plaintext), e.ring.keys[e.ring.primary])
 	if err != nil {
 		return "", fmt.Errorf("encrypting: %w", err)
 	}
 	return string(tok), nil
 }
 
-// Decrypt decrypts a Fernet token and returns the plaintext.
-func (e *Encryptor) Decrypt(token string) (string, error) {
-	msg := fernet.VerifyAndDecrypt([]byte(token), 0, []*fernet.Key{e.key})
+// Decrypt decrypts a Fernet token made by Encrypt with any master key and
+// returns the plaintext.
+func (e *Fernet) Decrypt(token string) (string, error) {
+	msg := fernet.VerifyAndDecrypt([]byte(token), 0, e.ring.all())
 	if msg == nil {
 		return "", fmt.Errorf("decryption failed: invalid token or key")
 	}
@@ -0,0 +1,144 @@
+package encryption
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fernet/fernet-go"
+)
+
+// MasterKey is one versioned key in a Keyring.
+type MasterKey struct {
+	// ID is stored with every data key the master key wraps. If empty it
+	// defaults to the key's fingerprint.
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// Keyring is the set of master keys able to unwrap data keys. New data keys
+// are wrapped with the primary key; the others only decrypt until a re-wrap
+// job has moved their secrets to the primary.
+//
+// A keyring file is JSON:
+//
+//	{"primary": "2026-10", "keys": [{"id": "2026-04", "key": "..."}, {"id": "2026-10", "key": "..."}]}
+//
+// If primary is omitted the last key is primary.
+type Keyring struct {
+	Primary string      `json:"primary,omitempty"`
+	Keys    []MasterKey `json:"keys"`
+}
+
+// LoadKeyring reads a keyring file.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading keyring: %w", err)
+	}
+	var kr Keyring
+	if err := json.Unmarshal(data, &kr); err != nil {
+		return nil, fmt.Errorf("parsing keyring %s: %w", path, err)
+	}
+	return &kr, nil
+}
+
+// Include adds key as the oldest key of the ring unless it is already in
+// it, so it can still decrypt without becoming primary.
+func (kr *Keyring) Include(key string) {
+	key = strings.TrimSpace(key)
+	for _, k := range kr.Keys {
+		if strings.TrimSpace(k.Key) == key {
+			return
+		}
+	}
+	kr.Keys = append([]MasterKey{{Key: key}}, kr.Keys...)
+}
+
+// KeyID returns the fingerprint used as the ID of a master key without one:
+// the first 8 bytes of the SHA-256 of the decoded key, in hex.
+func KeyID(k *fernet.Key) string {
+	sum := sha256.Sum256(k[:])
+	return hex.EncodeToString(sum[:8])
+}
+
+// keyring is a parsed Keyring.
+type keyring struct {
+	keys    map[string]*fernet.Key
+	order   []string // oldest first
+	primary string
+}
+
+func parseKeyring(kr Keyring) (*keyring, error) {
+	if len(kr.Keys) == 0 {
+		return nil, fmt.Errorf("keyring has no keys")
+	}
+	ring := &keyring{keys: make(map[string]*fernet.Key, len(kr.Keys))}
+	for i, mk := range kr.Keys {
+		keyStr := strings.TrimSpace(mk.Key)
+		if keyStr == "" {
+			return nil, fmt.Errorf("keyring key %d is empty", i)
+		}
+		k, err := fernet.DecodeKey(keyStr)
+		if err != nil {
+			return nil, fmt.Errorf("decoding keyring key %d: %w", i, err)
+		}
+		id := strings.TrimSpace(mk.ID)
+		if id == "" {
+			id = KeyID(k)
+		}
+		if _, dup := ring.keys[id]; dup {
+			return nil, fmt.Errorf("duplicate keyring key ID %q", id)
+		}
+		ring.keys[id] = k
+		ring.order = append(ring.order, id)
+	}
+
+	ring.primary = ring.order[len(ring.order)-1]
+	if kr.Primary != "" {
+		if _, ok := ring.keys[kr.Primary]; !ok {
+			return nil, fmt.Errorf("keyring primary %q is not one of its keys", kr.Primary)
+		}
+		ring.primary = kr.Primary
+	}
+	return ring, nil
+}
+
+// all returns every key, primary first, for legacy decryption.
+func (r *keyring) all() []*fernet.Key {
+	keys := []*fernet.Key{r.keys[r.primary]}
+	for _, id := range r.order {
+		if id != r.primary {
+			keys = append(keys, r.keys[id])
+		}
+	}
+	return keys
+}
+
+// ErrNoKey is returned by LoadFernet when neither a keyring nor a key is
+// configured.
+var ErrNoKey = errors.New("no encryption key configured")
+
+// LoadFernet creates a Fernet backend from the keyring file at keyringPath,
+// the single key, or both. The key is kept in the ring alongside a keyring
+// file so secrets sealed with it can still be re-wrapped.
+func LoadFernet(keyringPath, key string) (*Fernet, error) {
+	if keyringPath == "" && strings.TrimSpace(key) == "" {
+		return nil, ErrNoKey
+	}
+	kr := &Keyring{}
+	if keyringPath != "" {
+		var err error
+		if kr, err = LoadKeyring(keyringPath); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(key) != "" {
+		kr.Include(key)
+	}
+	return NewFernet(*kr)
+}
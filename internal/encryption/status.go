@@ -0,0 +1,27 @@
+package encryption
+
+// State summarises whether secrets can be encrypted and decrypted.
+type State string
+
+const (
+	StateOK          State = "ok"           // keys loaded and the self-test passed
+	StateUnverified  State = "unverified"   // keys loaded but the self-test could not run
+	StateKeyMismatch State = "key_mismatch" // stored secrets do not decrypt with the keys
+	StateEphemeral   State = "ephemeral"    // development only: a throwaway key
+)
+
+// Status reports the encryption setup, for /health.
+type Status struct {
+	State        State  `json:"status"`
+	Env          string `json:"env"`
+	Backend      string `json:"backend,omitempty"`
+	PrimaryKeyID string `json:"primary_key_id,omitempty"`
+	Canary       string `json:"canary,omitempty"` // outcome of the self-test
+	Error        string `json:"error,omitempty"`
+}
+
+// Healthy reports whether secrets written now will stay readable and those
+// already stored can be read.
+func (s Status) Healthy() bool {
+	return s.State == StateOK || s.State == StateUnverified
+}
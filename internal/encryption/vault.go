@@ -0,0 +1,211 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultConfig configures the Vault Transit backend.
+type VaultConfig struct {
+	Addr      string // e.g. https://vault.internal:8200
+	Token     string
+	Namespace string // Vault Enterprise namespace, optional
+	Mount     string // Transit mount path; default "transit"
+	Key       string // Transit key name
+	Timeout   time.Duration
+}
+
+// VaultTransit wraps data keys with a HashiCorp Vault Transit key. The
+// master key never leaves Vault; each Transit key version is a master key
+// ID of the form vault-transit:<key>:v<version>.
+type VaultTransit struct {
+	config VaultConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	latest int // latest Transit key version seen
+}
+
+// NewVaultTransit creates a Vault Transit backend and reads the key's
+// latest version.
+func NewVaultTransit(ctx context.Context, cfg VaultConfig) (*VaultTransit, error) {
+	if cfg.Addr == "" || cfg.Key == "" {
+		return nil, fmt.Errorf("vault transit needs an address and key name")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.Addr = strings.TrimRight(cfg.Addr, "/")
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+
+	v := &VaultTransit{config: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+	if err := v.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Name implements KMS.
+func (v *VaultTransit) Name() string {
+	return BackendVaultTransit
+}
+
+// PrimaryKeyID implements KMS.
+func (v *VaultTransit) PrimaryKeyID() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.keyID(v.latest)
+}
+
+// Owns implements KMS.
+func (v *VaultTransit) Owns(keyID string) bool {
+	_, ok := v.version(keyID)
+	return ok
+}
+
+// Refresh implements KMS, reading the Transit key's latest version.
+func (v *VaultTransit) Refresh(ctx context.Context) error {
+	var resp struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, "keys/"+v.config.Key, nil, &resp); err != nil {
+		return fmt.Errorf("reading vault transit key: %w", err)
+	}
+	if resp.Data.LatestVersion < 1 {
+		return fmt.Errorf("vault transit key %q has no versions", v.config.Key)
+	}
+	v.seen(resp.Data.LatestVersion)
+	return nil
+}
+
+// WrapKey implements KMS. Vault always encrypts with the key's latest
+// version, which becomes the primary key ID.
+func (v *VaultTransit) WrapKey(ctx context.Context, dataKey []byte) (string, string, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	if err := v.do(ctx, http.MethodPost, "encrypt/"+v.config.Key, body, &resp); err != nil {
+		return "", "", fmt.Errorf("wrapping data key: %w", err)
+	}
+	version, err := ciphertextVersion(resp.Data.Ciphertext)
+	if err != nil {
+		return "", "", err
+	}
+	v.seen(version)
+	return resp.Data.Ciphertext, v.keyID(version), nil
+}
+
+// UnwrapKey implements KMS.
+func (v *VaultTransit) UnwrapKey(ctx context.Context, wrapped, keyID string) ([]byte, error) {
+	version, ok := v.version(keyID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	if got, err := ciphertextVersion(wrapped); err != nil || got != version {
+		return nil, fmt.Errorf("unwrapping data key: ciphertext is not from %s", keyID)
+	}
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodPost, "decrypt/"+v.config.Key, map[string]string{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, fmt.Errorf("unwrapping data key: %w", err)
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decoding data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func (v *VaultTransit) keyID(version int) string {
+	return fmt.Sprintf("vault-transit:%s:v%d", v.config.Key, version)
+}
+
+// version parses a key ID of this backend's Transit key.
+func (v *VaultTransit) version(keyID string) (int, bool) {
+	rest, ok := strings.CutPrefix(keyID, "vault-transit:"+v.config.Key+":v")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest)
+	return n, err == nil && n > 0
+}
+
+func (v *VaultTransit) seen(version int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if version > v.latest {
+		v.latest = version
+	}
+}
+
+// ciphertextVersion returns the key version of a vault:v<n>:... ciphertext.
+func ciphertextVersion(ciphertext string) (int, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return 0, fmt.Errorf("unexpected vault ciphertext format")
+	}
+	n, err := strconv.Atoi(parts[1][1:])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("unexpected vault ciphertext version %q", parts[1])
+	}
+	return n, nil
+}
+
+// do calls the Transit API at path under the mount and decodes the JSON
+// response into out.
+func (v *VaultTransit) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, v.config.Addr+"/v1/"+v.config.Mount+"/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&errResp)
+		return fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.Join(errResp.Errors, "; "))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -75,6 +75,40 @@ func (p *Publisher) KnowledgeUpdated(ctx context.Context, entry *store.Knowledge
 	})
 }
 
+// KnowledgeSuperseded publishes a supersession event so downstream agents can drop the stale entry.
+func (p *Publisher) KnowledgeSuperseded(ctx context.Context, old, replacement *store.KnowledgeEntry, supersededBy string) error {
+	return p.publish(ctx, "swarm.vault.knowledge.superseded", VaultEvent{
+		ID:        old.ID,
+		Type:      "vault.knowledge.superseded",
+		Source:    "alexandria",
+		Timestamp: time.Now(),
+		Data: map[string]any{
+			"id":            old.ID,
+			"superseded_by": replacement.ID,
+			"category":      replacement.Category,
+			"source_agent":  old.SourceAgent,
+			"actor":         supersededBy,
+			"summary":       replacement.Summary,
+		},
+	})
+}
+
+// KnowledgeExpired publishes an event when the reaper retires an expired or decayed entry.
+func (p *Publisher) KnowledgeExpired(ctx context.Context, entry *store.ExpiredKnowledge) error {
+	return p.publish(ctx, "swarm.vault.knowledge.expired", VaultEvent{
+		ID:        entry.ID,
+		Type:      "vault.knowledge.expired",
+		Source:    "alexandria",
+		Timestamp: time.Now(),
+		Data: map[string]any{
+			"id":           entry.ID,
+			"category":     entry.Category,
+			"source_agent": entry.SourceAgent,
+			"reason":       entry.Reason,
+		},
+	})
+}
+
 // KnowledgeSearched publishes a search event (for analytics).
 func (p *Publisher) KnowledgeSearched(ctx context.Context, agentID, query string, resultCount int) error {
 	return p.publish(ctx, "swarm.vault.knowledge.searched", VaultEvent{
@@ -115,6 +149,22 @@ func (p *Publisher) SecretRotated(ctx context.Context, secretName, rotatedBy str
 	})
 }
 
+// SecretRotationOverdue publishes an event for a secret whose rotation
+// interval has elapsed without it being rotated. reason says why the
+// scheduler did not rotate it.
+func (p *Publisher) SecretRotationOverdue(ctx context.Context, secretName string, dueAt time.Time, reason string) error {
+	return p.publish(ctx, "swarm.vault.secret.rotation_overdue", VaultEvent{
+		Type:      "vault.secret.rotation_overdue",
+		Source:    "alexandria",
+		Timestamp: time.Now(),
+		Data: map[string]any{
+			"secret_name": secretName,
+			"due_at":      dueAt,
+			"reason":      reason,
+		},
+	})
+}
+
 // BriefingGenerated publishes a briefing generation event.
 func (p *Publisher) BriefingGenerated(ctx context.Context, agentID string, itemCount int) error {
 	return p.publish(ctx, "swarm.vault.briefing.generated", VaultEvent{
@@ -139,3 +189,29 @@ func (p *Publisher) ContextGenerated(ctx context.Context, agentID string) error
 		},
 	})
 }
+
+// WatchMatched publishes a saved search match to the search owner's subject,
+// swarm.vault.watch.<agent>.matched.
+func (p *Publisher) WatchMatched(ctx context.Context, search *store.SavedSearch, entry *store.KnowledgeEntry, score float64) error {
+	return p.publish(ctx, "swarm.vault.watch."+sanitizeSubject(search.AgentID)+".matched", VaultEvent{
+		ID:        entry.ID,
+		Type:      "vault.watch.matched",
+		Source:    "alexandria",
+		Timestamp: time.Now(),
+		Data: map[string]any{
+			"saved_search_id": search.ID,
+			"saved_search":    search.Name,
+			"query":           search.Query,
+			"agent_id":        search.AgentID,
+			"score":           score,
+			"knowledge": map[string]any{
+				"id":           entry.ID,
+				"category":     entry.Category,
+				"scope":        entry.Scope,
+				"source_agent": entry.SourceAgent,
+				"summary":      entry.Summary,
+				"tags":         entry.Tags,
+			},
+		},
+	})
+}
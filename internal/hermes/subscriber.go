@@ -3,6 +3,7 @@ package hermes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+	"github.com/MikeSquared-Agency/Alexandria/internal/watch"
 )
 
 // Subscriber listens to Hermes events and auto-captures knowledge.
@@ -18,6 +20,7 @@ type Subscriber struct {
 	knowledge *store.KnowledgeStore
 	embedder  embeddings.Provider
 	publisher *Publisher
+	watcher   *watch.Watcher
 	logger    *slog.Logger
 	subs      []*nats.Subscription
 }
@@ -33,6 +36,18 @@ func NewSubscriber(client *Client, knowledge *store.KnowledgeStore, embedder emb
 	}
 }
 
+// SetWatcher checks captured entries against agents' saved searches.
+func (s *Subscriber) SetWatcher(watcher *watch.Watcher) {
+	s.watcher = watcher
+}
+
+// watchMatches queues a new entry to be checked against saved searches.
+func (s *Subscriber) watchMatches(entry *store.KnowledgeEntry) {
+	if s.watcher != nil {
+		s.watcher.Enqueue(entry)
+	}
+}
+
 // CorrectionSignal represents a Dredd correction event payload.
 type CorrectionSignal struct {
 	SessionRef     string `json:"session_ref"`
@@ -172,10 +187,13 @@ func (s *Subscriber) handleCorrection(msg *nats.Msg) {
 	}
 
 	// Generate embedding for the lesson content
+	var model string
 	embedding, err := s.embedder.Embed(ctx, content)
 	if err != nil {
 		s.logger.Error("failed to generate embedding for correction", "error", err)
 		// Continue without embedding
+	} else {
+		model = embeddings.ModelName(s.embedder)
 	}
 
 	eventID := envelope.ID
@@ -187,6 +205,7 @@ func (s *Subscriber) handleCorrection(msg *nats.Msg) {
 		Scope:          store.ScopePublic,
 		Tags:           tags,
 		Embedding:      embedding,
+		EmbeddingModel: model,
 		Metadata: map[string]any{
 			"decision_id":     signal.DecisionID,
 			"agent_id":        signal.AgentID,
@@ -202,12 +221,30 @@ func (s *Subscriber) handleCorrection(msg *nats.Msg) {
 		RelevanceDecay: store.DecaySlow,
 	}
 
-	entry, err := s.knowledge.Create(ctx, input)
+	result, err := s.knowledge.CreateWithOutcome(ctx, input)
 	if err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			s.logger.Info("skipped duplicate Dredd correction", "event_id", envelope.ID, "existing_id", dup.Existing.ID)
+			s.ack(msg)
+			return
+		}
+		if errors.Is(err, store.ErrQuotaExceeded) {
+			s.logger.Warn("dropped Dredd correction over knowledge quota", "error", err, "event_id", envelope.ID, "decision_id", signal.DecisionID)
+			s.ack(msg)
+			return
+		}
 		s.logger.Error("failed to persist correction as lesson", "error", err, "event_id", envelope.ID, "decision_id", signal.DecisionID)
 		s.ack(msg)
 		return
 	}
+	entry := result.Entry
+
+	if result.Outcome == store.OutcomeReplayed {
+		s.logger.Debug("Dredd correction already captured", "event_id", envelope.ID, "knowledge_id", entry.ID)
+		s.ack(msg)
+		return
+	}
 
 	s.logger.Info("captured Dredd correction as lesson",
 		"knowledge_id", entry.ID,
@@ -215,11 +252,20 @@ func (s *Subscriber) handleCorrection(msg *nats.Msg) {
 		"agent_id", signal.AgentID,
 		"category", signal.Category,
 		"severity", signal.Severity,
+		"outcome", result.Outcome,
 	)
 
-	// Publish vault.knowledge.created event
+	if result.Outcome == store.OutcomeCreated {
+		s.watchMatches(entry)
+	}
+
+	// Publish vault.knowledge.created (or .updated for a merged duplicate)
 	if s.publisher != nil {
-		_ = s.publisher.KnowledgeCreated(ctx, entry)
+		if result.Outcome == store.OutcomeCreated {
+			_ = s.publisher.KnowledgeCreated(ctx, entry)
+		} else {
+			_ = s.publisher.KnowledgeUpdated(ctx, entry)
+		}
 	}
 
 	s.ack(msg)
@@ -236,10 +282,13 @@ func (s *Subscriber) captureEvent(msg *nats.Msg, category store.KnowledgeCategor
 	ctx := context.Background()
 
 	// Generate embedding
+	var model string
 	embedding, err := s.embedder.Embed(ctx, event.Data.Content)
 	if err != nil {
 		s.logger.Error("failed to generate embedding", "error", err)
 		// Still persist without embedding
+	} else {
+		model = embeddings.ModelName(s.embedder)
 	}
 
 	summary := event.Data.Summary
@@ -257,28 +306,57 @@ func (s *Subscriber) captureEvent(msg *nats.Msg, category store.KnowledgeCategor
 		Scope:          store.ScopePublic,
 		Tags:           event.Data.Tags,
 		Embedding:      embedding,
+		EmbeddingModel: model,
 		SourceEventID:  &event.ID,
 		Confidence:     confidence,
 		RelevanceDecay: decay,
 	}
 
-	entry, err := s.knowledge.Create(ctx, input)
+	result, err := s.knowledge.CreateWithOutcome(ctx, input)
 	if err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			s.logger.Info("skipped duplicate Hermes event", "event_id", event.ID, "existing_id", dup.Existing.ID)
+			s.ack(msg)
+			return
+		}
+		if errors.Is(err, store.ErrQuotaExceeded) {
+			s.logger.Warn("dropped Hermes event over knowledge quota", "error", err, "event_id", event.ID, "source", event.Source)
+			s.ack(msg)
+			return
+		}
 		s.logger.Error("failed to persist Hermes event as knowledge", "error", err, "event_id", event.ID)
 		s.ack(msg)
 		return
 	}
+	entry := result.Entry
+
+	if result.Outcome == store.OutcomeReplayed {
+		// Redelivery of an event we already stored: success, nothing new to announce.
+		s.logger.Debug("Hermes event already captured", "event_id", event.ID, "knowledge_id", entry.ID)
+		s.ack(msg)
+		return
+	}
 
 	s.logger.Info("auto-captured knowledge from Hermes",
 		"knowledge_id", entry.ID,
 		"event_id", event.ID,
 		"category", category,
 		"source", event.Source,
+		"outcome", result.Outcome,
 	)
 
-	// Publish vault.knowledge.created event
+	if result.Outcome == store.OutcomeCreated {
+		s.watchMatches(entry)
+	}
+
+	// Publish vault.knowledge.created (or .updated for a merged duplicate)
 	if s.publisher != nil {
-		_ = s.publisher.KnowledgeCreated(ctx, entry)
+		if result.Outcome == store.OutcomeCreated {
+			_ = s.publisher.KnowledgeCreated(ctx, entry)
+		} else {
+			_ = s.publisher.KnowledgeUpdated(ctx, entry)
+		}
 	}
 
 	s.ack(msg)
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -143,9 +145,16 @@ type SemanticStatus struct {
 // apiEnvelope wraps Alexandria's standard response format.
 type apiEnvelope struct {
 	Data  json.RawMessage `json:"data"`
+	Meta  json.RawMessage `json:"meta,omitempty"`
 	Error json.RawMessage `json:"error,omitempty"`
 }
 
+// EntityPage is one page of entities with the cursor for the next page.
+type EntityPage struct {
+	Entities   []json.RawMessage `json:"entities"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
 // --- Methods ---
 
 // Resolve calls the identity resolution endpoint.
@@ -179,6 +188,34 @@ func (c *Client) ListEntities(ctx context.Context, entityType string) ([]json.Ra
 	return result, nil
 }
 
+// ListEntitiesPage lists one page of entities, optionally filtered by type.
+// Pass the returned NextCursor to fetch the following page.
+func (c *Client) ListEntitiesPage(ctx context.Context, entityType string, limit int, cursor string) (*EntityPage, error) {
+	q := url.Values{}
+	if entityType != "" {
+		q.Set("type", entityType)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/graph/entities?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	page := &EntityPage{}
+	var meta struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := c.doWithMeta(req, &page.Entities, &meta); err != nil {
+		return nil, err
+	}
+	page.NextCursor = meta.NextCursor
+	return page, nil
+}
+
 // CreateEdge creates a graph relationship.
 func (c *Client) CreateEdge(ctx context.Context, req CreateEdgeRequest) (json.RawMessage, error) {
 	var result json.RawMessage
@@ -249,6 +286,11 @@ func (c *Client) post(ctx context.Context, path string, body any, out any) error
 }
 
 func (c *Client) do(req *http.Request, out any) error {
+	return c.doWithMeta(req, out, nil)
+}
+
+// doWithMeta is do that also decodes the envelope's meta object into meta.
+func (c *Client) doWithMeta(req *http.Request, out, meta any) error {
 	if c.apiKey != "" {
 		req.Header.Set("X-API-Key", c.apiKey)
 	}
@@ -271,6 +313,11 @@ func (c *Client) do(req *http.Request, out any) error {
 		// Alexandria wraps responses in {"data": ...}
 		var envelope apiEnvelope
 		if err := json.Unmarshal(body, &envelope); err == nil && envelope.Data != nil {
+			if meta != nil && envelope.Meta != nil {
+				if err := json.Unmarshal(envelope.Meta, meta); err != nil {
+					return fmt.Errorf("decode meta: %w", err)
+				}
+			}
 			return json.Unmarshal(envelope.Data, out)
 		}
 		// Fallback: try direct unmarshal
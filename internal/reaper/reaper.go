@@ -0,0 +1,158 @@
+// Package reaper provides background lifecycle maintenance for knowledge:
+// soft-deleting expired or fully decayed entries and purging old trash.
+package reaper
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/blob"
+	"github.com/MikeSquared-Agency/Alexandria/internal/hermes"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// Config controls reaper scheduling and retention.
+type Config struct {
+	Interval     time.Duration // how often the reaper runs
+	Retention    time.Duration // how long soft-deleted entries are kept before purge
+	EphemeralTTL time.Duration // age at which ephemeral entries are treated as decayed; 0 disables
+	BatchSize    int           // max rows expired or purged per pass
+}
+
+// Stats reports reaper activity since process start.
+type Stats struct {
+	ExpiredTotal        int64      `json:"expired_total"`
+	PurgedTotal         int64      `json:"purged_total"`
+	BlobsCollectedTotal int64      `json:"blobs_collected_total"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// Reaper periodically expires and purges knowledge entries.
+type Reaper struct {
+	knowledge   *store.KnowledgeStore
+	attachments *store.AttachmentStore
+	blobs       blob.Store
+	publisher   *hermes.Publisher
+	config      Config
+	logger      *slog.Logger
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New creates a reaper. publisher may be nil.
+func New(knowledge *store.KnowledgeStore, publisher *hermes.Publisher, cfg Config, logger *slog.Logger) *Reaper {
+	return &Reaper{
+		knowledge: knowledge,
+		publisher: publisher,
+		config:    cfg,
+		logger:    logger,
+	}
+}
+
+// SetAttachments makes the reaper delete the blobs of attachments orphaned
+// by purged knowledge.
+func (r *Reaper) SetAttachments(attachments *store.AttachmentStore, blobs blob.Store) {
+	r.attachments = attachments
+	r.blobs = blobs
+}
+
+// Start launches the reaper loop. It runs until ctx is cancelled.
+func (r *Reaper) Start(ctx context.Context) {
+	r.logger.Info("knowledge reaper starting", "interval", r.config.Interval, "retention", r.config.Retention)
+	go r.runLoop(ctx)
+}
+
+// Stats returns a snapshot of reaper activity.
+func (r *Reaper) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func (r *Reaper) runLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	// Run once immediately
+	r.record(r.RunOnce(ctx))
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("knowledge reaper shutting down")
+			return
+		case <-ticker.C:
+			r.record(r.RunOnce(ctx))
+		}
+	}
+}
+
+func (r *Reaper) record(err error) {
+	now := time.Now()
+	r.mu.Lock()
+	r.stats.LastRunAt = &now
+	r.stats.LastError = ""
+	if err != nil {
+		r.stats.LastError = err.Error()
+	}
+	r.mu.Unlock()
+	if err != nil {
+		r.logger.Warn("knowledge reaper error", "error", err)
+	}
+}
+
+// RunOnce performs a single expire-and-purge pass.
+func (r *Reaper) RunOnce(ctx context.Context) error {
+	expired, err := r.knowledge.ExpireKnowledge(ctx, r.config.EphemeralTTL, r.config.BatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range expired {
+		if r.publisher != nil {
+			_ = r.publisher.KnowledgeExpired(ctx, &expired[i])
+		}
+	}
+
+	purged, err := r.knowledge.PurgeDeletedKnowledge(ctx, r.config.Retention, r.config.BatchSize)
+	var collected int64
+	if err == nil {
+		collected, err = r.collectBlobs(ctx)
+	}
+
+	r.mu.Lock()
+	r.stats.ExpiredTotal += int64(len(expired))
+	r.stats.PurgedTotal += purged
+	r.stats.BlobsCollectedTotal += collected
+	r.mu.Unlock()
+
+	if len(expired) > 0 || purged > 0 || collected > 0 {
+		r.logger.Info("knowledge reaper pass", "expired", len(expired), "purged", purged, "blobs_collected", collected)
+	}
+	return err
+}
+
+// collectBlobs deletes the blobs of orphaned attachments, then their rows.
+func (r *Reaper) collectBlobs(ctx context.Context) (int64, error) {
+	if r.attachments == nil || r.blobs == nil {
+		return 0, nil
+	}
+	orphans, err := r.attachments.ListOrphaned(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var collected int64
+	for _, a := range orphans {
+		if err := r.blobs.Delete(ctx, a.StorageKey); err != nil {
+			return collected, err
+		}
+		if err := r.attachments.DeleteOrphaned(ctx, a.ID); err != nil {
+			return collected, err
+		}
+		collected++
+	}
+	return collected, nil
+}
@@ -0,0 +1,241 @@
+// Package reembed keeps knowledge embeddings current. A periodic backfill
+// embeds entries that were stored without a vector or whose content changed
+// after embedding, and an on-demand job re-embeds every entry produced by
+// another model, e.g. after switching EMBEDDING_BACKEND.
+package reembed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// ErrJobRunning is returned by StartJob while a job is in progress.
+var ErrJobRunning = errors.New("re-embed job already running")
+
+// JobState is the lifecycle state of a re-embed job.
+type JobState string
+
+const (
+	JobIdle      JobState = "idle"
+	JobRunning   JobState = "running"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+)
+
+// Progress reports the current or last re-embed job.
+type Progress struct {
+	State      JobState   `json:"state"`
+	Model      string     `json:"model,omitempty"`
+	All        bool       `json:"all"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Embedded   int64      `json:"embedded"`
+	Skipped    int64      `json:"skipped"` // edited or deleted mid-job; left to the backfill
+	Failed     int64      `json:"failed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Config controls the backfill and job batch sizes.
+type Config struct {
+	Interval  time.Duration // backfill interval
+	BatchSize int           // entries embedded per provider call
+}
+
+// Worker runs the embedding backfill and re-embed jobs.
+type Worker struct {
+	knowledge *store.KnowledgeStore
+	embedder  embeddings.Provider
+	config    Config
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	ctx      context.Context // parent of jobs; set by Start
+	progress Progress
+}
+
+// New creates a Worker.
+func New(knowledge *store.KnowledgeStore, embedder embeddings.Provider, cfg Config, logger *slog.Logger) *Worker {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Worker{
+		knowledge: knowledge,
+		embedder:  embedder,
+		config:    cfg,
+		logger:    logger,
+		ctx:       context.Background(),
+		progress:  Progress{State: JobIdle},
+	}
+}
+
+// Model returns the name recorded for vectors from the current provider.
+func (w *Worker) Model() string {
+	return embeddings.ModelName(w.embedder)
+}
+
+// Start launches the backfill loop. It runs until ctx is cancelled; jobs
+// started afterwards are cancelled with it.
+func (w *Worker) Start(ctx context.Context) {
+	w.mu.Lock()
+	w.ctx = ctx
+	w.mu.Unlock()
+
+	w.logger.Info("embedding backfill starting", "model", w.Model(), "interval", w.config.Interval)
+	go w.runLoop(ctx)
+}
+
+func (w *Worker) runLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	// Run once immediately
+	w.backfill(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("embedding backfill shutting down")
+			return
+		case <-ticker.C:
+			w.backfill(ctx)
+		}
+	}
+}
+
+func (w *Worker) backfill(ctx context.Context) {
+	n, err := w.RunOnce(ctx)
+	if err != nil {
+		w.logger.Warn("embedding backfill error", "error", err)
+	}
+	if n > 0 {
+		w.logger.Info("embedding backfill pass", "entries", n)
+	}
+}
+
+// RunOnce embeds up to BatchSize entries with a missing or stale embedding
+// and returns how many it stored. If the provider fails, nothing is stored
+// and the entries are retried on the next pass.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	candidates, err := w.knowledge.ListStaleEmbeddings(ctx, w.config.BatchSize)
+	if err != nil || len(candidates) == 0 {
+		return 0, err
+	}
+	stored, _, err := w.embed(ctx, candidates)
+	return stored, err
+}
+
+// embed embeds candidates in one provider call and stores the vectors. It
+// returns how many were stored and how many were skipped because the entry
+// changed in the meantime.
+func (w *Worker) embed(ctx context.Context, candidates []store.EmbeddingCandidate) (int, int, error) {
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = c.Content
+	}
+	vecs, err := embeddings.EmbedAll(ctx, w.embedder, texts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("embedding %d entries: %w", len(candidates), err)
+	}
+
+	model := w.Model()
+	stored, skipped := 0, 0
+	for i, c := range candidates {
+		ok, err := w.knowledge.SetEmbedding(ctx, c.ID, c.ContentHash, vecs[i], model)
+		if err != nil {
+			w.logger.Warn("storing knowledge embedding", "id", c.ID, "error", err)
+			continue
+		}
+		if ok {
+			stored++
+		} else {
+			skipped++
+		}
+	}
+	return stored, skipped, nil
+}
+
+// Progress returns the state of the current or last job.
+func (w *Worker) Progress() Progress {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.progress
+}
+
+// StartJob starts re-embedding, in the background, every live entry that is
+// stale or was embedded by a model other than the current one, or every
+// entry if all is set. It returns the job's initial progress.
+func (w *Worker) StartJob(all bool) (Progress, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.progress.State == JobRunning {
+		return w.progress, ErrJobRunning
+	}
+
+	ctx := w.ctx
+	model := w.Model()
+	total, err := w.knowledge.CountReembed(ctx, model, all)
+	if err != nil {
+		return w.progress, err
+	}
+
+	now := time.Now().UTC()
+	w.progress = Progress{State: JobRunning, Model: model, All: all, Total: total, StartedAt: &now}
+	go w.runJob(ctx, model, all)
+	return w.progress, nil
+}
+
+func (w *Worker) runJob(ctx context.Context, model string, all bool) {
+	w.logger.Info("re-embed job starting", "model", model, "all", all, "total", w.Progress().Total)
+
+	var afterID string
+	var jobErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			jobErr = err
+			break
+		}
+		candidates, err := w.knowledge.ListReembed(ctx, model, all, afterID, w.config.BatchSize)
+		if err != nil {
+			jobErr = err
+			break
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		afterID = candidates[len(candidates)-1].ID
+
+		stored, skipped, err := w.embed(ctx, candidates)
+		if err != nil {
+			w.logger.Warn("re-embed batch failed", "after_id", afterID, "error", err)
+		}
+		w.mu.Lock()
+		w.progress.Processed += int64(len(candidates))
+		w.progress.Embedded += int64(stored)
+		w.progress.Skipped += int64(skipped)
+		w.progress.Failed += int64(len(candidates) - stored - skipped)
+		w.mu.Unlock()
+	}
+
+	now := time.Now().UTC()
+	w.mu.Lock()
+	w.progress.FinishedAt = &now
+	if jobErr != nil {
+		w.progress.State = JobFailed
+		w.progress.Error = jobErr.Error()
+	} else {
+		w.progress.State = JobCompleted
+	}
+	p := w.progress
+	w.mu.Unlock()
+
+	w.logger.Info("re-embed job finished", "state", p.State, "embedded", p.Embedded, "skipped", p.Skipped, "failed", p.Failed)
+}
@@ -0,0 +1,215 @@
+// Package rewrap moves sealed secret values to the newest master key. After
+// a key is added to the keyring as primary, a re-wrap job re-encrypts the
+// data key of every current and historical secret value still wrapped by an
+// older key, and seals legacy values under a data key of their own, so the
+// old master key can be retired.
+package rewrap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// ErrJobRunning is returned by StartJob while a job is in progress.
+var ErrJobRunning = errors.New("re-wrap job already running")
+
+// JobState is the lifecycle state of a re-wrap job.
+type JobState string
+
+const (
+	JobIdle      JobState = "idle"
+	JobRunning   JobState = "running"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+)
+
+// Progress reports the current or last re-wrap job.
+type Progress struct {
+	State      JobState   `json:"state"`
+	KeyID      string     `json:"key_id"` // the primary master key values are moved to
+	StartedBy  string     `json:"started_by,omitempty"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Rewrapped  int64      `json:"rewrapped"`
+	Skipped    int64      `json:"skipped"` // changed mid-job; already sealed under the primary key
+	Failed     int64      `json:"failed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Config controls the job batch size.
+type Config struct {
+	BatchSize int // rows fetched per query
+}
+
+// Worker runs re-wrap jobs.
+type Worker struct {
+	secrets   *store.SecretStore
+	audit     *store.AuditStore
+	encryptor encryption.Encryptor
+	config    Config
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	ctx      context.Context // parent of jobs; set by Start
+	progress Progress
+}
+
+// New creates a Worker.
+func New(secrets *store.SecretStore, audit *store.AuditStore, encryptor encryption.Encryptor, cfg Config, logger *slog.Logger) *Worker {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Worker{
+		secrets:   secrets,
+		audit:     audit,
+		encryptor: encryptor,
+		config:    cfg,
+		logger:    logger,
+		ctx:       context.Background(),
+		progress:  Progress{State: JobIdle, KeyID: encryptor.PrimaryKeyID()},
+	}
+}
+
+// Start sets the context jobs run under; they are cancelled with it.
+func (w *Worker) Start(ctx context.Context) {
+	w.mu.Lock()
+	w.ctx = ctx
+	w.mu.Unlock()
+}
+
+// KeyID returns the primary master key jobs move values to.
+func (w *Worker) KeyID() string {
+	return w.encryptor.PrimaryKeyID()
+}
+
+// Progress returns the state of the current or last job.
+func (w *Worker) Progress() Progress {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.progress
+}
+
+// StartJob starts re-wrapping, in the background, every sealed value not
+// under the primary master key, and returns the job's initial progress.
+// Each re-wrapped current secret is audited as startedBy.
+func (w *Worker) StartJob(startedBy string) (Progress, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.progress.State == JobRunning {
+		return w.progress, ErrJobRunning
+	}
+
+	ctx := w.ctx
+	if err := w.encryptor.Refresh(ctx); err != nil {
+		return w.progress, err
+	}
+	keyID := w.KeyID()
+	total, err := w.secrets.CountRewrap(ctx, keyID)
+	if err != nil {
+		return w.progress, err
+	}
+
+	now := time.Now().UTC()
+	w.progress = Progress{State: JobRunning, KeyID: keyID, StartedBy: startedBy, Total: total, StartedAt: &now}
+	go w.runJob(ctx, keyID, startedBy)
+	return w.progress, nil
+}
+
+func (w *Worker) runJob(ctx context.Context, keyID, startedBy string) {
+	w.logger.Info("re-wrap job starting", "key_id", keyID, "total", w.Progress().Total)
+
+	var jobErr error
+	for _, table := range store.CiphertextTables {
+		if jobErr = w.rewrapTable(ctx, table, keyID, startedBy); jobErr != nil {
+			break
+		}
+	}
+
+	now := time.Now().UTC()
+	w.mu.Lock()
+	w.progress.FinishedAt = &now
+	if jobErr != nil {
+		w.progress.State = JobFailed
+		w.progress.Error = jobErr.Error()
+	} else {
+		w.progress.State = JobCompleted
+	}
+	p := w.progress
+	w.mu.Unlock()
+
+	if w.audit != nil {
+		_ = w.audit.Log(ctx, store.ActionSecretRewrap, startedBy, nil, nil, p.State == JobCompleted, map[string]any{
+			"key_id":    keyID,
+			"state":     p.State,
+			"rewrapped": p.Rewrapped,
+			"skipped":   p.Skipped,
+			"failed":    p.Failed,
+		})
+	}
+	w.logger.Info("re-wrap job finished", "state", p.State, "rewrapped", p.Rewrapped, "skipped", p.Skipped, "failed", p.Failed)
+}
+
+// rewrapTable pages through table in ID order, so a value that keeps
+// failing is visited once per job.
+func (w *Worker) rewrapTable(ctx context.Context, table store.CiphertextTable, keyID, startedBy string) error {
+	var afterID string
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rows, err := w.secrets.ListRewrap(ctx, table, keyID, afterID, w.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+
+		for _, row := range rows {
+			w.rewrapRow(ctx, row, startedBy)
+		}
+	}
+}
+
+func (w *Worker) rewrapRow(ctx context.Context, row store.SealedRow, startedBy string) {
+	var rewrapped, skipped bool
+	value, changed, err := w.encryptor.Rewrap(ctx, row.Value)
+	if err == nil && changed {
+		rewrapped, err = w.secrets.SetRewrapped(ctx, row, value)
+		skipped = err == nil && !rewrapped
+	} else if err == nil {
+		skipped = true
+	}
+	if err != nil {
+		w.logger.Warn("re-wrapping secret", "table", row.Table, "id", row.ID, "name", row.Name, "error", err)
+	}
+
+	w.mu.Lock()
+	w.progress.Processed++
+	switch {
+	case rewrapped:
+		w.progress.Rewrapped++
+	case skipped:
+		w.progress.Skipped++
+	default:
+		w.progress.Failed++
+	}
+	w.mu.Unlock()
+
+	if rewrapped && row.Table == store.CiphertextSecrets && w.audit != nil {
+		name := row.Name
+		_ = w.audit.Log(ctx, store.ActionSecretRewrap, startedBy, &name, nil, true, map[string]any{
+			"from_key_id": row.Value.KeyID,
+			"to_key_id":   value.KeyID,
+		})
+	}
+}
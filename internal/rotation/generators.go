@@ -0,0 +1,208 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Built-in generator names, as set in a secret's rotation_generator.
+const (
+	GeneratorPassword   = "password"
+	GeneratorToken      = "token"
+	GeneratorSSHKeypair = "ssh-keypair"
+	GeneratorHook       = "hook"
+)
+
+// Request describes the secret a generator produces a new value for.
+type Request struct {
+	Secret  string         // secret name
+	Options map[string]any // the secret's rotation_options
+}
+
+// Generator produces the next value of a secret.
+type Generator interface {
+	Generate(ctx context.Context, req Request) (string, error)
+}
+
+// GeneratorFunc adapts a function to Generator.
+type GeneratorFunc func(ctx context.Context, req Request) (string, error)
+
+// Generate implements Generator.
+func (f GeneratorFunc) Generate(ctx context.Context, req Request) (string, error) {
+	return f(ctx, req)
+}
+
+// intOption reads a positive integer option, or def if it is absent.
+func intOption(opts map[string]any, key string, def, max int) (int, error) {
+	v, ok := opts[key]
+	if !ok {
+		return def, nil
+	}
+	f, ok := v.(float64) // JSON numbers
+	if !ok || f != float64(int(f)) || f < 1 || int(f) > max {
+		return 0, fmt.Errorf("option %q must be an integer between 1 and %d", key, max)
+	}
+	return int(f), nil
+}
+
+// stringOption reads a string option, or def if it is absent.
+func stringOption(opts map[string]any, key, def string) (string, error) {
+	v, ok := opts[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("option %q must be a non-empty string", key)
+	}
+	return s, nil
+}
+
+// Password generates random passwords. Options: length (default 32) and
+// alphabet (default letters, digits and punctuation safe in URLs and shells).
+func Password() Generator {
+	const defaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~"
+	return GeneratorFunc(func(_ context.Context, req Request) (string, error) {
+		length, err := intOption(req.Options, "length", 32, 1024)
+		if err != nil {
+			return "", err
+		}
+		alphabet, err := stringOption(req.Options, "alphabet", defaultAlphabet)
+		if err != nil {
+			return "", err
+		}
+		chars := []rune(alphabet)
+		n := big.NewInt(int64(len(chars)))
+		out := make([]rune, length)
+		for i := range out {
+			idx, err := rand.Int(rand.Reader, n)
+			if err != nil {
+				return "", err
+			}
+			out[i] = chars[idx.Int64()]
+		}
+		return string(out), nil
+	})
+}
+
+// Token generates random tokens. Options: bytes of randomness (default
+// 32), encoding (hex, base64 or base64url, the default) and prefix.
+func Token() Generator {
+	return GeneratorFunc(func(_ context.Context, req Request) (string, error) {
+		size, err := intOption(req.Options, "bytes", 32, 1024)
+		if err != nil {
+			return "", err
+		}
+		encoding, err := stringOption(req.Options, "encoding", "base64url")
+		if err != nil {
+			return "", err
+		}
+		prefix, _ := req.Options["prefix"].(string)
+
+		raw := make([]byte, size)
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+		switch encoding {
+		case "hex":
+			return prefix + hex.EncodeToString(raw), nil
+		case "base64":
+			return prefix + base64.StdEncoding.EncodeToString(raw), nil
+		case "base64url":
+			return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+		default:
+			return "", fmt.Errorf("option \"encoding\" must be hex, base64 or base64url")
+		}
+	})
+}
+
+// SSHKeypair generates SSH keys. The value is the private key in OpenSSH
+// format; ssh-keygen -y derives the public key from it. Options: type
+// (ed25519, the default, or rsa), bits for RSA (default 4096) and comment
+// (default the secret name).
+func SSHKeypair() Generator {
+	return GeneratorFunc(func(_ context.Context, req Request) (string, error) {
+		keyType, err := stringOption(req.Options, "type", "ed25519")
+		if err != nil {
+			return "", err
+		}
+		comment, err := stringOption(req.Options, "comment", req.Secret)
+		if err != nil {
+			return "", err
+		}
+
+		var key any
+		switch keyType {
+		case "ed25519":
+			_, key, err = ed25519.GenerateKey(rand.Reader)
+		case "rsa":
+			var bits int
+			if bits, err = intOption(req.Options, "bits", 4096, 8192); err != nil {
+				return "", err
+			}
+			if bits < 2048 {
+				return "", fmt.Errorf("option \"bits\" must be at least 2048")
+			}
+			key, err = rsa.GenerateKey(rand.Reader, bits)
+		default:
+			return "", fmt.Errorf("option \"type\" must be ed25519 or rsa")
+		}
+		if err != nil {
+			return "", fmt.Errorf("generating %s key: %w", keyType, err)
+		}
+
+		block, err := ssh.MarshalPrivateKey(key, comment)
+		if err != nil {
+			return "", fmt.Errorf("encoding %s key: %w", keyType, err)
+		}
+		return string(pem.EncodeToMemory(block)), nil
+	})
+}
+
+// Hook runs an operator-configured command to produce the new value, for
+// credentials that must also be changed where they are used (a database
+// password, an API key at its provider). The command gets the secret name
+// as its argument and {"secret": ..., "options": ...} as JSON on stdin, and
+// prints the new value on stdout; a non-zero exit fails the rotation.
+func Hook(command string, timeout time.Duration) Generator {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return GeneratorFunc(func(ctx context.Context, req Request) (string, error) {
+		input, err := json.Marshal(map[string]any{"secret": req.Secret, "options": req.Options})
+		if err != nil {
+			return "", err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, command, req.Secret)
+		cmd.Stdin = bytes.NewReader(input)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("rotation hook: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		value := strings.TrimRight(stdout.String(), "\r\n")
+		if value == "" {
+			return "", fmt.Errorf("rotation hook printed no value")
+		}
+		return value, nil
+	})
+}
@@ -0,0 +1,249 @@
+// Package rotation rotates secrets on schedule. A secret with a
+// rotation_interval_days is due once that many days have passed since it
+// was last rotated (or created). If it names a registered generator, the
+// scheduler generates a new value and rotates it; otherwise, or if the
+// generator fails, it reports the secret as overdue.
+package rotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// Actor is the agent ID scheduled rotations are recorded under.
+const Actor = "alexandria"
+
+// Publisher announces rotations and overdue secrets on the event bus.
+type Publisher interface {
+	SecretRotated(ctx context.Context, secretName, rotatedBy string) error
+	SecretRotationOverdue(ctx context.Context, secretName string, dueAt time.Time, reason string) error
+}
+
+// Config controls scheduling.
+type Config struct {
+	Interval      time.Duration // how often due secrets are checked
+	BatchSize     int           // due secrets fetched per page; each pass pages through them all
+	RenotifyAfter time.Duration // how often an overdue secret is re-announced
+}
+
+// Stats reports scheduler activity since process start.
+type Stats struct {
+	RotatedTotal int64      `json:"rotated_total"`
+	FailedTotal  int64      `json:"failed_total"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// Scheduler periodically rotates secrets that are due.
+type Scheduler struct {
+	secrets    *store.SecretStore
+	audit      *store.AuditStore
+	encryptor  encryption.Encryptor
+	publisher  Publisher
+	config     Config
+	logger     *slog.Logger
+	generators map[string]Generator
+
+	mu       sync.Mutex
+	stats    Stats
+	notified map[string]time.Time // secret ID -> last overdue event
+}
+
+// New creates a Scheduler with the password, token and ssh-keypair
+// generators registered. publisher may be nil.
+func New(secrets *store.SecretStore, audit *store.AuditStore, encryptor encryption.Encryptor, publisher Publisher, cfg Config, logger *slog.Logger) *Scheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.RenotifyAfter <= 0 {
+		cfg.RenotifyAfter = 24 * time.Hour
+	}
+	s := &Scheduler{
+		secrets:    secrets,
+		audit:      audit,
+		encryptor:  encryptor,
+		publisher:  publisher,
+		config:     cfg,
+		logger:     logger,
+		generators: map[string]Generator{},
+		notified:   map[string]time.Time{},
+	}
+	s.Register(GeneratorPassword, Password())
+	s.Register(GeneratorToken, Token())
+	s.Register(GeneratorSSHKeypair, SSHKeypair())
+	return s
+}
+
+// Register makes a generator available under name, replacing any generator
+// of that name. Register before Start.
+func (s *Scheduler) Register(name string, g Generator) {
+	s.generators[name] = g
+}
+
+// HasGenerator reports whether a generator is registered under name.
+func (s *Scheduler) HasGenerator(name string) bool {
+	_, ok := s.generators[name]
+	return ok
+}
+
+// Start launches the scheduler loop. It runs until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.logger.Info("secret rotation scheduler starting", "interval", s.config.Interval)
+	go s.runLoop(ctx)
+}
+
+// Stats returns a snapshot of scheduler activity.
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *Scheduler) runLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	// Run once immediately
+	s.record(s.RunOnce(ctx))
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("secret rotation scheduler shutting down")
+			return
+		case <-ticker.C:
+			s.record(s.RunOnce(ctx))
+		}
+	}
+}
+
+func (s *Scheduler) record(err error) {
+	now := time.Now()
+	s.mu.Lock()
+	s.stats.LastRunAt = &now
+	s.stats.LastError = ""
+	if err != nil {
+		s.stats.LastError = err.Error()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Warn("secret rotation scheduler error", "error", err)
+	}
+}
+
+// RunOnce rotates, or reports as overdue, every secret that is due. It
+// pages through them BatchSize at a time, so secrets that keep failing or
+// have no generator cannot crowd out the ones behind them.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	var rotated, failed int64
+	var after *store.RotationDue
+	for {
+		due, err := s.secrets.ListRotationDue(ctx, after, s.config.BatchSize)
+		if err != nil {
+			s.count(rotated, failed)
+			return err
+		}
+		for _, d := range due {
+			if d.Generator == "" {
+				s.overdue(ctx, d, "no rotation generator configured")
+				continue
+			}
+			err := s.rotate(ctx, d)
+			switch {
+			case err == nil:
+				rotated++
+			case errors.Is(err, store.ErrSecretChanged):
+				s.logger.Info("scheduled secret rotation skipped; secret changed since listed", "secret", d.Name)
+			default:
+				failed++
+				// The error may carry generator output, so it stays in the log.
+				s.logger.Warn("scheduled secret rotation failed", "secret", d.Name, "generator", d.Generator, "error", err)
+				s.overdue(ctx, d, "rotation generator failed")
+			}
+		}
+		if len(due) < s.config.BatchSize {
+			break
+		}
+		after = &due[len(due)-1]
+	}
+	s.count(rotated, failed)
+
+	if rotated > 0 || failed > 0 {
+		s.logger.Info("secret rotation pass", "rotated", rotated, "failed", failed)
+	}
+	return nil
+}
+
+func (s *Scheduler) count(rotated, failed int64) {
+	s.mu.Lock()
+	s.stats.RotatedTotal += rotated
+	s.stats.FailedTotal += failed
+	s.mu.Unlock()
+}
+
+// rotate generates, seals and stores a new value for a due secret.
+func (s *Scheduler) rotate(ctx context.Context, d store.RotationDue) error {
+	gen, ok := s.generators[d.Generator]
+	if !ok {
+		return fmt.Errorf("unknown rotation generator %q", d.Generator)
+	}
+	value, err := gen.Generate(ctx, Request{Secret: d.Name, Options: d.Options})
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("generator %q produced an empty value", d.Generator)
+	}
+	sealed, err := s.encryptor.Seal(ctx, value)
+	if err != nil {
+		return fmt.Errorf("encrypting new value: %w", err)
+	}
+	if err := s.secrets.RotateDue(ctx, d, sealed, Actor); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.notified, d.ID)
+	s.mu.Unlock()
+
+	name := d.Name
+	if s.audit != nil {
+		_ = s.audit.Log(ctx, store.ActionSecretRotate, Actor, &name, nil, true, map[string]any{
+			"scheduled": true,
+			"generator": d.Generator,
+		})
+	}
+	if s.publisher != nil {
+		_ = s.publisher.SecretRotated(ctx, d.Name, Actor)
+	}
+	s.logger.Info("secret rotated on schedule", "secret", d.Name, "generator", d.Generator)
+	return nil
+}
+
+// overdue announces an overdue secret, at most once per RenotifyAfter.
+func (s *Scheduler) overdue(ctx context.Context, d store.RotationDue, reason string) {
+	now := time.Now()
+	s.mu.Lock()
+	last, seen := s.notified[d.ID]
+	if seen && now.Sub(last) < s.config.RenotifyAfter {
+		s.mu.Unlock()
+		return
+	}
+	s.notified[d.ID] = now
+	s.mu.Unlock()
+
+	s.logger.Warn("secret rotation overdue", "secret", d.Name, "due_at", d.DueAt, "reason", reason)
+	if s.publisher != nil {
+		_ = s.publisher.SecretRotationOverdue(ctx, d.Name, d.DueAt, reason)
+	}
+}
@@ -10,15 +10,22 @@ import (
 	chimw "github.com/go-chi/chi/v5/middleware"
 
 	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/blob"
 	"github.com/MikeSquared-Agency/Alexandria/internal/bootctx"
 	"github.com/MikeSquared-Agency/Alexandria/internal/briefings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/chunking"
 	"github.com/MikeSquared-Agency/Alexandria/internal/config"
 	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
 	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
 	"github.com/MikeSquared-Agency/Alexandria/internal/hermes"
 	"github.com/MikeSquared-Agency/Alexandria/internal/identity"
 	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/reaper"
+	"github.com/MikeSquared-Agency/Alexandria/internal/reembed"
+	"github.com/MikeSquared-Agency/Alexandria/internal/rewrap"
+	"github.com/MikeSquared-Agency/Alexandria/internal/rotation"
 	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+	"github.com/MikeSquared-Agency/Alexandria/internal/watch"
 
 	"log/slog"
 )
@@ -28,50 +35,158 @@ type Server struct {
 	Router    *chi.Mux
 	Config    *config.Config
 	DB        *store.DB
+	Knowledge *store.KnowledgeStore // configured with the dedupe policy and quotas
 	Hermes    *hermes.Client
 	Publisher *hermes.Publisher
+	Reaper    *reaper.Reaper
+	Chunker   *chunking.Indexer
+	Reembed   *reembed.Worker
+	Rewrap    *rewrap.Worker      // nil without an encryptor
+	Rotation  *rotation.Scheduler // nil without an encryptor
+	Watcher   *watch.Watcher
 	Logger    *slog.Logger
+
+	health *api.HealthHandler
+}
+
+// SetEncryptionStatus sets the encryption status reported by /health.
+func (s *Server) SetEncryptionStatus(status encryption.Status) {
+	s.health.SetEncryption(status)
 }
 
 // New creates a new Server with all routes configured.
-func New(cfg *config.Config, db *store.DB, hermesClient *hermes.Client, embedder embeddings.Provider, encryptor *encryption.Encryptor, resolver *identity.Resolver, logger *slog.Logger) *Server {
+func New(cfg *config.Config, db *store.DB, hermesClient *hermes.Client, embedder embeddings.Provider, encryptor encryption.Encryptor, resolver *identity.Resolver, logger *slog.Logger) *Server {
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(chimw.RequestID)
 	r.Use(chimw.RealIP)
 	r.Use(chimw.Recoverer)
-	r.Use(chimw.Timeout(30 * time.Second))
 	r.Use(middleware.RequestLogging(logger))
 	r.Use(middleware.APIKeyAuth(cfg.APIKey))
 	r.Use(middleware.AgentAuth(cfg.JWTSecret))
 
 	// Stores
 	knowledgeStore := store.NewKnowledgeStore(db)
+	knowledgeStore.SetDedupe(store.DedupeConfig{
+		Policy:        store.DedupePolicy(cfg.KnowledgeDedupePolicy),
+		NearThreshold: cfg.NearDuplicateThreshold,
+	})
+	knowledgeStore.SetQuota(store.Quota{
+		MaxEntries:      cfg.QuotaMaxEntries,
+		MaxBytes:        cfg.QuotaMaxBytes,
+		MaxDailyCreates: cfg.QuotaMaxDailyCreates,
+	})
 	secretStore := store.NewSecretStore(db)
+	secretStore.SetHistoryLimit(cfg.SecretHistoryVersions)
 	graphStore := store.NewGraphStore(db)
 	auditStore := store.NewAuditStore(db)
+	rankingStore := store.NewRankingStore(db, store.HalfLives{
+		store.DecaySlow:      cfg.HalfLifeSlowDays,
+		store.DecayFast:      cfg.HalfLifeFastDays,
+		store.DecayEphemeral: cfg.HalfLifeEphemeralDays,
+	})
 
 	// New access control stores
 	peopleStore := store.NewPersonStore(db)
 	devicesStore := store.NewDeviceStore(db)
 	grantsStore := store.NewGrantStore(db)
 
+	// Attachment blobs (the backend is validated by config.Load)
+	attachmentStore := store.NewAttachmentStore(db)
+	blobs, err := blob.New(cfg.AttachmentBackend, cfg.AttachmentDir)
+	if err != nil {
+		logger.Error("invalid attachment backend, using filesystem", "error", err)
+		blobs = blob.NewFilesystem(cfg.AttachmentDir)
+	}
+
 	// Publisher (may be nil if NATS not available)
 	var publisher *hermes.Publisher
 	if hermesClient != nil {
 		publisher = hermes.NewPublisher(hermesClient, logger)
 	}
 
+	// Knowledge reaper (started by the caller)
+	knowledgeReaper := reaper.New(knowledgeStore, publisher, reaper.Config{
+		Interval:     cfg.ReaperInterval,
+		Retention:    cfg.KnowledgeRetention,
+		EphemeralTTL: cfg.EphemeralKnowledgeTTL,
+		BatchSize:    500,
+	}, logger)
+	knowledgeReaper.SetAttachments(attachmentStore, blobs)
+
+	// Knowledge chunker (started by the caller)
+	chunker := chunking.New(knowledgeStore, embedder, chunking.Config{
+		Size:      cfg.ChunkSize,
+		Overlap:   cfg.ChunkOverlap,
+		Interval:  cfg.ChunkingInterval,
+		BatchSize: 100,
+	}, logger)
+
+	// Embedding backfill (started by the caller); re-embed jobs run on demand
+	reembedder := reembed.New(knowledgeStore, embedder, reembed.Config{
+		Interval:  cfg.EmbeddingBackfillInterval,
+		BatchSize: 100,
+	}, logger)
+
+	// Saved search watcher (started by the caller)
+	savedSearchStore := store.NewSavedSearchStore(db)
+	var watchPublisher watch.Publisher
+	if publisher != nil {
+		watchPublisher = publisher
+	}
+	watcher := watch.New(savedSearchStore, embedder, watchPublisher, watch.Config{
+		WebhookTimeout: cfg.WatchWebhookTimeout,
+	}, logger)
+
 	// Handlers
-	healthHandler := api.NewHealthHandler(db, knowledgeStore, secretStore, hermesClient)
+	healthHandler := api.NewHealthHandler(db, knowledgeStore, secretStore, hermesClient, knowledgeReaper)
 	knowledgeHandler := api.NewKnowledgeHandler(knowledgeStore, auditStore, embedder, publisher)
+	if cfg.ChunkingEnabled {
+		knowledgeHandler.SetChunker(chunker)
+	}
+	knowledgeHandler.SetRanking(rankingStore)
+	if cfg.WatchEnabled {
+		knowledgeHandler.SetWatcher(watcher)
+	}
+	attachmentHandler := api.NewAttachmentHandler(knowledgeStore, attachmentStore, blobs, auditStore, store.AttachmentQuota{
+		MaxFileBytes:  cfg.AttachmentMaxBytes,
+		MaxEntryBytes: cfg.AttachmentEntryBytes,
+		MaxCount:      cfg.AttachmentMaxCount,
+	})
 	secretHandler := api.NewSecretHandler(secretStore, grantsStore, auditStore, encryptor, publisher)
 	briefingAssembler := briefings.NewAssembler(knowledgeStore, secretStore)
 	briefingHandler := api.NewBriefingHandler(briefingAssembler, auditStore, publisher)
+	briefingHandler.SetRanking(rankingStore)
+	rankingHandler := api.NewRankingHandler(rankingStore, auditStore)
+	savedSearchHandler := api.NewSavedSearchHandler(savedSearchStore, auditStore, embedder)
+	usageHandler := api.NewUsageHandler(knowledgeStore, auditStore)
 	contextAssembler := bootctx.NewAssembler(knowledgeStore, secretStore, graphStore, grantsStore)
 	contextHandler := api.NewContextHandler(contextAssembler, auditStore, publisher, logger)
 	graphHandler := api.NewGraphHandler(graphStore, auditStore)
+	auditHandler := api.NewAuditHandler(auditStore)
+	adminHandler := api.NewAdminHandler(reembedder, auditStore)
+	var rewrapper *rewrap.Worker
+	var rotator *rotation.Scheduler
+	if encryptor != nil {
+		rewrapper = rewrap.New(secretStore, auditStore, encryptor, rewrap.Config{BatchSize: 100}, logger)
+		adminHandler.SetRewrap(rewrapper)
+
+		// Scheduled secret rotation (started by the caller)
+		var rotationPublisher rotation.Publisher
+		if publisher != nil {
+			rotationPublisher = publisher
+		}
+		rotator = rotation.New(secretStore, auditStore, encryptor, rotationPublisher, rotation.Config{
+			Interval:  cfg.RotationInterval,
+			BatchSize: 100,
+		}, logger)
+		if cfg.RotationHook != "" {
+			rotator.Register(rotation.GeneratorHook, rotation.Hook(cfg.RotationHook, cfg.RotationHookTimeout))
+		}
+		secretHandler.SetRotation(rotator)
+		healthHandler.SetRotation(rotator)
+	}
 
 	// New access control handlers
 	peopleHandler := api.NewPeopleHandler(peopleStore, auditStore)
@@ -87,115 +202,177 @@ func New(cfg *config.Config, db *store.DB, hermesClient *hermes.Client, embedder
 	secretRL := middleware.NewRateLimiter(cfg.SecretRateLimit, cfg.RateWindow)
 	briefingRL := middleware.NewRateLimiter(cfg.BriefingRateLimit, cfg.RateWindow)
 
-	// Root-level health and info (no auth required)
-	r.Get("/health", healthHandler.Health)
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"service":"alexandria","version":"0.1.0"}`))
-	})
+	// Bulk transfers stream for as long as they make progress, so they sit
+	// outside the request timeout and extend their own deadlines.
+	r.With(knowledgeRL.Middleware).Get("/api/v1/knowledge/export", knowledgeHandler.Export)
+	r.With(knowledgeRL.Middleware).Post("/api/v1/knowledge/import", knowledgeHandler.Import)
+
+	r.Group(func(r chi.Router) {
+		r.Use(chimw.Timeout(30 * time.Second))
 
-	// Routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Health (no rate limit)
+		// Root-level health and info (no auth required)
 		r.Get("/health", healthHandler.Health)
-		r.Get("/stats", healthHandler.Stats)
-
-		// Knowledge
-		r.Route("/knowledge", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Post("/", knowledgeHandler.Create)
-			r.Get("/", knowledgeHandler.List)
-			r.Post("/search", knowledgeHandler.Search)
-			r.Post("/batch", knowledgeHandler.BatchCreate)
-			r.Get("/{id}", knowledgeHandler.Get)
-			r.Put("/{id}", knowledgeHandler.Update)
-			r.Delete("/{id}", knowledgeHandler.Delete)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"service":"alexandria","version":"0.1.0"}`))
 		})
 
-		// Secrets
-		r.Route("/secrets", func(r chi.Router) {
-			r.Use(secretRL.Middleware)
-			r.Post("/", secretHandler.Create)
-			r.Get("/", secretHandler.List)
-			r.Get("/{name}", secretHandler.Get)
-			r.Put("/{name}", secretHandler.Update)
-			r.Delete("/{name}", secretHandler.Delete)
-			r.Post("/{name}/rotate", secretHandler.Rotate)
-		})
+		// Routes
+		r.Route("/api/v1", func(r chi.Router) {
+			// Health (no rate limit)
+			r.Get("/health", healthHandler.Health)
+			r.Get("/stats", healthHandler.Stats)
+			r.Get("/audit", auditHandler.List)
 
-		// Briefings
-		r.Route("/briefings", func(r chi.Router) {
-			r.Use(briefingRL.Middleware)
-			r.Get("/{agent_id}", briefingHandler.Generate)
-		})
+			// Maintenance (warren only)
+			r.Route("/admin", func(r chi.Router) {
+				r.Post("/reembed", adminHandler.Reembed)
+				r.Get("/reembed", adminHandler.ReembedStatus)
+				r.Post("/secrets/rewrap", adminHandler.Rewrap)
+				r.Get("/secrets/rewrap", adminHandler.RewrapStatus)
+			})
 
-		// Boot Context
-		r.Route("/context", func(r chi.Router) {
-			r.Use(briefingRL.Middleware)
-			r.Get("/{agent_id}", contextHandler.Generate)
-		})
+			// Knowledge
+			r.Route("/knowledge", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Post("/", knowledgeHandler.Create)
+				r.Get("/", knowledgeHandler.List)
+				r.Post("/search", knowledgeHandler.Search)
+				r.Post("/batch", knowledgeHandler.BatchCreate)
+				r.Get("/trash", knowledgeHandler.Trash)
+				r.Get("/facets", knowledgeHandler.Facets)
+				r.Get("/{id}", knowledgeHandler.Get)
+				r.Put("/{id}", knowledgeHandler.Update)
+				r.Delete("/{id}", knowledgeHandler.Delete)
+				r.Get("/{id}/versions", knowledgeHandler.Versions)
+				r.Get("/{id}/versions/{n}", knowledgeHandler.GetVersion)
+				r.Post("/{id}/revert/{n}", knowledgeHandler.Revert)
+				r.Post("/{id}/supersede", knowledgeHandler.Supersede)
+				r.Get("/{id}/lineage", knowledgeHandler.Lineage)
+				r.Post("/{id}/similar", knowledgeHandler.Similar)
+				r.Post("/{id}/restore", knowledgeHandler.Restore)
+				r.Get("/{id}/attachments", attachmentHandler.List)
+				r.Post("/{id}/attachments", attachmentHandler.Upload)
+				r.Get("/{id}/attachments/{attachment_id}", attachmentHandler.Download)
+				r.Delete("/{id}/attachments/{attachment_id}", attachmentHandler.Delete)
+			})
 
-		// Knowledge Graph
-		r.Route("/graph", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Get("/entities", graphHandler.ListEntities)
-			r.Post("/entities", graphHandler.CreateEntity)
-			r.Get("/entities/{id}", graphHandler.GetEntity)
-			r.Get("/entities/{id}/related", graphHandler.GetRelatedEntities)
-			r.Post("/relationships", graphHandler.CreateRelationship)
-		})
+			// Secrets
+			r.Route("/secrets", func(r chi.Router) {
+				r.Use(secretRL.Middleware)
+				r.Post("/", secretHandler.Create)
+				r.Get("/", secretHandler.List)
+				r.Get("/{name}", secretHandler.Get)
+				r.Put("/{name}", secretHandler.Update)
+				r.Delete("/{name}", secretHandler.Delete)
+				r.Post("/{name}/rotate", secretHandler.Rotate)
+				r.Get("/{name}/versions", secretHandler.Versions)
+				r.Get("/{name}/versions/{n}", secretHandler.GetVersion)
+				r.Post("/{name}/rollback/{n}", secretHandler.Rollback)
+				r.Put("/{name}/rotation", secretHandler.SetRotationPolicy)
+			})
 
-		// Access Control - People
-		r.Route("/people", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Post("/", peopleHandler.Create)
-			r.Get("/", peopleHandler.List)
-			r.Get("/{id}", peopleHandler.Get)
-			r.Put("/{id}", peopleHandler.Update)
-			r.Delete("/{id}", peopleHandler.Delete)
-		})
+			// Ranking profiles
+			r.Route("/ranking-profiles", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Get("/", rankingHandler.List)
+				r.Get("/{name}", rankingHandler.Get)
+				r.Put("/{name}", rankingHandler.Put)
+				r.Delete("/{name}", rankingHandler.Delete)
+			})
 
-		// Access Control - Devices
-		r.Route("/devices", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Post("/", devicesHandler.Create)
-			r.Get("/", devicesHandler.List)
-			r.Get("/{id}", devicesHandler.Get)
-			r.Put("/{id}", devicesHandler.Update)
-			r.Delete("/{id}", devicesHandler.Delete)
-		})
+			// Agent knowledge usage and quotas
+			r.Route("/agents/{id}", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Get("/usage", usageHandler.Usage)
+				r.Put("/quota", usageHandler.SetQuota)
+				r.Delete("/quota", usageHandler.DeleteQuota)
+			})
 
-		// Access Control - Grants
-		r.Route("/grants", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Post("/", grantsHandler.Create)
-			r.Get("/", grantsHandler.List)
-			r.Get("/check", grantsHandler.CheckAccess)
-			r.Get("/{id}", grantsHandler.Get)
-			r.Delete("/{id}", grantsHandler.Delete)
-		})
+			// Saved searches
+			r.Route("/saved-searches", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Get("/", savedSearchHandler.List)
+				r.Post("/", savedSearchHandler.Create)
+				r.Get("/{id}", savedSearchHandler.Get)
+				r.Put("/{id}", savedSearchHandler.Update)
+				r.Delete("/{id}", savedSearchHandler.Delete)
+			})
 
-		// Identity Resolution
-		r.Route("/identity", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Post("/resolve", identityHandler.Resolve)
-			r.Post("/merge", identityHandler.Merge)
-			r.Get("/pending", identityHandler.Pending)
-			r.Post("/aliases/{id}/review", identityHandler.ReviewAlias)
-			r.Get("/entities/{id}", identityHandler.EntityLookup)
-		})
+			// Briefings
+			r.Route("/briefings", func(r chi.Router) {
+				r.Use(briefingRL.Middleware)
+				r.Get("/{agent_id}", briefingHandler.Generate)
+			})
+
+			// Boot Context
+			r.Route("/context", func(r chi.Router) {
+				r.Use(briefingRL.Middleware)
+				r.Get("/{agent_id}", contextHandler.Generate)
+			})
+
+			// Knowledge Graph
+			r.Route("/graph", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Get("/entities", graphHandler.ListEntities)
+				r.Post("/entities", graphHandler.CreateEntity)
+				r.Get("/entities/{id}", graphHandler.GetEntity)
+				r.Get("/entities/{id}/related", graphHandler.GetRelatedEntities)
+				r.Post("/relationships", graphHandler.CreateRelationship)
+			})
+
+			// Access Control - People
+			r.Route("/people", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Post("/", peopleHandler.Create)
+				r.Get("/", peopleHandler.List)
+				r.Get("/{id}", peopleHandler.Get)
+				r.Put("/{id}", peopleHandler.Update)
+				r.Delete("/{id}", peopleHandler.Delete)
+			})
+
+			// Access Control - Devices
+			r.Route("/devices", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Post("/", devicesHandler.Create)
+				r.Get("/", devicesHandler.List)
+				r.Get("/{id}", devicesHandler.Get)
+				r.Put("/{id}", devicesHandler.Update)
+				r.Delete("/{id}", devicesHandler.Delete)
+			})
+
+			// Access Control - Grants
+			r.Route("/grants", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Post("/", grantsHandler.Create)
+				r.Get("/", grantsHandler.List)
+				r.Get("/check", grantsHandler.CheckAccess)
+				r.Get("/{id}", grantsHandler.Get)
+				r.Delete("/{id}", grantsHandler.Delete)
+			})
+
+			// Identity Resolution
+			r.Route("/identity", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Post("/resolve", identityHandler.Resolve)
+				r.Post("/merge", identityHandler.Merge)
+				r.Get("/pending", identityHandler.Pending)
+				r.Post("/aliases/{id}/review", identityHandler.ReviewAlias)
+				r.Get("/entities/{id}", identityHandler.EntityLookup)
+			})
 
-		// Semantic Layer
-		r.Route("/semantic", func(r chi.Router) {
-			r.Use(knowledgeRL.Middleware)
-			r.Get("/status", semanticHandler.Status)
-			r.Get("/similar/{id}", semanticHandler.SimilarEntities)
-			r.Get("/clusters", semanticHandler.ListClusters)
-			r.Get("/clusters/{id}/members", semanticHandler.ClusterMembers)
-			r.Get("/entities/{id}/clusters", semanticHandler.EntityClusters)
-			r.Get("/proposals", semanticHandler.Proposals)
-			r.Post("/proposals/{id}/review", semanticHandler.ReviewProposal)
+			// Semantic Layer
+			r.Route("/semantic", func(r chi.Router) {
+				r.Use(knowledgeRL.Middleware)
+				r.Get("/status", semanticHandler.Status)
+				r.Get("/similar/{id}", semanticHandler.SimilarEntities)
+				r.Get("/clusters", semanticHandler.ListClusters)
+				r.Get("/clusters/{id}/members", semanticHandler.ClusterMembers)
+				r.Get("/entities/{id}/clusters", semanticHandler.EntityClusters)
+				r.Get("/proposals", semanticHandler.Proposals)
+				r.Post("/proposals/{id}/review", semanticHandler.ReviewProposal)
+			})
 		})
 	})
 
@@ -203,8 +380,16 @@ func New(cfg *config.Config, db *store.DB, hermesClient *hermes.Client, embedder
 		Router:    r,
 		Config:    cfg,
 		DB:        db,
+		Knowledge: knowledgeStore,
 		Hermes:    hermesClient,
 		Publisher: publisher,
+		Reaper:    knowledgeReaper,
+		Chunker:   chunker,
+		Reembed:   reembedder,
+		Rewrap:    rewrapper,
+		Rotation:  rotator,
+		Watcher:   watcher,
 		Logger:    logger,
+		health:    healthHandler,
 	}
 }
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when an attachment does not exist.
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentQuota is returned when an upload would exceed a quota.
+	ErrAttachmentQuota = errors.New("attachment quota exceeded")
+)
+
+// Attachment is a binary artifact attached to a knowledge entry. Its bytes
+// live in the blob backend under StorageKey.
+type Attachment struct {
+	ID          string    `json:"id"`
+	KnowledgeID string    `json:"knowledge_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	UploadedBy  string    `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentQuota limits attachments per knowledge entry. Zero disables a limit.
+type AttachmentQuota struct {
+	MaxFileBytes  int64 // largest single attachment
+	MaxEntryBytes int64 // total attachment bytes per entry
+	MaxCount      int   // attachments per entry
+}
+
+const attachmentColumns = `id, COALESCE(knowledge_id::text, ''), filename, content_type, size_bytes, sha256, storage_key, uploaded_by, created_at`
+
+func attachmentScanDest(a *Attachment) []any {
+	return []any{&a.ID, &a.KnowledgeID, &a.Filename, &a.ContentType, &a.SizeBytes, &a.SHA256, &a.StorageKey, &a.UploadedBy, &a.CreatedAt}
+}
+
+// AttachmentStore provides knowledge attachment metadata operations.
+type AttachmentStore struct {
+	db *DB
+}
+
+// NewAttachmentStore creates a new AttachmentStore.
+func NewAttachmentStore(db *DB) *AttachmentStore {
+	return &AttachmentStore{db: db}
+}
+
+// Remaining checks that agentID may attach to the entry and returns how many
+// bytes the next upload may hold under quota (-1 for unlimited).
+func (s *AttachmentStore) Remaining(ctx context.Context, knowledgeID, agentID string, quota AttachmentQuota) (int64, error) {
+	var remaining int64
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockKnowledgeForWrite(ctx, tx, knowledgeID, agentID); err != nil {
+			return err
+		}
+		var err error
+		remaining, err = remainingQuota(ctx, tx, knowledgeID, quota)
+		return err
+	})
+	return remaining, err
+}
+
+// remainingQuota returns the byte allowance for one more attachment, or
+// ErrAttachmentQuota if none is left.
+func remainingQuota(ctx context.Context, tx pgx.Tx, knowledgeID string, quota AttachmentQuota) (int64, error) {
+	var count int
+	var used int64
+	err := tx.QueryRow(ctx,
+		"SELECT COUNT(*), COALESCE(SUM(size_bytes), 0) FROM vault_knowledge_attachments WHERE knowledge_id = $1",
+		knowledgeID,
+	).Scan(&count, &used)
+	if err != nil {
+		return 0, fmt.Errorf("checking attachment usage: %w", err)
+	}
+	if quota.MaxCount > 0 && count >= quota.MaxCount {
+		return 0, ErrAttachmentQuota
+	}
+
+	remaining := int64(-1)
+	if quota.MaxEntryBytes > 0 {
+		remaining = quota.MaxEntryBytes - used
+		if remaining <= 0 {
+			return 0, ErrAttachmentQuota
+		}
+	}
+	if quota.MaxFileBytes > 0 && (remaining < 0 || quota.MaxFileBytes < remaining) {
+		remaining = quota.MaxFileBytes
+	}
+	return remaining, nil
+}
+
+// Create records an uploaded attachment. Ownership and quota are rechecked
+// under the parent's row lock, so concurrent uploads cannot overshoot.
+func (s *AttachmentStore) Create(ctx context.Context, agentID string, a Attachment, quota AttachmentQuota) (*Attachment, error) {
+	created := &Attachment{}
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockKnowledgeForWrite(ctx, tx, a.KnowledgeID, agentID); err != nil {
+			return err
+		}
+		remaining, err := remainingQuota(ctx, tx, a.KnowledgeID, quota)
+		if err != nil {
+			return err
+		}
+		if remaining >= 0 && a.SizeBytes > remaining {
+			return ErrAttachmentQuota
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO vault_knowledge_attachments (id, knowledge_id, filename, content_type, size_bytes, sha256, storage_key, uploaded_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING `+attachmentColumns,
+			a.ID, a.KnowledgeID, a.Filename, a.ContentType, a.SizeBytes, a.SHA256, a.StorageKey, agentID,
+		).Scan(attachmentScanDest(created)...)
+		if err != nil {
+			return fmt.Errorf("creating attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// List returns the attachments of a knowledge entry, oldest first. Callers
+// check access to the entry first.
+func (s *AttachmentStore) List(ctx context.Context, knowledgeID string) ([]Attachment, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM vault_knowledge_attachments
+		WHERE knowledge_id = $1
+		ORDER BY created_at, id`,
+		knowledgeID)
+	if err != nil {
+		return nil, fmt.Errorf("listing attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(attachmentScanDest(&a)...); err != nil {
+			return nil, fmt.Errorf("scanning attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Get returns one attachment of a knowledge entry.
+func (s *AttachmentStore) Get(ctx context.Context, knowledgeID, id string) (*Attachment, error) {
+	a := &Attachment{}
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM vault_knowledge_attachments
+		WHERE knowledge_id = $1 AND id = $2`,
+		knowledgeID, id,
+	).Scan(attachmentScanDest(a)...)
+	if err == pgx.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting attachment: %w", err)
+	}
+	return a, nil
+}
+
+// Delete removes an attachment record if agentID may modify its entry and
+// returns it so the caller can delete the blob.
+func (s *AttachmentStore) Delete(ctx context.Context, knowledgeID, id, agentID string) (*Attachment, error) {
+	deleted := &Attachment{}
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockKnowledgeForWrite(ctx, tx, knowledgeID, agentID); err != nil {
+			return err
+		}
+		err := tx.QueryRow(ctx, `
+			DELETE FROM vault_knowledge_attachments
+			WHERE knowledge_id = $1 AND id = $2
+			RETURNING `+attachmentColumns,
+			knowledgeID, id,
+		).Scan(attachmentScanDest(deleted)...)
+		if err == pgx.ErrNoRows {
+			return ErrAttachmentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("deleting attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// ListOrphaned returns attachments whose knowledge entry has been purged.
+func (s *AttachmentStore) ListOrphaned(ctx context.Context, limit int) ([]Attachment, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM vault_knowledge_attachments
+		WHERE knowledge_id IS NULL
+		ORDER BY created_at
+		LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing orphaned attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(attachmentScanDest(&a)...); err != nil {
+			return nil, fmt.Errorf("scanning attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteOrphaned removes an orphaned attachment record once its blob is gone.
+func (s *AttachmentStore) DeleteOrphaned(ctx context.Context, id string) error {
+	_, err := s.db.Pool.Exec(ctx,
+		"DELETE FROM vault_knowledge_attachments WHERE id = $1 AND knowledge_id IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("deleting orphaned attachment: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -14,10 +15,22 @@ const (
 	ActionKnowledgeWrite  AccessAction = "knowledge.write"
 	ActionKnowledgeSearch AccessAction = "knowledge.search"
 	ActionKnowledgeDelete AccessAction = "knowledge.delete"
+	ActionKnowledgeTrash   AccessAction = "knowledge.trash"
+	ActionKnowledgeRestore AccessAction = "knowledge.restore"
+	ActionKnowledgeExport  AccessAction = "knowledge.export"
+	ActionKnowledgeImport  AccessAction = "knowledge.import"
+	ActionKnowledgeReembed AccessAction = "knowledge.reembed"
+	ActionRankingWrite     AccessAction = "ranking.write"
+	ActionSavedSearchWrite AccessAction = "saved_search.write"
+	ActionQuotaWrite       AccessAction = "quota.write"
+	ActionAttachmentUpload   AccessAction = "attachment.upload"
+	ActionAttachmentDownload AccessAction = "attachment.download"
+	ActionAttachmentDelete   AccessAction = "attachment.delete"
 	ActionSecretRead      AccessAction = "secret.read"
 	ActionSecretWrite     AccessAction = "secret.write"
 	ActionSecretDelete    AccessAction = "secret.delete"
 	ActionSecretRotate    AccessAction = "secret.rotate"
+	ActionSecretRewrap    AccessAction = "secret.rewrap"
 	ActionBriefingGen     AccessAction = "briefing.generate"
 	ActionContextGen      AccessAction = "context.generate"
 	ActionGraphRead        AccessAction = "graph.read"
@@ -104,3 +117,67 @@ func (s *AuditStore) Query(ctx context.Context, agentID *string, action *AccessA
 	}
 	return entries, rows.Err()
 }
+
+// QueryPage retrieves audit log entries newest first using keyset pagination.
+func (s *AuditStore) QueryPage(ctx context.Context, agentID *string, action *AccessAction, page PageRequest) ([]AccessLogEntry, PageInfo, error) {
+	var info PageInfo
+	var conditions []string
+	var args []any
+	argN := 1
+
+	if agentID != nil {
+		conditions = append(conditions, fmt.Sprintf("agent_id = $%d", argN))
+		args = append(args, *agentID)
+		argN++
+	}
+	if action != nil {
+		conditions = append(conditions, fmt.Sprintf("action = $%d", argN))
+		args = append(args, *action)
+		argN++
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_access_log", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`SELECT id, action, agent_id, resource_id, ip_address, success, metadata, created_at
+		FROM vault_access_log WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, where, limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("querying audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AccessLogEntry
+	for rows.Next() {
+		var e AccessLogEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.AgentID, &e.ResourceID, &e.IPAddress,
+			&e.Success, &e.Metadata, &e.CreatedAt); err != nil {
+			return nil, info, fmt.Errorf("scanning audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	entries, info.NextCursor = trimPage(entries, limit, func(e AccessLogEntry) string {
+		return EncodeCursor(timeCursorKey(e.CreatedAt), e.ID)
+	})
+	return entries, info, nil
+}
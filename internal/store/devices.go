@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -165,6 +166,69 @@ func (s *DeviceStore) ListByOwner(ctx context.Context, ownerID string) ([]Device
 	return devices, rows.Err()
 }
 
+// ListPage returns devices newest first using keyset pagination,
+// optionally restricted to one owner.
+func (s *DeviceStore) ListPage(ctx context.Context, ownerID *string, page PageRequest) ([]Device, PageInfo, error) {
+	var info PageInfo
+	var conditions []string
+	var args []any
+	argN := 1
+	if ownerID != nil {
+		conditions = append(conditions, fmt.Sprintf("owner_id = $%d", argN))
+		args = append(args, *ownerID)
+		argN++
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_devices", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`
+		SELECT id, name, device_type, owner_id, identifier, metadata, last_seen, created_at, updated_at
+		FROM vault_devices WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, where, limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []Device
+	for rows.Next() {
+		var d Device
+		if err := rows.Scan(
+			&d.ID, &d.Name, &d.DeviceType, &d.OwnerID,
+			&d.Identifier, &d.Metadata, &d.LastSeen,
+			&d.CreatedAt, &d.UpdatedAt,
+		); err != nil {
+			return nil, info, fmt.Errorf("scanning device: %w", err)
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	devices, info.NextCursor = trimPage(devices, limit, func(d Device) string {
+		return EncodeCursor(timeCursorKey(d.CreatedAt), d.ID)
+	})
+	return devices, info, nil
+}
+
 // Update updates a device.
 func (s *DeviceStore) Update(ctx context.Context, id string, input DeviceUpdateInput) (*Device, error) {
 	// Build dynamic query
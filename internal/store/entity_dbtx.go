@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -106,3 +107,57 @@ func ListEntitiesTx(ctx context.Context, db DBTX, entityType string) ([]CGEntity
 	}
 	return result, rows.Err()
 }
+
+// ListEntitiesPageTx lists non-deleted entities newest first with keyset
+// pagination using a DBTX. Unlike ListEntitiesTx it never returns more than
+// one page.
+func ListEntitiesPageTx(ctx context.Context, db DBTX, entityType string, page PageRequest) ([]CGEntity, PageInfo, error) {
+	var info PageInfo
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+	argN := 1
+	if entityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argN))
+		args = append(args, entityType)
+		argN++
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, db, "vault_entities", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	limit := page.pageLimit()
+
+	rows, err := db.Query(ctx, fmt.Sprintf(`SELECT id, entity_type, key, display_name, summary, metadata, created_at, updated_at, deleted_at
+		FROM vault_entities WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, strings.Join(conditions, " AND "), limit+1), args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("list entities: %w", err)
+	}
+	defer rows.Close()
+
+	var result []CGEntity
+	for rows.Next() {
+		var e CGEntity
+		if err := rows.Scan(&e.ID, &e.Type, &e.Key, &e.DisplayName, &e.Summary, &e.Metadata,
+			&e.CreatedAt, &e.UpdatedAt, &e.DeletedAt); err != nil {
+			return nil, info, fmt.Errorf("scan entity: %w", err)
+		}
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	result, info.NextCursor = trimPage(result, limit, func(e CGEntity) string {
+		return EncodeCursor(timeCursorKey(e.CreatedAt), e.ID.String())
+	})
+	return result, info, nil
+}
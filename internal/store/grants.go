@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -135,6 +136,79 @@ func (s *GrantStore) List(ctx context.Context, resourceType, resourceID, subject
 	return grants, rows.Err()
 }
 
+// ListPage returns access grants newest first using keyset pagination,
+// with the same optional filters as List.
+func (s *GrantStore) ListPage(ctx context.Context, resourceType, resourceID, subjectType, subjectID *string, page PageRequest) ([]AccessGrant, PageInfo, error) {
+	var info PageInfo
+	var conditions []string
+	var args []any
+	argN := 1
+	for _, f := range []struct {
+		col string
+		val *string
+	}{
+		{"resource_type", resourceType},
+		{"resource_id", resourceID},
+		{"subject_type", subjectType},
+		{"subject_id", subjectID},
+	} {
+		if f.val != nil {
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", f.col, argN))
+			args = append(args, *f.val)
+			argN++
+		}
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_access_grants", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`
+		SELECT id, resource_type, resource_id, subject_type, subject_id, permission, granted_by, created_at
+		FROM vault_access_grants WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, where, limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing access grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []AccessGrant
+	for rows.Next() {
+		var g AccessGrant
+		if err := rows.Scan(
+			&g.ID, &g.ResourceType, &g.ResourceID,
+			&g.SubjectType, &g.SubjectID, &g.Permission,
+			&g.GrantedBy, &g.CreatedAt,
+		); err != nil {
+			return nil, info, fmt.Errorf("scanning access grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	grants, info.NextCursor = trimPage(grants, limit, func(g AccessGrant) string {
+		return EncodeCursor(timeCursorKey(g.CreatedAt), g.ID)
+	})
+	return grants, info, nil
+}
+
 // CheckAccess checks if a subject has access to a resource.
 func (s *GrantStore) CheckAccess(ctx context.Context, subjectType, subjectID, resourceType, resourceID string) (bool, error) {
 	query := `
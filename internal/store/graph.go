@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -97,7 +98,7 @@ func (s *GraphStore) GetEntity(ctx context.Context, id string) (*Entity, error)
 	return e, nil
 }
 
-// ListEntities lists entities with optional type filter.
+// ListEntities lists entities newest first with optional type filter.
 func (s *GraphStore) ListEntities(ctx context.Context, entityType *EntityType, limit, offset int) ([]Entity, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50
@@ -107,11 +108,11 @@ func (s *GraphStore) ListEntities(ctx context.Context, entityType *EntityType, l
 	var args []any
 	if entityType != nil {
 		query = `SELECT id, name, entity_type, key, display_name, summary, metadata, created_at, updated_at, deleted_at
-			FROM vault_entities WHERE entity_type = $1 AND deleted_at IS NULL ORDER BY name LIMIT $2 OFFSET $3`
+			FROM vault_entities WHERE entity_type = $1 AND deleted_at IS NULL ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
 		args = []any{*entityType, limit, offset}
 	} else {
 		query = `SELECT id, name, entity_type, key, display_name, summary, metadata, created_at, updated_at, deleted_at
-			FROM vault_entities WHERE deleted_at IS NULL ORDER BY name LIMIT $1 OFFSET $2`
+			FROM vault_entities WHERE deleted_at IS NULL ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2`
 		args = []any{limit, offset}
 	}
 
@@ -133,6 +134,60 @@ func (s *GraphStore) ListEntities(ctx context.Context, entityType *EntityType, l
 	return entities, rows.Err()
 }
 
+// ListEntitiesPage lists non-deleted entities newest first using keyset pagination.
+func (s *GraphStore) ListEntitiesPage(ctx context.Context, entityType *EntityType, page PageRequest) ([]Entity, PageInfo, error) {
+	var info PageInfo
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+	argN := 1
+	if entityType != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argN))
+		args = append(args, *entityType)
+		argN++
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_entities", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`SELECT id, name, entity_type, key, display_name, summary, metadata, created_at, updated_at, deleted_at
+		FROM vault_entities WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, strings.Join(conditions, " AND "), limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing entities: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []Entity
+	for rows.Next() {
+		var e Entity
+		if err := rows.Scan(&e.ID, &e.Name, &e.EntityType, &e.Key, &e.DisplayName, &e.Summary,
+			&e.Metadata, &e.CreatedAt, &e.UpdatedAt, &e.DeletedAt); err != nil {
+			return nil, info, fmt.Errorf("scanning entity: %w", err)
+		}
+		entities = append(entities, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	entities, info.NextCursor = trimPage(entities, limit, func(e Entity) string {
+		return EncodeCursor(timeCursorKey(e.CreatedAt), e.ID)
+	})
+	return entities, info, nil
+}
+
 // GetEntityByName retrieves an entity by name and type (case-insensitive).
 func (s *GraphStore) GetEntityByName(ctx context.Context, name string, entityType EntityType) (*Entity, error) {
 	e := &Entity{}
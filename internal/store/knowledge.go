@@ -2,8 +2,9 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -60,10 +61,25 @@ type KnowledgeEntry struct {
 	RelevanceDecay RelevanceDecay    `json:"relevance_decay"`
 	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
 	SupersededBy   *string           `json:"superseded_by,omitempty"`
+	Version        int               `json:"version"`
 	CreatedAt      time.Time         `json:"created_at"`
 	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
+// knowledgeColumns is the column list matching knowledgeScanDest.
+const knowledgeColumns = `id, content, summary, source_agent, category, scope, shared_with, tags, metadata,
+		source_event_id, confidence, relevance_decay, expires_at, superseded_by, version, created_at, updated_at`
+
+// knowledgeScanDest returns scan targets for knowledgeColumns.
+func knowledgeScanDest(e *KnowledgeEntry) []any {
+	return []any{
+		&e.ID, &e.Content, &e.Summary, &e.SourceAgent, &e.Category,
+		&e.Scope, &e.SharedWith, &e.Tags, &e.Metadata, &e.SourceEventID,
+		&e.Confidence, &e.RelevanceDecay, &e.ExpiresAt, &e.SupersededBy,
+		&e.Version, &e.CreatedAt, &e.UpdatedAt,
+	}
+}
+
 // KnowledgeCreateInput is the input for creating a knowledge entry.
 type KnowledgeCreateInput struct {
 	Content        string            `json:"content"`
@@ -74,11 +90,13 @@ type KnowledgeCreateInput struct {
 	SharedWith     []string          `json:"shared_with,omitempty"`
 	Tags           []string          `json:"tags,omitempty"`
 	Embedding      pgvector.Vector   `json:"-"`
+	EmbeddingModel string            `json:"-"` // model that produced Embedding
 	Metadata       map[string]any    `json:"metadata,omitempty"`
 	SourceEventID  *string           `json:"source_event_id,omitempty"`
 	Confidence     float64           `json:"confidence"`
 	RelevanceDecay RelevanceDecay    `json:"relevance_decay"`
 	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	Dedupe         DedupePolicy      `json:"-"` // overrides the store's dedupe policy when set
 }
 
 // KnowledgeUpdateInput is the input for updating a knowledge entry.
@@ -90,6 +108,7 @@ type KnowledgeUpdateInput struct {
 	SharedWith     []string           `json:"shared_with,omitempty"`
 	Tags           []string           `json:"tags,omitempty"`
 	Embedding      *pgvector.Vector   `json:"-"`
+	EmbeddingModel string             `json:"-"` // model that produced Embedding
 	Metadata       map[string]any     `json:"metadata,omitempty"`
 	Confidence     *float64           `json:"confidence,omitempty"`
 	RelevanceDecay *RelevanceDecay    `json:"relevance_decay,omitempty"`
@@ -99,35 +118,84 @@ type KnowledgeUpdateInput struct {
 
 // KnowledgeFilter specifies filter criteria for listing knowledge.
 type KnowledgeFilter struct {
-	Category    *KnowledgeCategory
-	Scope       *KnowledgeScope
-	SourceAgent *string
-	Tags        []string
-	AgentID     string // requesting agent for access control
-	Limit       int
-	Offset      int
+	Category      *KnowledgeCategory
+	Scope         *KnowledgeScope
+	SourceAgent   *string
+	Tags          []string // any of these tags
+	TagsAll       []string // all of these tags
+	TagsNone      []string // none of these tags
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	MinConfidence *float64
+	Metadata      []MetadataPredicate // all must hold
+	AgentID       string              // requesting agent for access control
+	Limit         int
+	Offset        int
 }
 
+// SearchMode selects how knowledge search ranks results.
+type SearchMode string
+
+const (
+	SearchModeVector  SearchMode = "vector"
+	SearchModeLexical SearchMode = "lexical"
+	SearchModeHybrid  SearchMode = "hybrid"
+)
+
+// rrfK is the reciprocal-rank fusion constant; 60 is the value from the
+// original RRF paper and dampens the influence of top-ranked outliers.
+const rrfK = 60
+
+// rankCandidateFactor controls how many candidates each ranker fetches
+// relative to the requested limit, before ranking profiles reorder them.
+const rankCandidateFactor = 4
+
+// defaultMinSimilarity is the similarity a vector match needs when the
+// search sets no min_relevance.
+const defaultMinSimilarity = 0.5
+
 // SearchInput represents a semantic search request.
 type SearchInput struct {
 	QueryEmbedding pgvector.Vector
+	QueryText      string
+	Mode           SearchMode
 	Limit          int
 	Scope          *KnowledgeScope
 	Categories     []KnowledgeCategory
 	AgentID        string
 	MinRelevance   float64
 	IncludeExpired bool
+	Explain        bool            // attach an Explanation to each result
+	Profile        *RankingProfile // nil for the built-in default
+	ExcludeIDs     []string        // entries left out of the results, e.g. the examples
 }
 
 // SearchResult is a knowledge entry with relevance score.
 type SearchResult struct {
 	KnowledgeEntry
-	Similarity float64 `json:"relevance"`
+	Similarity   float64  `json:"relevance"`
+	VectorScore  *float64 `json:"vector_score,omitempty"`
+	LexicalScore *float64 `json:"lexical_score,omitempty"`
+	// Chunk is the best-matching chunk of a long entry, when one matched.
+	Chunk *ChunkMatch `json:"chunk,omitempty"`
+	// Explanation breaks the relevance down when the search asked for it.
+	Explanation *SearchExplanation `json:"explanation,omitempty"`
 }
 
+var (
+	// ErrKnowledgeNotFound is returned when a knowledge entry does not exist or is deleted.
+	ErrKnowledgeNotFound = errors.New("not found")
+	// ErrAccessDenied is returned when an agent may not modify a knowledge entry.
+	ErrAccessDenied = errors.New("access denied")
+	// ErrVersionNotFound is returned when a requested knowledge or secret version does not exist.
+	ErrVersionNotFound = errors.New("version not found")
+)
+
 // KnowledgeStore provides knowledge CRUD operations.
 type KnowledgeStore struct {
-	db *DB
+	db     *DB
+	dedupe DedupeConfig
+	quota  Quota
 }
 
 // NewKnowledgeStore creates a new KnowledgeStore.
@@ -135,45 +203,72 @@ func NewKnowledgeStore(db *DB) *KnowledgeStore {
 	return &KnowledgeStore{db: db}
 }
 
-// Create inserts a new knowledge entry.
+// Create inserts a new knowledge entry, applying the dedupe policy. When the
+// input duplicates an existing entry, that entry is returned instead.
 func (s *KnowledgeStore) Create(ctx context.Context, input KnowledgeCreateInput) (*KnowledgeEntry, error) {
+	result, err := s.CreateWithOutcome(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return result.Entry, nil
+}
+
+// errSourceEventExists reports that insertKnowledge hit an existing
+// (source_agent, source_event_id) pair and inserted nothing.
+var errSourceEventExists = errors.New("source event already recorded")
+
+// insertKnowledge inserts a knowledge entry using db, which may be a transaction.
+func insertKnowledge(ctx context.Context, db DBTX, input KnowledgeCreateInput) (*KnowledgeEntry, error) {
 	query := `
-		INSERT INTO vault_knowledge (content, summary, source_agent, category, scope, shared_with, tags, embedding, metadata, source_event_id, confidence, relevance_decay, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		RETURNING id, content, summary, source_agent, category, scope, shared_with, tags, metadata, source_event_id, confidence, relevance_decay, expires_at, superseded_by, created_at, updated_at`
+		INSERT INTO vault_knowledge (content, summary, source_agent, category, scope, shared_with, tags, embedding, metadata, source_event_id, confidence, relevance_decay, expires_at, embedding_model)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''))
+		ON CONFLICT (source_agent, source_event_id) WHERE source_event_id IS NOT NULL AND deleted_at IS NULL DO NOTHING
+		RETURNING ` + knowledgeColumns
 
 	entry := &KnowledgeEntry{}
-	err := s.db.Pool.QueryRow(ctx, query,
+	err := db.QueryRow(ctx, query,
 		input.Content, input.Summary, input.SourceAgent, input.Category, input.Scope,
 		input.SharedWith, input.Tags, input.Embedding, input.Metadata, input.SourceEventID,
-		input.Confidence, input.RelevanceDecay, input.ExpiresAt,
-	).Scan(
-		&entry.ID, &entry.Content, &entry.Summary, &entry.SourceAgent, &entry.Category,
-		&entry.Scope, &entry.SharedWith, &entry.Tags, &entry.Metadata, &entry.SourceEventID,
-		&entry.Confidence, &entry.RelevanceDecay, &entry.ExpiresAt, &entry.SupersededBy,
-		&entry.CreatedAt, &entry.UpdatedAt,
-	)
+		input.Confidence, input.RelevanceDecay, input.ExpiresAt, input.EmbeddingModel,
+	).Scan(knowledgeScanDest(entry)...)
+	if err == pgx.ErrNoRows {
+		return nil, errSourceEventExists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("creating knowledge entry: %w", err)
 	}
 	return entry, nil
 }
 
+// findBySourceEvent returns the live entry created by sourceAgent for
+// eventID, or nil if there is none. A deleted entry is not replayed, so the
+// event creates a new one.
+func findBySourceEvent(ctx context.Context, db DBTX, sourceAgent, eventID string) (*KnowledgeEntry, error) {
+	entry := &KnowledgeEntry{}
+	err := db.QueryRow(ctx, `
+		SELECT `+knowledgeColumns+`
+		FROM vault_knowledge
+		WHERE source_agent = $1 AND source_event_id = $2 AND deleted_at IS NULL`,
+		sourceAgent, eventID,
+	).Scan(knowledgeScanDest(entry)...)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding knowledge by source event: %w", err)
+	}
+	return entry, nil
+}
+
 // GetByID retrieves a knowledge entry by ID with access control.
 func (s *KnowledgeStore) GetByID(ctx context.Context, id, agentID string) (*KnowledgeEntry, error) {
 	query := `
-		SELECT id, content, summary, source_agent, category, scope, shared_with, tags, metadata,
-		       source_event_id, confidence, relevance_decay, expires_at, superseded_by, created_at, updated_at
+		SELECT ` + knowledgeColumns + `
 		FROM vault_knowledge
 		WHERE id = $1 AND deleted_at IS NULL`
 
 	entry := &KnowledgeEntry{}
-	err := s.db.Pool.QueryRow(ctx, query, id).Scan(
-		&entry.ID, &entry.Content, &entry.Summary, &entry.SourceAgent, &entry.Category,
-		&entry.Scope, &entry.SharedWith, &entry.Tags, &entry.Metadata, &entry.SourceEventID,
-		&entry.Confidence, &entry.RelevanceDecay, &entry.ExpiresAt, &entry.SupersededBy,
-		&entry.CreatedAt, &entry.UpdatedAt,
-	)
+	err := s.db.Pool.QueryRow(ctx, query, id).Scan(knowledgeScanDest(entry)...)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -191,11 +286,104 @@ func (s *KnowledgeStore) GetByID(ctx context.Context, id, agentID string) (*Know
 
 // List retrieves knowledge entries with filters and access control.
 func (s *KnowledgeStore) List(ctx context.Context, filter KnowledgeFilter) ([]KnowledgeEntry, error) {
+	conditions, args, _ := knowledgeListConditions(filter)
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM vault_knowledge
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT %d OFFSET %d`,
+		knowledgeColumns, strings.Join(conditions, " AND "), limit, offset)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing knowledge: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []KnowledgeEntry
+	for rows.Next() {
+		var e KnowledgeEntry
+		if err := rows.Scan(knowledgeScanDest(&e)...); err != nil {
+			return nil, fmt.Errorf("scanning knowledge entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ListPage retrieves knowledge entries newest first using keyset pagination.
+// Filter Limit and Offset are ignored in favour of page.
+func (s *KnowledgeStore) ListPage(ctx context.Context, filter KnowledgeFilter, page PageRequest) ([]KnowledgeEntry, PageInfo, error) {
+	var info PageInfo
+	conditions, args, argN := knowledgeListConditions(filter)
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_knowledge", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM vault_knowledge
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT %d`,
+		knowledgeColumns, strings.Join(conditions, " AND "), limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing knowledge: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []KnowledgeEntry
+	for rows.Next() {
+		var e KnowledgeEntry
+		if err := rows.Scan(knowledgeScanDest(&e)...); err != nil {
+			return nil, info, fmt.Errorf("scanning knowledge entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	entries, info.NextCursor = trimPage(entries, limit, func(e KnowledgeEntry) string {
+		return EncodeCursor(timeCursorKey(e.CreatedAt), e.ID)
+	})
+	return entries, info, nil
+}
+
+// knowledgeListConditions builds the WHERE conditions shared by List and
+// ListPage. It returns the conditions, their args and the next placeholder.
+func knowledgeListConditions(filter KnowledgeFilter) ([]string, []any, int) {
 	var conditions []string
 	var args []any
 	argN := 1
 
 	conditions = append(conditions, "deleted_at IS NULL")
+	// Expired entries are hidden even before the reaper soft-deletes them.
+	conditions = append(conditions, "(expires_at IS NULL OR expires_at > NOW())")
 
 	if filter.Category != nil {
 		conditions = append(conditions, fmt.Sprintf("category = $%d", argN))
@@ -217,6 +405,36 @@ func (s *KnowledgeStore) List(ctx context.Context, filter KnowledgeFilter) ([]Kn
 		args = append(args, filter.Tags)
 		argN++
 	}
+	if len(filter.TagsAll) > 0 {
+		conditions = append(conditions, fmt.Sprintf("tags @> $%d", argN))
+		args = append(args, filter.TagsAll)
+		argN++
+	}
+	if len(filter.TagsNone) > 0 {
+		conditions = append(conditions, fmt.Sprintf("NOT (COALESCE(tags, '{}') && $%d)", argN))
+		args = append(args, filter.TagsNone)
+		argN++
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argN))
+		args = append(args, *filter.CreatedAfter)
+		argN++
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argN))
+		args = append(args, *filter.CreatedBefore)
+		argN++
+	}
+	if filter.MinConfidence != nil {
+		conditions = append(conditions, fmt.Sprintf("confidence >= $%d", argN))
+		args = append(args, *filter.MinConfidence)
+		argN++
+	}
+	for _, p := range filter.Metadata {
+		var cond string
+		cond, args, argN = p.condition(args, argN)
+		conditions = append(conditions, cond)
+	}
 
 	// Access control: show public, own entries, or shared with agent
 	conditions = append(conditions, fmt.Sprintf(
@@ -224,62 +442,15 @@ func (s *KnowledgeStore) List(ctx context.Context, filter KnowledgeFilter) ([]Kn
 		argN, argN+1,
 	))
 	args = append(args, filter.AgentID, filter.AgentID)
+	argN += 2
 
-	limit := filter.Limit
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
-	offset := filter.Offset
-	if offset < 0 {
-		offset = 0
-	}
-
-	query := fmt.Sprintf(`
-		SELECT id, content, summary, source_agent, category, scope, shared_with, tags, metadata,
-		       source_event_id, confidence, relevance_decay, expires_at, superseded_by, created_at, updated_at
-		FROM vault_knowledge
-		WHERE %s
-		ORDER BY created_at DESC
-		LIMIT %d OFFSET %d`,
-		strings.Join(conditions, " AND "), limit, offset)
-
-	rows, err := s.db.Pool.Query(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("listing knowledge: %w", err)
-	}
-	defer rows.Close()
-
-	var entries []KnowledgeEntry
-	for rows.Next() {
-		var e KnowledgeEntry
-		if err := rows.Scan(
-			&e.ID, &e.Content, &e.Summary, &e.SourceAgent, &e.Category,
-			&e.Scope, &e.SharedWith, &e.Tags, &e.Metadata, &e.SourceEventID,
-			&e.Confidence, &e.RelevanceDecay, &e.ExpiresAt, &e.SupersededBy,
-			&e.CreatedAt, &e.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scanning knowledge entry: %w", err)
-		}
-		entries = append(entries, e)
-	}
-	return entries, rows.Err()
+	return conditions, args, argN
 }
 
 // Update modifies a knowledge entry. Only the owning agent or admin can update.
+// The previous state is recorded in vault_knowledge_versions in the same
+// transaction, and the entry's version is incremented.
 func (s *KnowledgeStore) Update(ctx context.Context, id, agentID string, input KnowledgeUpdateInput) (*KnowledgeEntry, error) {
-	// Verify ownership
-	var owner string
-	err := s.db.Pool.QueryRow(ctx, "SELECT source_agent FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL", id).Scan(&owner)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("checking ownership: %w", err)
-	}
-	if owner != agentID && agentID != "warren" {
-		return nil, fmt.Errorf("access denied: only owner or admin can update")
-	}
-
 	var setClauses []string
 	var args []any
 	argN := 1
@@ -318,6 +489,9 @@ func (s *KnowledgeStore) Update(ctx context.Context, id, agentID string, input K
 		setClauses = append(setClauses, fmt.Sprintf("embedding = $%d", argN))
 		args = append(args, *input.Embedding)
 		argN++
+		setClauses = append(setClauses, fmt.Sprintf("embedding_model = NULLIF($%d, '')", argN))
+		args = append(args, input.EmbeddingModel)
+		argN++
 	}
 	if input.Metadata != nil {
 		setClauses = append(setClauses, fmt.Sprintf("metadata = $%d", argN))
@@ -345,27 +519,43 @@ func (s *KnowledgeStore) Update(ctx context.Context, id, agentID string, input K
 		argN++
 	}
 
-	if len(setClauses) == 0 {
-		return s.GetByID(ctx, id, agentID)
-	}
+	var entry *KnowledgeEntry
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockKnowledgeForWrite(ctx, tx, id, agentID); err != nil {
+			return err
+		}
 
-	query := fmt.Sprintf(`
-		UPDATE vault_knowledge SET %s
-		WHERE id = $%d AND deleted_at IS NULL
-		RETURNING id, content, summary, source_agent, category, scope, shared_with, tags, metadata,
-		          source_event_id, confidence, relevance_decay, expires_at, superseded_by, created_at, updated_at`,
-		strings.Join(setClauses, ", "), argN)
-	args = append(args, id)
+		if len(setClauses) == 0 {
+			entry = &KnowledgeEntry{}
+			return tx.QueryRow(ctx, "SELECT "+knowledgeColumns+" FROM vault_knowledge WHERE id = $1", id).
+				Scan(knowledgeScanDest(entry)...)
+		}
 
-	entry := &KnowledgeEntry{}
-	err = s.db.Pool.QueryRow(ctx, query, args...).Scan(
-		&entry.ID, &entry.Content, &entry.Summary, &entry.SourceAgent, &entry.Category,
-		&entry.Scope, &entry.SharedWith, &entry.Tags, &entry.Metadata, &entry.SourceEventID,
-		&entry.Confidence, &entry.RelevanceDecay, &entry.ExpiresAt, &entry.SupersededBy,
-		&entry.CreatedAt, &entry.UpdatedAt,
-	)
+		if err := snapshotKnowledgeVersion(ctx, tx, id, agentID); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`
+			UPDATE vault_knowledge SET %s, version = version + 1
+			WHERE id = $%d AND deleted_at IS NULL
+			RETURNING %s`,
+			strings.Join(setClauses, ", "), argN, knowledgeColumns)
+		args = append(args, id)
+
+		entry = &KnowledgeEntry{}
+		if err := tx.QueryRow(ctx, query, args...).Scan(knowledgeScanDest(entry)...); err != nil {
+			return fmt.Errorf("updating knowledge entry: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("updating knowledge entry: %w", err)
+		if errors.Is(err, ErrKnowledgeNotFound) {
+			return nil, nil
+		}
+		if errors.Is(err, ErrAccessDenied) {
+			return nil, fmt.Errorf("%w: only owner or admin can update", ErrAccessDenied)
+		}
+		return nil, err
 	}
 	return entry, nil
 }
@@ -376,25 +566,125 @@ func (s *KnowledgeStore) Delete(ctx context.Context, id, agentID string) error {
 	err := s.db.Pool.QueryRow(ctx, "SELECT source_agent FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL", id).Scan(&owner)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("not found")
+			return fmt.Errorf("deleting knowledge entry %s: %w", id, ErrKnowledgeNotFound)
 		}
 		return fmt.Errorf("checking ownership: %w", err)
 	}
-	if owner != agentID && agentID != "warren" {
-		return fmt.Errorf("access denied")
+	if !canModify(owner, agentID) {
+		return fmt.Errorf("%w: only owner or admin can delete", ErrAccessDenied)
 	}
 
 	_, err = s.db.Pool.Exec(ctx, "UPDATE vault_knowledge SET deleted_at = NOW() WHERE id = $1", id)
 	return err
 }
 
-// Search performs semantic search using pgvector cosine similarity.
+// Search ranks knowledge entries according to input.Mode: pgvector cosine
+// similarity (the default), PostgreSQL full-text rank, or a reciprocal-rank
+// fusion of both. Each mode over-fetches candidates by their raw score, and
+// RankResults turns those into the final relevance, so heavily decayed
+// matches cannot crowd fresher ones out of the limit.
 func (s *KnowledgeStore) Search(ctx context.Context, input SearchInput) ([]SearchResult, error) {
+	limit := input.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	candidates := limit * rankCandidateFactor
+
+	var results []SearchResult
+	switch input.Mode {
+	case SearchModeLexical:
+		lexical, err := s.searchLexical(ctx, input, candidates)
+		if err != nil {
+			return nil, err
+		}
+		results = lexical
+
+	case SearchModeHybrid:
+		vector, err := s.searchVector(ctx, input, candidates)
+		if err != nil {
+			return nil, err
+		}
+		lexical, err := s.searchLexical(ctx, input, candidates)
+		if err != nil {
+			return nil, err
+		}
+		// Fused scores are rank-based, so min_relevance means a cosine
+		// similarity here and is applied to the vector side before fusion.
+		minSimilarity := input.MinRelevance
+		if minSimilarity <= 0 {
+			minSimilarity = defaultMinSimilarity
+		}
+		results = FuseRankings(FilterVectorMatches(vector, minSimilarity), lexical, rrfK)
+
+	default:
+		vector, err := s.searchVector(ctx, input, candidates)
+		if err != nil {
+			return nil, err
+		}
+		results = vector
+	}
+
+	minRelevance := input.MinRelevance
+	switch input.Mode {
+	case SearchModeHybrid:
+		minRelevance = 0 // already applied to the vector matches
+	case SearchModeLexical:
+	default:
+		if minRelevance <= 0 {
+			// Without a threshold, weak vector matches are not matches at all.
+			minRelevance = defaultMinSimilarity
+		}
+	}
+
+	profile := input.Profile
+	if profile == nil {
+		profile = &BuiltInProfiles(DefaultHalfLives())[0]
+	}
+	results = RankResults(results, profile, minRelevance, limit, time.Now(), input.Explain)
+
+	if input.Explain && input.Mode != SearchModeVector && input.QueryText != "" {
+		if err := s.highlight(ctx, results, input.QueryText); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// RankResults applies profile to candidates scored by raw similarity or
+// rank, drops those whose final relevance is under minRelevance, and
+// returns the best limit ordered by final relevance. Ties keep candidate
+// order. With explain set, each result gets an Explanation.
+func RankResults(candidates []SearchResult, profile *RankingProfile, minRelevance float64, limit int, now time.Time, explain bool) []SearchResult {
+	results := make([]SearchResult, 0, len(candidates))
+	for _, r := range candidates {
+		base := r.Similarity
+		factors := profile.Factors(&r.KnowledgeEntry, now)
+		r.Similarity = base * factors.Multiplier()
+		if minRelevance > 0 && r.Similarity < minRelevance {
+			continue
+		}
+		if explain {
+			r.Explanation = explainResult(r, base, profile.Name, factors)
+		}
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// searchFilters builds the WHERE conditions shared by every search mode.
+// Placeholders are numbered from $1; callers append their own arguments after.
+func searchFilters(input SearchInput) ([]string, []any) {
 	var conditions []string
 	var args []any
 	argN := 1
 
-	// Query embedding
 	conditions = append(conditions, "deleted_at IS NULL")
 	conditions = append(conditions, "(superseded_by IS NULL)")
 
@@ -414,40 +704,130 @@ func (s *KnowledgeStore) Search(ctx context.Context, input SearchInput) ([]Searc
 		argN++
 	}
 
+	if len(input.ExcludeIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("NOT (id = ANY($%d::uuid[]))", argN))
+		args = append(args, input.ExcludeIDs)
+		argN++
+	}
+
 	// Access control
 	conditions = append(conditions, fmt.Sprintf(
 		"(scope = 'public' OR source_agent = $%d OR (scope = 'shared' AND $%d = ANY(shared_with)))",
 		argN, argN+1,
 	))
 	args = append(args, input.AgentID, input.AgentID)
-	argN += 2
 
-	// Embedding parameter
-	embeddingArgN := argN
+	return conditions, args
+}
 
-	limit := input.Limit
-	if limit <= 0 || limit > 100 {
-		limit = 10
+// searchVector returns entries ordered by cosine similarity to the query
+// embedding, with the raw similarity in both Similarity and VectorScore. An
+// entry scores the better of its own embedding and its best chunk's, so long
+// entries match on text past the model's input window.
+func (s *KnowledgeStore) searchVector(ctx context.Context, input SearchInput, limit int) ([]SearchResult, error) {
+	conditions, args := searchFilters(input)
+	embeddingArgN := len(args) + 1
+	where := strings.Join(conditions, " AND ")
+
+	// Candidates come from the nearest parents and the nearest chunks, both
+	// served by their vector indexes; only those are scored. Chunks are
+	// filtered by their parent before the limit, so chunks of entries the
+	// agent cannot see (or that are deleted or expired) don't use it up.
+	query := fmt.Sprintf(`
+		WITH chunk_hits AS (
+			SELECT DISTINCT ON (knowledge_id)
+			       knowledge_id, chunk_index, chunk_content, chunk_start, chunk_end, chunk_sim
+			FROM (
+				SELECT knowledge_id, chunk_index, content AS chunk_content,
+				       start_offset AS chunk_start, end_offset AS chunk_end,
+				       (1 - (embedding <=> $%[1]d))::FLOAT AS chunk_sim
+				FROM vault_knowledge_chunks
+				WHERE knowledge_id IN (SELECT id FROM vault_knowledge WHERE %[3]s)
+				ORDER BY embedding <=> $%[1]d
+				LIMIT %[2]d
+			) nearest
+			ORDER BY knowledge_id, chunk_sim DESC
+		),
+		parent_hits AS (
+			SELECT id FROM vault_knowledge
+			WHERE %[3]s AND embedding IS NOT NULL
+			ORDER BY embedding <=> $%[1]d
+			LIMIT %[4]d
+		),
+		scored AS (
+			SELECT %[5]s,
+			       (1 - (embedding <=> $%[1]d))::FLOAT AS parent_sim,
+			       chunk_index, chunk_content, chunk_start, chunk_end, chunk_sim
+			FROM vault_knowledge
+			LEFT JOIN chunk_hits ON chunk_hits.knowledge_id = vault_knowledge.id
+			WHERE %[3]s
+			  AND (id IN (SELECT id FROM parent_hits) OR chunk_hits.knowledge_id IS NOT NULL)
+		)
+		SELECT *, GREATEST(COALESCE(parent_sim, 0), COALESCE(chunk_sim, 0)) AS similarity
+		FROM scored
+		ORDER BY similarity DESC
+		LIMIT %[4]d`,
+		embeddingArgN, limit*chunkCandidateFactor, where, limit, knowledgeColumns)
+
+	args = append(args, input.QueryEmbedding)
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching knowledge: %w", err)
 	}
+	defer rows.Close()
 
-	minSim := input.MinRelevance
-	if minSim <= 0 {
-		minSim = 0.5
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		var parentSim, chunkSim *float64
+		var chunkIndex, chunkStart, chunkEnd *int
+		var chunkContent *string
+		dest := append(knowledgeScanDest(&r.KnowledgeEntry),
+			&parentSim, &chunkIndex, &chunkContent, &chunkStart, &chunkEnd, &chunkSim, &r.Similarity)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scanning search result: %w", err)
+		}
+		if chunkSim != nil {
+			r.Chunk = &ChunkMatch{Index: *chunkIndex, Content: *chunkContent, Start: *chunkStart, End: *chunkEnd, Score: *chunkSim}
+		}
+		score := r.Similarity
+		r.VectorScore = &score
+		results = append(results, r)
 	}
+	return results, rows.Err()
+}
+
+// searchLexical returns entries matching the query text via full-text search,
+// ordered by ts_rank_cd. The rank is normalised into [0, 1) so it can be
+// compared against min_relevance.
+func (s *KnowledgeStore) searchLexical(ctx context.Context, input SearchInput, limit int) ([]SearchResult, error) {
+	conditions, args := searchFilters(input)
+	queryArgN := len(args) + 1
 
 	query := fmt.Sprintf(`
-		SELECT id, content, summary, source_agent, category, scope, shared_with, tags, metadata,
-		       source_event_id, confidence, relevance_decay, expires_at, superseded_by, created_at, updated_at,
-		       (1 - (embedding <=> $%d))::FLOAT AS similarity
+		SELECT %s,
+		       ts_rank_cd(search_tsv, websearch_to_tsquery('simple', $%d), 32)::FLOAT AS rank
 		FROM vault_knowledge
 		WHERE %s
-		  AND embedding IS NOT NULL
-		  AND (1 - (embedding <=> $%d)) >= %f
-		ORDER BY embedding <=> $%d
+		  AND search_tsv @@ websearch_to_tsquery('simple', $%d)
+		ORDER BY rank DESC, created_at DESC
 		LIMIT %d`,
-		embeddingArgN, strings.Join(conditions, " AND "), embeddingArgN, minSim, embeddingArgN, limit)
+		knowledgeColumns, queryArgN, strings.Join(conditions, " AND "), queryArgN, limit)
 
-	args = append(args, input.QueryEmbedding)
+	args = append(args, input.QueryText)
+	results, err := s.querySearchResults(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		score := results[i].Similarity
+		results[i].LexicalScore = &score
+	}
+	return results, nil
+}
+
+// querySearchResults runs a search query whose final column is a score.
+func (s *KnowledgeStore) querySearchResults(ctx context.Context, query string, args []any) ([]SearchResult, error) {
 	rows, err := s.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("searching knowledge: %w", err)
@@ -457,21 +837,70 @@ func (s *KnowledgeStore) Search(ctx context.Context, input SearchInput) ([]Searc
 	var results []SearchResult
 	for rows.Next() {
 		var r SearchResult
-		if err := rows.Scan(
-			&r.ID, &r.Content, &r.Summary, &r.SourceAgent, &r.Category,
-			&r.Scope, &r.SharedWith, &r.Tags, &r.Metadata, &r.SourceEventID,
-			&r.Confidence, &r.RelevanceDecay, &r.ExpiresAt, &r.SupersededBy,
-			&r.CreatedAt, &r.UpdatedAt, &r.Similarity,
-		); err != nil {
+		if err := rows.Scan(append(knowledgeScanDest(&r.KnowledgeEntry), &r.Similarity)...); err != nil {
 			return nil, fmt.Errorf("scanning search result: %w", err)
 		}
-		// Apply relevance decay
-		r.Similarity = applyDecay(r.Similarity, r.RelevanceDecay, r.CreatedAt)
 		results = append(results, r)
 	}
 	return results, rows.Err()
 }
 
+// FilterVectorMatches drops vector results whose raw similarity is under
+// minSimilarity, keeping the order of the rest.
+func FilterVectorMatches(results []SearchResult, minSimilarity float64) []SearchResult {
+	kept := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if r.VectorScore != nil && *r.VectorScore >= minSimilarity {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// FuseRankings merges vector and lexical result lists with reciprocal-rank
+// fusion. Each entry scores 1/(k+rank) per list it appears in; the sum is
+// normalised by the best possible score (rank 1 in both lists) so relevance
+// stays in [0, 1]. Component scores from either list are carried over.
+func FuseRankings(vector, lexical []SearchResult, k int) []SearchResult {
+	fused := make(map[string]*SearchResult)
+	scores := make(map[string]float64)
+	var order []string
+
+	add := func(list []SearchResult) {
+		for rank, r := range list {
+			existing, ok := fused[r.ID]
+			if !ok {
+				copied := r
+				copied.VectorScore, copied.LexicalScore = nil, nil
+				existing = &copied
+				fused[r.ID] = existing
+				order = append(order, r.ID)
+			}
+			if r.VectorScore != nil {
+				existing.VectorScore = r.VectorScore
+			}
+			if r.LexicalScore != nil {
+				existing.LexicalScore = r.LexicalScore
+			}
+			scores[r.ID] += 1.0 / float64(k+rank+1)
+		}
+	}
+	add(vector)
+	add(lexical)
+
+	maxScore := 2.0 / float64(k+1)
+	results := make([]SearchResult, 0, len(order))
+	for _, id := range order {
+		r := *fused[id]
+		r.Similarity = scores[id] / maxScore
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	return results
+}
+
 // Count returns the total number of non-deleted knowledge entries.
 func (s *KnowledgeStore) Count(ctx context.Context) (int64, error) {
 	var count int64
@@ -500,23 +929,27 @@ func canAccess(entry *KnowledgeEntry, agentID string) bool {
 	return false
 }
 
-// applyDecay applies relevance decay based on entry age.
-func applyDecay(similarity float64, decay RelevanceDecay, createdAt time.Time) float64 {
-	var halfLifeDays float64
-	switch decay {
-	case DecayNone:
-		return similarity
-	case DecaySlow:
-		halfLifeDays = 30
-	case DecayFast:
-		halfLifeDays = 7
-	case DecayEphemeral:
-		halfLifeDays = 1
-	default:
-		return similarity
-	}
+// canModify reports whether agentID may modify an entry owned by owner.
+// Only the owning agent or the warren admin can write.
+func canModify(owner, agentID string) bool {
+	return owner == agentID || agentID == "warren"
+}
 
-	ageDays := time.Since(createdAt).Hours() / 24
-	multiplier := math.Pow(0.5, ageDays/halfLifeDays)
-	return similarity * multiplier
+// lockKnowledgeForWrite row-locks a live knowledge entry inside tx and checks
+// that agentID may modify it.
+func lockKnowledgeForWrite(ctx context.Context, tx pgx.Tx, id, agentID string) error {
+	var owner string
+	err := tx.QueryRow(ctx,
+		"SELECT source_agent FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+	).Scan(&owner)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrKnowledgeNotFound
+		}
+		return fmt.Errorf("checking ownership: %w", err)
+	}
+	if !canModify(owner, agentID) {
+		return ErrAccessDenied
+	}
+	return nil
 }
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+)
+
+// chunkCandidateFactor controls how many nearest chunks vector search pulls
+// relative to the requested limit; a long entry can own several of them.
+const chunkCandidateFactor = 8
+
+// KnowledgeChunk is a slice of a long entry's content with its own embedding.
+// Start and End are byte offsets into the parent's content.
+type KnowledgeChunk struct {
+	Index     int
+	Content   string
+	Start     int
+	End       int
+	Embedding pgvector.Vector
+}
+
+// ChunkMatch is the chunk that best matched a search query.
+type ChunkMatch struct {
+	Index   int     `json:"index"`
+	Content string  `json:"content"`
+	Start   int     `json:"start"`
+	End     int     `json:"end"`
+	Score   float64 `json:"score"`
+}
+
+// ChunkCandidate is an entry whose chunks are missing or stale.
+type ChunkCandidate struct {
+	ID          string
+	Content     string
+	ContentHash string
+}
+
+// ListChunkCandidates returns live entries longer than minBytes without
+// chunks for their current content, and entries at or under minBytes that
+// still have chunks, least recently updated first.
+func (s *KnowledgeStore) ListChunkCandidates(ctx context.Context, minBytes, limit int) ([]ChunkCandidate, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, content, content_hash
+		FROM vault_knowledge k
+		WHERE deleted_at IS NULL
+		AND (
+			(octet_length(content) > $1 AND NOT EXISTS (
+				SELECT 1 FROM vault_knowledge_chunks c
+				WHERE c.knowledge_id = k.id AND c.source_hash = k.content_hash))
+			OR (octet_length(content) <= $1 AND EXISTS (
+				SELECT 1 FROM vault_knowledge_chunks c WHERE c.knowledge_id = k.id))
+		)
+		ORDER BY updated_at
+		LIMIT $2`,
+		minBytes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing chunk candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []ChunkCandidate
+	for rows.Next() {
+		var c ChunkCandidate
+		if err := rows.Scan(&c.ID, &c.Content, &c.ContentHash); err != nil {
+			return nil, fmt.Errorf("scanning chunk candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// GetChunkCandidate returns the content and hash of a live entry, or nil.
+func (s *KnowledgeStore) GetChunkCandidate(ctx context.Context, id string) (*ChunkCandidate, error) {
+	c := &ChunkCandidate{ID: id}
+	err := s.db.Pool.QueryRow(ctx,
+		"SELECT content, content_hash FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL", id,
+	).Scan(&c.Content, &c.ContentHash)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting chunk candidate: %w", err)
+	}
+	return c, nil
+}
+
+// ReplaceChunks swaps an entry's chunks for chunks made from the content
+// with contentHash. It does nothing and returns false if the content has
+// changed since, so a slow chunker never overwrites newer chunks.
+func (s *KnowledgeStore) ReplaceChunks(ctx context.Context, id, contentHash string, chunks []KnowledgeChunk) (bool, error) {
+	replaced := false
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var current string
+		err := tx.QueryRow(ctx,
+			"SELECT content_hash FROM vault_knowledge WHERE id = $1 FOR UPDATE", id,
+		).Scan(&current)
+		if err == pgx.ErrNoRows || (err == nil && current != contentHash) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("locking chunked knowledge: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM vault_knowledge_chunks WHERE knowledge_id = $1", id); err != nil {
+			return fmt.Errorf("deleting chunks: %w", err)
+		}
+		batch := &pgx.Batch{}
+		for _, c := range chunks {
+			batch.Queue(`
+				INSERT INTO vault_knowledge_chunks (knowledge_id, chunk_index, content, start_offset, end_offset, embedding, source_hash)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				id, c.Index, c.Content, c.Start, c.End, c.Embedding, contentHash)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("inserting chunks: %w", err)
+		}
+		replaced = true
+		return nil
+	})
+	return replaced, err
+}
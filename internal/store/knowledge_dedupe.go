@@ -0,0 +1,385 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DedupePolicy controls what Create does when the new content duplicates an
+// existing entry.
+type DedupePolicy string
+
+const (
+	DedupeNone           DedupePolicy = "none"            // always insert
+	DedupeReject         DedupePolicy = "reject"          // fail with a DuplicateError
+	DedupeMergeTags      DedupePolicy = "merge_tags"      // add new tags to the existing entry
+	DedupeBumpConfidence DedupePolicy = "bump_confidence" // treat the report as corroboration
+)
+
+// Valid reports whether p is a known policy.
+func (p DedupePolicy) Valid() bool {
+	switch p {
+	case DedupeNone, DedupeReject, DedupeMergeTags, DedupeBumpConfidence:
+		return true
+	}
+	return false
+}
+
+// DedupeConfig is the store-wide deduplication behaviour.
+type DedupeConfig struct {
+	Policy DedupePolicy
+	// NearThreshold is the cosine similarity at or above which an entry with
+	// an embedding counts as a near-duplicate. Zero disables near matching.
+	NearThreshold float64
+}
+
+// CreateOutcome describes what Create did with an input.
+type CreateOutcome string
+
+const (
+	OutcomeCreated  CreateOutcome = "created"  // a new row was inserted
+	OutcomeMerged   CreateOutcome = "merged"   // content matched an existing entry exactly
+	OutcomeLinked   CreateOutcome = "linked"   // content was a near-duplicate of an existing entry
+	OutcomeReplayed CreateOutcome = "replayed" // source event was already recorded; the original is returned
+)
+
+// KnowledgeCreateResult is the entry Create stored or matched.
+type KnowledgeCreateResult struct {
+	Entry      *KnowledgeEntry `json:"entry"`
+	Outcome    CreateOutcome   `json:"outcome"`
+	Similarity float64         `json:"similarity,omitempty"` // set for linked near-duplicates and DuplicateOf
+	// DuplicateOf is the entry a created entry duplicates when that entry
+	// belongs to another agent and so was not merged into.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+}
+
+// ErrDuplicateKnowledge matches any DuplicateError.
+var ErrDuplicateKnowledge = errors.New("duplicate knowledge")
+
+// DuplicateError is returned under DedupeReject and carries the existing entry.
+type DuplicateError struct {
+	Existing   *KnowledgeEntry
+	Similarity float64 // 1 for exact content matches
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate of knowledge entry %s", e.Existing.ID)
+}
+
+// Is lets errors.Is(err, ErrDuplicateKnowledge) match.
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicateKnowledge
+}
+
+// SetDedupe configures the default deduplication behaviour for Create.
+func (s *KnowledgeStore) SetDedupe(cfg DedupeConfig) {
+	s.dedupe = cfg
+}
+
+// MergeTags returns existing followed by any tags in incoming it lacks.
+func MergeTags(existing, incoming []string) []string {
+	seen := make(map[string]bool, len(existing))
+	merged := append([]string{}, existing...)
+	for _, t := range existing {
+		seen[t] = true
+	}
+	for _, t := range incoming {
+		if !seen[t] {
+			seen[t] = true
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// BumpConfidence combines two independent reports of the same fact: the
+// result is the probability that at least one of them is right.
+func BumpConfidence(existing, incoming float64) float64 {
+	c := 1 - (1-existing)*(1-incoming)
+	if c > 1 {
+		return 1
+	}
+	return c
+}
+
+// CreateWithOutcome inserts a knowledge entry unless it duplicates a live,
+// unexpired entry the creator can see (public or their own), in which case
+// the dedupe policy decides what happens. Only entries the creator may edit
+// are merged into; a duplicate of another agent's entry is still inserted,
+// with metadata.duplicate_of pointing at that entry. input.Dedupe overrides
+// the store default.
+func (s *KnowledgeStore) CreateWithOutcome(ctx context.Context, input KnowledgeCreateInput) (*KnowledgeCreateResult, error) {
+	var result *KnowledgeCreateResult
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		result, err = s.createTx(ctx, tx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BatchItemError reports which input of an atomic batch failed.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch entry %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error { return e.Err }
+
+// CreateBatch creates every input in a single transaction, applying the same
+// replay and dedupe rules as CreateWithOutcome. If any input fails nothing is
+// written and the error is a *BatchItemError.
+func (s *KnowledgeStore) CreateBatch(ctx context.Context, inputs []KnowledgeCreateInput) ([]KnowledgeCreateResult, error) {
+	results := make([]KnowledgeCreateResult, len(inputs))
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		for i, input := range inputs {
+			result, err := s.createTx(ctx, tx, input)
+			if err != nil {
+				return &BatchItemError{Index: i, Err: err}
+			}
+			results[i] = *result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// createTx is the body of CreateWithOutcome and CreateBatch.
+func (s *KnowledgeStore) createTx(ctx context.Context, tx pgx.Tx, input KnowledgeCreateInput) (*KnowledgeCreateResult, error) {
+	policy := input.Dedupe
+	if policy == "" {
+		policy = s.dedupe.Policy
+	}
+	if policy != "" && !policy.Valid() {
+		return nil, fmt.Errorf("unknown dedupe policy %q", policy)
+	}
+
+	quota, err := s.lockQuota(ctx, tx, input.SourceAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	// A redelivered event or retried request returns the original entry.
+	if input.SourceEventID != nil {
+		original, err := findBySourceEvent(ctx, tx, input.SourceAgent, *input.SourceEventID)
+		if err != nil {
+			return nil, err
+		}
+		if original != nil {
+			return &KnowledgeCreateResult{Entry: original, Outcome: OutcomeReplayed}, nil
+		}
+	}
+
+	if policy == "" || policy == DedupeNone {
+		if err := checkQuota(ctx, tx, input, quota); err != nil {
+			return nil, err
+		}
+		return s.insertOrReplay(ctx, tx, input)
+	}
+
+	// Serialise concurrent creates of the same content.
+	if _, err := tx.Exec(ctx,
+		"SELECT pg_advisory_xact_lock(hashtextextended(vault_knowledge_content_hash($1), 0))", input.Content,
+	); err != nil {
+		return nil, fmt.Errorf("locking content hash: %w", err)
+	}
+
+	existing, similarity, err := findDuplicate(ctx, tx, input, s.dedupe.NearThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		if err := checkQuota(ctx, tx, input, quota); err != nil {
+			return nil, err
+		}
+		return s.insertOrReplay(ctx, tx, input)
+	}
+
+	if policy == DedupeReject {
+		return nil, &DuplicateError{Existing: existing, Similarity: similarity}
+	}
+
+	if !canModify(existing.SourceAgent, input.SourceAgent) {
+		if err := checkQuota(ctx, tx, input, quota); err != nil {
+			return nil, err
+		}
+		input.Metadata = withDuplicateOf(input.Metadata, existing.ID, similarity)
+		result, err := s.insertOrReplay(ctx, tx, input)
+		if err != nil {
+			return nil, err
+		}
+		if result.Outcome == OutcomeCreated {
+			result.DuplicateOf = existing.ID
+			result.Similarity = similarity
+		}
+		return result, nil
+	}
+
+	outcome := OutcomeMerged
+	if similarity < 1 {
+		outcome = OutcomeLinked
+	}
+	entry, err := applyDedupe(ctx, tx, existing, input, policy, outcome, similarity)
+	if err != nil {
+		return nil, err
+	}
+	result := &KnowledgeCreateResult{Entry: entry, Outcome: outcome}
+	if outcome == OutcomeLinked {
+		result.Similarity = similarity
+	}
+	return result, nil
+}
+
+// insertOrReplay inserts input, falling back to the original entry when a
+// concurrent create already recorded the same source event.
+func (s *KnowledgeStore) insertOrReplay(ctx context.Context, db DBTX, input KnowledgeCreateInput) (*KnowledgeCreateResult, error) {
+	entry, err := insertKnowledge(ctx, db, input)
+	if errors.Is(err, errSourceEventExists) {
+		original, err := findBySourceEvent(ctx, db, input.SourceAgent, *input.SourceEventID)
+		if err != nil {
+			return nil, err
+		}
+		if original == nil {
+			return nil, fmt.Errorf("creating knowledge entry: %w", errSourceEventExists)
+		}
+		return &KnowledgeCreateResult{Entry: original, Outcome: OutcomeReplayed}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &KnowledgeCreateResult{Entry: entry, Outcome: OutcomeCreated}, nil
+}
+
+// withDuplicateOf returns a copy of metadata recording that the entry
+// duplicates id.
+func withDuplicateOf(metadata map[string]any, id string, similarity float64) map[string]any {
+	out := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out["duplicate_of"] = map[string]any{"id": id, "similarity": similarity}
+	return out
+}
+
+// findDuplicate looks for an exact content-hash match, then for the nearest
+// embedding in the same category above threshold, among live, unexpired
+// entries. Exact matches the creator may edit are preferred. The match is
+// row-locked. similarity is 1 for exact matches.
+func findDuplicate(ctx context.Context, tx pgx.Tx, input KnowledgeCreateInput, threshold float64) (*KnowledgeEntry, float64, error) {
+	entry := &KnowledgeEntry{}
+	err := tx.QueryRow(ctx, `
+		SELECT `+knowledgeColumns+`
+		FROM vault_knowledge
+		WHERE content_hash = vault_knowledge_content_hash($1)
+		AND deleted_at IS NULL AND superseded_by IS NULL
+		AND (expires_at IS NULL OR expires_at > NOW())
+		AND (scope = 'public' OR source_agent = $2)
+		ORDER BY (source_agent = $2 OR $2 = 'warren') DESC, created_at
+		LIMIT 1
+		FOR UPDATE`,
+		input.Content, input.SourceAgent,
+	).Scan(knowledgeScanDest(entry)...)
+	if err == nil {
+		return entry, 1, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, 0, fmt.Errorf("finding duplicate: %w", err)
+	}
+
+	if threshold <= 0 || len(input.Embedding.Slice()) == 0 {
+		return nil, 0, nil
+	}
+
+	var similarity float64
+	err = tx.QueryRow(ctx, `
+		SELECT `+knowledgeColumns+`, 1 - (embedding <=> $1) AS similarity
+		FROM vault_knowledge
+		WHERE embedding IS NOT NULL
+		AND deleted_at IS NULL AND superseded_by IS NULL
+		AND (expires_at IS NULL OR expires_at > NOW())
+		AND category = $2
+		AND (scope = 'public' OR source_agent = $3)
+		ORDER BY embedding <=> $1
+		LIMIT 1
+		FOR UPDATE`,
+		input.Embedding, input.Category, input.SourceAgent,
+	).Scan(append(knowledgeScanDest(entry), &similarity)...)
+	if err == pgx.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding near duplicate: %w", err)
+	}
+	if similarity < threshold {
+		return nil, 0, nil
+	}
+	return entry, similarity, nil
+}
+
+// applyDedupe folds input into existing, which the creator may edit,
+// according to policy. Near-duplicates are also recorded under
+// metadata.linked_reports. The existing entry is versioned like any other
+// edit; nothing is written if nothing changed.
+func applyDedupe(ctx context.Context, tx pgx.Tx, existing *KnowledgeEntry, input KnowledgeCreateInput, policy DedupePolicy, outcome CreateOutcome, similarity float64) (*KnowledgeEntry, error) {
+
+	tags := existing.Tags
+	confidence := existing.Confidence
+	switch policy {
+	case DedupeMergeTags:
+		tags = MergeTags(existing.Tags, input.Tags)
+	case DedupeBumpConfidence:
+		confidence = BumpConfidence(existing.Confidence, input.Confidence)
+	}
+
+	var link map[string]any
+	if outcome == OutcomeLinked {
+		link = map[string]any{
+			"source_agent": input.SourceAgent,
+			"similarity":   similarity,
+			"linked_at":    time.Now().UTC(),
+		}
+		if input.SourceEventID != nil {
+			link["source_event_id"] = *input.SourceEventID
+		}
+	}
+
+	if link == nil && len(tags) == len(existing.Tags) && confidence == existing.Confidence {
+		return existing, nil
+	}
+
+	if err := snapshotKnowledgeVersion(ctx, tx, existing.ID, input.SourceAgent); err != nil {
+		return nil, err
+	}
+
+	entry := &KnowledgeEntry{}
+	err := tx.QueryRow(ctx, `
+		UPDATE vault_knowledge
+		SET tags = $2, confidence = $3,
+			metadata = CASE WHEN $4::jsonb IS NULL THEN metadata
+				ELSE jsonb_set(COALESCE(metadata, '{}'::jsonb), '{linked_reports}',
+					COALESCE(metadata->'linked_reports', '[]'::jsonb) || jsonb_build_array($4::jsonb))
+				END,
+			version = version + 1
+		WHERE id = $1
+		RETURNING `+knowledgeColumns,
+		existing.ID, tags, confidence, link,
+	).Scan(knowledgeScanDest(entry)...)
+	if err != nil {
+		return nil, fmt.Errorf("merging duplicate knowledge: %w", err)
+	}
+	return entry, nil
+}
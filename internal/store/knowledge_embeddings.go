@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+// EmbeddingCandidate is a live entry whose embedding needs (re)computing.
+type EmbeddingCandidate struct {
+	ID          string
+	Content     string
+	ContentHash string
+}
+
+// staleEmbeddingCondition matches rows with no vector or a vector computed
+// from content that has since changed.
+const staleEmbeddingCondition = `(embedding IS NULL OR embedded_hash IS DISTINCT FROM content_hash)`
+
+// ListStaleEmbeddings returns live entries with a missing or stale
+// embedding, least recently updated first.
+func (s *KnowledgeStore) ListStaleEmbeddings(ctx context.Context, limit int) ([]EmbeddingCandidate, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, content, content_hash
+		FROM vault_knowledge
+		WHERE deleted_at IS NULL AND `+staleEmbeddingCondition+`
+		ORDER BY updated_at
+		LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing stale embeddings: %w", err)
+	}
+	return scanEmbeddingCandidates(rows)
+}
+
+// CountReembed counts the live entries ListReembed would visit: all of them
+// when all is set, otherwise those that are stale or not embedded by model.
+func (s *KnowledgeStore) CountReembed(ctx context.Context, model string, all bool) (int64, error) {
+	var n int64
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM vault_knowledge
+		WHERE deleted_at IS NULL
+		AND ($2 OR embedding_model IS DISTINCT FROM $1 OR `+staleEmbeddingCondition+`)`,
+		model, all,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("counting entries to re-embed: %w", err)
+	}
+	return n, nil
+}
+
+// ListReembed pages through the entries counted by CountReembed in ID
+// order, starting after afterID (empty for the first page). Paging by ID
+// means an entry that keeps failing is visited once per run.
+func (s *KnowledgeStore) ListReembed(ctx context.Context, model string, all bool, afterID string, limit int) ([]EmbeddingCandidate, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var after any
+	if afterID != "" {
+		after = afterID
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, content, content_hash
+		FROM vault_knowledge
+		WHERE deleted_at IS NULL
+		AND ($2 OR embedding_model IS DISTINCT FROM $1 OR `+staleEmbeddingCondition+`)
+		AND ($3::uuid IS NULL OR id > $3::uuid)
+		ORDER BY id
+		LIMIT $4`,
+		model, all, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing entries to re-embed: %w", err)
+	}
+	return scanEmbeddingCandidates(rows)
+}
+
+func scanEmbeddingCandidates(rows pgx.Rows) ([]EmbeddingCandidate, error) {
+	defer rows.Close()
+	var candidates []EmbeddingCandidate
+	for rows.Next() {
+		var c EmbeddingCandidate
+		if err := rows.Scan(&c.ID, &c.Content, &c.ContentHash); err != nil {
+			return nil, fmt.Errorf("scanning embedding candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// SetEmbedding stores a vector computed by model from the content with
+// contentHash. It does nothing and returns false if the content has changed
+// since. Chunks embedded by a different model are dropped so the chunker
+// rebuilds them with the new one. The entry's version and updated_at are
+// left alone.
+func (s *KnowledgeStore) SetEmbedding(ctx context.Context, id, contentHash string, embedding pgvector.Vector, model string) (bool, error) {
+	stored := false
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var current string
+		var previous *string
+		err := tx.QueryRow(ctx,
+			"SELECT content_hash, embedding_model FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id,
+		).Scan(&current, &previous)
+		if err == pgx.ErrNoRows || (err == nil && current != contentHash) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("locking knowledge for embedding: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			"UPDATE vault_knowledge SET embedding = $2, embedding_model = $3, embedded_hash = content_hash WHERE id = $1",
+			id, embedding, model,
+		); err != nil {
+			return fmt.Errorf("storing knowledge embedding: %w", err)
+		}
+		if previous == nil || *previous != model {
+			if _, err := tx.Exec(ctx, "DELETE FROM vault_knowledge_chunks WHERE knowledge_id = $1", id); err != nil {
+				return fmt.Errorf("dropping stale chunks: %w", err)
+			}
+		}
+		stored = true
+		return nil
+	})
+	return stored, err
+}
+
+// ExampleEmbeddings returns the stored embeddings of the live entries among
+// ids that agentID may find through search, so they can serve as query
+// examples without being re-embedded. Visible entries without an embedding
+// map to nil; entries that are missing or not visible are absent.
+func (s *KnowledgeStore) ExampleEmbeddings(ctx context.Context, ids []string, agentID string) (map[string]*pgvector.Vector, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, embedding
+		FROM vault_knowledge
+		WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+		AND (scope = 'public' OR source_agent = $2 OR (scope = 'shared' AND $2 = ANY(shared_with)))`,
+		ids, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("loading example embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]*pgvector.Vector, len(ids))
+	for rows.Next() {
+		var id string
+		var embedding *pgvector.Vector
+		if err := rows.Scan(&id, &embedding); err != nil {
+			return nil, fmt.Errorf("scanning example embedding: %w", err)
+		}
+		found[id] = embedding
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Highlight markers wrapped around matched terms in explanation snippets.
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	// highlightDelimiter separates the fragments ts_headline returns.
+	highlightDelimiter = " … "
+)
+
+// highlightOptions configures ts_headline: up to three short fragments
+// around the matched terms.
+var highlightOptions = fmt.Sprintf(`StartSel=%s, StopSel=%s, MaxFragments=3, MaxWords=24, MinWords=8, FragmentDelimiter="%s"`,
+	highlightStart, highlightStop, highlightDelimiter)
+
+// SearchExplanation shows how a result's relevance was computed:
+// relevance = score × decay_multiplier × confidence_factor × category_weight
+// × agent_trust, with the factors taken from the ranking profile.
+type SearchExplanation struct {
+	// Score is the relevance before ranking: the cosine similarity in vector
+	// mode, the normalised text rank in lexical mode, or the fused rank in
+	// hybrid mode.
+	Score float64 `json:"score"`
+	// Cosine is the raw cosine similarity to the query, the better of the
+	// entry's own embedding and its best chunk.
+	Cosine *float64 `json:"cosine,omitempty"`
+	// Lexical is the normalised full-text rank.
+	Lexical          *float64       `json:"lexical,omitempty"`
+	Profile          string         `json:"profile"`
+	RelevanceDecay   RelevanceDecay `json:"relevance_decay"`
+	HalfLifeDays     float64        `json:"half_life_days,omitempty"`
+	AgeDays          float64        `json:"age_days"`
+	DecayMultiplier  float64        `json:"decay_multiplier"`
+	Confidence       float64        `json:"confidence"`
+	ConfidenceFactor float64        `json:"confidence_factor"`
+	CategoryWeight   float64        `json:"category_weight"`
+	AgentTrust       float64        `json:"agent_trust"`
+	// Highlights are snippets with matched query terms wrapped in <mark>.
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+// explainResult builds the explanation for r, whose pre-ranking score was
+// base and which profile scaled by factors.
+func explainResult(r SearchResult, base float64, profile string, factors RankingFactors) *SearchExplanation {
+	return &SearchExplanation{
+		Score:            base,
+		Cosine:           r.VectorScore,
+		Lexical:          r.LexicalScore,
+		Profile:          profile,
+		RelevanceDecay:   r.RelevanceDecay,
+		HalfLifeDays:     factors.HalfLifeDays,
+		AgeDays:          factors.AgeDays,
+		DecayMultiplier:  factors.Decay,
+		Confidence:       r.Confidence,
+		ConfidenceFactor: factors.ConfidenceFactor,
+		CategoryWeight:   factors.CategoryWeight,
+		AgentTrust:       factors.AgentTrust,
+	}
+}
+
+// highlight fills in snippets around the terms of query matched by each
+// result's content or summary. Results without a text match get none.
+func (s *KnowledgeStore) highlight(ctx context.Context, results []SearchResult, query string) error {
+	if len(results) == 0 {
+		return nil
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id,
+		       ts_headline('simple', content, q, $3),
+		       ts_headline('simple', COALESCE(summary, ''), q, $3)
+		FROM vault_knowledge, websearch_to_tsquery('simple', $2) AS q
+		WHERE id = ANY($1::uuid[]) AND search_tsv @@ q`,
+		ids, query, highlightOptions)
+	if err != nil {
+		return fmt.Errorf("highlighting search results: %w", err)
+	}
+	defer rows.Close()
+
+	snippets := make(map[string][]string)
+	for rows.Next() {
+		var id, content, summary string
+		if err := rows.Scan(&id, &content, &summary); err != nil {
+			return fmt.Errorf("scanning highlight: %w", err)
+		}
+		snippets[id] = append(highlightFragments(content), highlightFragments(summary)...)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range results {
+		if results[i].Explanation != nil {
+			results[i].Explanation.Highlights = snippets[results[i].ID]
+		}
+	}
+	return nil
+}
+
+// highlightFragments splits a ts_headline result into fragments, keeping
+// only those that contain a match. A document whose match was in another
+// field comes back as its opening words, which are dropped.
+func highlightFragments(headline string) []string {
+	var fragments []string
+	for _, f := range strings.Split(headline, highlightDelimiter) {
+		if f = strings.TrimSpace(f); strings.Contains(f, highlightStart) {
+			fragments = append(fragments, f)
+		}
+	}
+	return fragments
+}
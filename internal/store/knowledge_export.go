@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+)
+
+// KnowledgeExportRecord is one line of a knowledge NDJSON export or import.
+type KnowledgeExportRecord struct {
+	KnowledgeEntry
+	Vector         []float32 `json:"embedding,omitempty"`
+	EmbeddingModel string    `json:"embedding_model,omitempty"`
+}
+
+// ExportFilter selects the entries written by ExportKnowledge.
+type ExportFilter struct {
+	AgentID        string // requesting agent; warren exports everything
+	SourceAgent    *string
+	Category       *KnowledgeCategory
+	Tags           []string // entries with any of these tags
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	WithEmbeddings bool
+}
+
+// ImportOutcome describes what ImportKnowledge did with a record.
+type ImportOutcome string
+
+const (
+	ImportInserted  ImportOutcome = "inserted"
+	ImportUpdated   ImportOutcome = "updated"
+	ImportUnchanged ImportOutcome = "unchanged"
+)
+
+// ExportKnowledge calls fn for every live entry matching filter, oldest
+// first, without buffering the result set. Superseded entries are included
+// so lineage survives a round trip.
+func (s *KnowledgeStore) ExportKnowledge(ctx context.Context, filter ExportFilter, fn func(*KnowledgeExportRecord) error) error {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+	argN := 1
+
+	if filter.SourceAgent != nil {
+		conditions = append(conditions, fmt.Sprintf("source_agent = $%d", argN))
+		args = append(args, *filter.SourceAgent)
+		argN++
+	}
+	if filter.Category != nil {
+		conditions = append(conditions, fmt.Sprintf("category = $%d", argN))
+		args = append(args, *filter.Category)
+		argN++
+	}
+	if len(filter.Tags) > 0 {
+		conditions = append(conditions, fmt.Sprintf("tags && $%d", argN))
+		args = append(args, filter.Tags)
+		argN++
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argN))
+		args = append(args, *filter.CreatedAfter)
+		argN++
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argN))
+		args = append(args, *filter.CreatedBefore)
+		argN++
+	}
+	if filter.AgentID != "warren" {
+		conditions = append(conditions, fmt.Sprintf(
+			"(scope = 'public' OR source_agent = $%d OR (scope = 'shared' AND ($%d = ANY(shared_with) OR '*' = ANY(shared_with))))",
+			argN, argN))
+		args = append(args, filter.AgentID)
+	}
+
+	embeddingCols := "NULL::vector, ''"
+	if filter.WithEmbeddings {
+		embeddingCols = "embedding, COALESCE(embedding_model, '')"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM vault_knowledge
+		WHERE %s
+		ORDER BY created_at, id`,
+		knowledgeColumns, embeddingCols, strings.Join(conditions, " AND "))
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exporting knowledge: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec KnowledgeExportRecord
+		var embedding *pgvector.Vector
+		if err := rows.Scan(append(knowledgeScanDest(&rec.KnowledgeEntry), &embedding, &rec.EmbeddingModel)...); err != nil {
+			return fmt.Errorf("scanning exported knowledge: %w", err)
+		}
+		if embedding != nil {
+			rec.Vector = embedding.Slice()
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// preserveUpdatedAt stops the touch trigger from bumping updated_at for the
+// rest of tx, so imported timestamps survive.
+func preserveUpdatedAt(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, "SELECT set_config('alexandria.preserve_updated_at', 'on', true)"); err != nil {
+		return fmt.Errorf("preserving updated_at: %w", err)
+	}
+	return nil
+}
+
+// ImportKnowledge upserts rec by ID, keeping its owner and timestamps. An
+// existing entry is left alone if every imported field already matches;
+// otherwise it is versioned like any other edit, recorded under agentID.
+// superseded_by is only set when the target exists; the returned entry shows
+// whether it was, so callers can link it once the rest of the import has
+// landed.
+func (s *KnowledgeStore) ImportKnowledge(ctx context.Context, rec KnowledgeExportRecord, embedding pgvector.Vector, agentID string) (*KnowledgeEntry, ImportOutcome, error) {
+	var entry *KnowledgeEntry
+	var outcome ImportOutcome
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := preserveUpdatedAt(ctx, tx); err != nil {
+			return err
+		}
+
+		var vec any
+		if len(embedding.Slice()) > 0 {
+			vec = embedding
+		}
+
+		// Without an embedding in the record the stored one is not compared.
+		var unchanged bool
+		err := tx.QueryRow(ctx, `
+			SELECT content = $2 AND summary IS NOT DISTINCT FROM $3 AND source_agent = $4
+				AND category = $5 AND scope = $6 AND shared_with IS NOT DISTINCT FROM $7
+				AND tags IS NOT DISTINCT FROM $8 AND metadata IS NOT DISTINCT FROM $9
+				AND source_event_id IS NOT DISTINCT FROM $10 AND confidence IS NOT DISTINCT FROM $11
+				AND relevance_decay = $12 AND expires_at IS NOT DISTINCT FROM $13
+				AND superseded_by IS NOT DISTINCT FROM (SELECT k.id FROM vault_knowledge k WHERE k.id = $14)
+				AND deleted_at IS NULL
+				AND ($15::vector IS NULL OR embedding IS NOT DISTINCT FROM $15::vector)
+			FROM vault_knowledge WHERE id = $1
+			FOR UPDATE OF vault_knowledge`,
+			rec.ID, rec.Content, rec.Summary, rec.SourceAgent, rec.Category, rec.Scope, rec.SharedWith,
+			rec.Tags, rec.Metadata, rec.SourceEventID, rec.Confidence, rec.RelevanceDecay, rec.ExpiresAt,
+			rec.SupersededBy, vec,
+		).Scan(&unchanged)
+		exists := err == nil
+		if err != nil && err != pgx.ErrNoRows {
+			return fmt.Errorf("checking existing knowledge: %w", err)
+		}
+
+		if exists && unchanged {
+			entry = &KnowledgeEntry{}
+			outcome = ImportUnchanged
+			return tx.QueryRow(ctx, "SELECT "+knowledgeColumns+" FROM vault_knowledge WHERE id = $1", rec.ID).
+				Scan(knowledgeScanDest(entry)...)
+		}
+
+		createdAt, updated := rec.CreatedAt, rec.UpdatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		if updated.IsZero() {
+			updated = createdAt
+		}
+		version := rec.Version
+		if version < 1 {
+			version = 1
+		}
+
+		if exists {
+			if err := snapshotKnowledgeVersion(ctx, tx, rec.ID, agentID); err != nil {
+				return err
+			}
+			outcome = ImportUpdated
+		} else {
+			outcome = ImportInserted
+		}
+
+		entry = &KnowledgeEntry{}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO vault_knowledge (id, content, summary, source_agent, category, scope, shared_with, tags,
+				embedding, metadata, source_event_id, confidence, relevance_decay, expires_at, superseded_by,
+				version, created_at, updated_at, embedding_model)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+				(SELECT id FROM vault_knowledge WHERE id = $15), $16, $17, $18, NULLIF($19, ''))
+			ON CONFLICT (id) DO UPDATE SET
+				content = EXCLUDED.content, summary = EXCLUDED.summary, source_agent = EXCLUDED.source_agent,
+				category = EXCLUDED.category, scope = EXCLUDED.scope, shared_with = EXCLUDED.shared_with,
+				tags = EXCLUDED.tags, embedding = EXCLUDED.embedding, embedding_model = EXCLUDED.embedding_model,
+				metadata = EXCLUDED.metadata,
+				source_event_id = EXCLUDED.source_event_id, confidence = EXCLUDED.confidence,
+				relevance_decay = EXCLUDED.relevance_decay, expires_at = EXCLUDED.expires_at,
+				superseded_by = EXCLUDED.superseded_by, version = vault_knowledge.version + 1,
+				updated_at = EXCLUDED.updated_at, deleted_at = NULL
+			RETURNING `+knowledgeColumns,
+			rec.ID, rec.Content, rec.Summary, rec.SourceAgent, rec.Category, rec.Scope, rec.SharedWith, rec.Tags,
+			vec, rec.Metadata, rec.SourceEventID, rec.Confidence, rec.RelevanceDecay, rec.ExpiresAt, rec.SupersededBy,
+			version, createdAt, updated, rec.EmbeddingModel,
+		).Scan(knowledgeScanDest(entry)...)
+		if err != nil {
+			return fmt.Errorf("importing knowledge entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return entry, outcome, nil
+}
+
+// LinkSuperseded sets superseded_by on id once replacement exists. It is the
+// second pass of an import whose records arrived before their replacements,
+// so the entry keeps its imported updated_at.
+func (s *KnowledgeStore) LinkSuperseded(ctx context.Context, id, replacement string) error {
+	return s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := preserveUpdatedAt(ctx, tx); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `
+			UPDATE vault_knowledge SET superseded_by = $2
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM vault_knowledge WHERE id = $2)`,
+			id, replacement)
+		if err != nil {
+			return fmt.Errorf("linking superseded knowledge: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrKnowledgeNotFound
+		}
+		return nil
+	})
+}
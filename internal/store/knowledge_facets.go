@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter is returned for a malformed knowledge filter.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// MetadataOp is a comparison applied to a metadata value.
+type MetadataOp string
+
+const (
+	MetadataEq     MetadataOp = "eq"     // value as text equals
+	MetadataNe     MetadataOp = "ne"     // value as text differs, or is absent
+	MetadataGt     MetadataOp = "gt"     // numeric value greater than
+	MetadataGte    MetadataOp = "gte"    // numeric value at least
+	MetadataLt     MetadataOp = "lt"     // numeric value less than
+	MetadataLte    MetadataOp = "lte"    // numeric value at most
+	MetadataExists MetadataOp = "exists" // path is present
+)
+
+// MetadataPredicate tests the metadata value at a dotted path, e.g.
+// host.name:eq:nfs-02.
+type MetadataPredicate struct {
+	Path  []string
+	Op    MetadataOp
+	Value string
+}
+
+// ParseMetadataPredicate parses "<path>:<op>[:<value>]". Path segments are
+// separated by dots; the value may itself contain colons.
+func ParseMetadataPredicate(s string) (MetadataPredicate, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return MetadataPredicate{}, fmt.Errorf("%w: metadata predicate %q must be <path>:<op>[:<value>]", ErrInvalidFilter, s)
+	}
+	p := MetadataPredicate{Path: strings.Split(parts[0], "."), Op: MetadataOp(parts[1])}
+	for _, seg := range p.Path {
+		if seg == "" {
+			return MetadataPredicate{}, fmt.Errorf("%w: metadata path %q has an empty segment", ErrInvalidFilter, parts[0])
+		}
+	}
+	if len(parts) == 3 {
+		p.Value = parts[2]
+	}
+
+	switch p.Op {
+	case MetadataExists:
+		if len(parts) == 3 {
+			return MetadataPredicate{}, fmt.Errorf("%w: metadata exists takes no value", ErrInvalidFilter)
+		}
+	case MetadataEq, MetadataNe:
+		if len(parts) < 3 {
+			return MetadataPredicate{}, fmt.Errorf("%w: metadata %s needs a value", ErrInvalidFilter, p.Op)
+		}
+	case MetadataGt, MetadataGte, MetadataLt, MetadataLte:
+		if _, err := strconv.ParseFloat(p.Value, 64); err != nil {
+			return MetadataPredicate{}, fmt.Errorf("%w: metadata %s needs a numeric value", ErrInvalidFilter, p.Op)
+		}
+	default:
+		return MetadataPredicate{}, fmt.Errorf("%w: unknown metadata operator %q", ErrInvalidFilter, p.Op)
+	}
+	return p, nil
+}
+
+// condition renders p as SQL against the metadata column. Numeric operators
+// only match JSON numbers, so a string value never fails the cast.
+func (p MetadataPredicate) condition(args []any, argN int) (string, []any, int) {
+	path := fmt.Sprintf("$%d::text[]", argN)
+	args = append(args, p.Path)
+	argN++
+
+	switch p.Op {
+	case MetadataExists:
+		return fmt.Sprintf("metadata #> %s IS NOT NULL", path), args, argN
+	case MetadataEq:
+		args = append(args, p.Value)
+		return fmt.Sprintf("metadata #>> %s = $%d", path, argN), args, argN + 1
+	case MetadataNe:
+		args = append(args, p.Value)
+		return fmt.Sprintf("metadata #>> %s IS DISTINCT FROM $%d", path, argN), args, argN + 1
+	}
+
+	ops := map[MetadataOp]string{MetadataGt: ">", MetadataGte: ">=", MetadataLt: "<", MetadataLte: "<="}
+	n, _ := strconv.ParseFloat(p.Value, 64) // validated by ParseMetadataPredicate
+	args = append(args, n)
+	return fmt.Sprintf(
+		"(CASE WHEN jsonb_typeof(metadata #> %s) = 'number' THEN (metadata #>> %s)::numeric END) %s $%d::numeric",
+		path, path, ops[p.Op], argN), args, argN + 1
+}
+
+// FacetCount is the number of entries sharing one facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// KnowledgeFacets are counts over the entries visible to an agent.
+type KnowledgeFacets struct {
+	Total        int64        `json:"total"`
+	Tags         []FacetCount `json:"tags"`
+	Categories   []FacetCount `json:"categories"`
+	Scopes       []FacetCount `json:"scopes"`
+	SourceAgents []FacetCount `json:"source_agents"`
+}
+
+// Facets counts the entries matching filter per tag, category, scope and
+// source agent, most common first. Only the top tagLimit tags are returned.
+func (s *KnowledgeStore) Facets(ctx context.Context, filter KnowledgeFilter, tagLimit int) (*KnowledgeFacets, error) {
+	conditions, args, _ := knowledgeListConditions(filter)
+	if tagLimit <= 0 || tagLimit > 500 {
+		tagLimit = 100
+	}
+
+	// One statement so every facet is counted over the same snapshot.
+	query := fmt.Sprintf(`
+		WITH visible AS (
+			SELECT tags, category, scope, source_agent
+			FROM vault_knowledge
+			WHERE %s
+		)
+		SELECT 'total', '', COUNT(*) FROM visible
+		UNION ALL
+		SELECT 'category', category::text, COUNT(*) FROM visible GROUP BY category
+		UNION ALL
+		SELECT 'scope', scope::text, COUNT(*) FROM visible GROUP BY scope
+		UNION ALL
+		SELECT 'source_agent', source_agent, COUNT(*) FROM visible GROUP BY source_agent
+		UNION ALL
+		(SELECT 'tag', tag, COUNT(*) FROM visible, unnest(tags) AS tag
+		 GROUP BY tag ORDER BY COUNT(*) DESC, tag LIMIT %d)`,
+		strings.Join(conditions, " AND "), tagLimit)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("counting knowledge facets: %w", err)
+	}
+	defer rows.Close()
+
+	facets := &KnowledgeFacets{
+		Tags:         []FacetCount{},
+		Categories:   []FacetCount{},
+		Scopes:       []FacetCount{},
+		SourceAgents: []FacetCount{},
+	}
+	for rows.Next() {
+		var facet string
+		var fc FacetCount
+		if err := rows.Scan(&facet, &fc.Value, &fc.Count); err != nil {
+			return nil, fmt.Errorf("scanning knowledge facet: %w", err)
+		}
+		switch facet {
+		case "total":
+			facets.Total = fc.Count
+		case "category":
+			facets.Categories = append(facets.Categories, fc)
+		case "scope":
+			facets.Scopes = append(facets.Scopes, fc)
+		case "source_agent":
+			facets.SourceAgents = append(facets.SourceAgents, fc)
+		case "tag":
+			facets.Tags = append(facets.Tags, fc)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, counts := range [][]FacetCount{facets.Tags, facets.Categories, facets.Scopes, facets.SourceAgents} {
+		sortFacets(counts)
+	}
+	return facets, nil
+}
+
+// sortFacets orders counts most common first, then by value.
+func sortFacets(counts []FacetCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+}
@@ -0,0 +1,281 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrQuotaExceeded matches any QuotaError.
+	ErrQuotaExceeded = errors.New("knowledge quota exceeded")
+	// ErrInvalidQuota is returned for a malformed quota override.
+	ErrInvalidQuota = errors.New("invalid quota")
+)
+
+// QuotaLimit names one of the limits in a Quota.
+type QuotaLimit string
+
+const (
+	QuotaEntries      QuotaLimit = "entries"       // live entries
+	QuotaBytes        QuotaLimit = "bytes"         // content and summary bytes of live entries
+	QuotaDailyCreates QuotaLimit = "daily_creates" // entries created since midnight UTC
+)
+
+// Quota limits what one agent may store in vault_knowledge. Zero disables a
+// limit.
+type Quota struct {
+	MaxEntries      int64 `json:"max_entries"`
+	MaxBytes        int64 `json:"max_bytes"`
+	MaxDailyCreates int64 `json:"max_daily_creates"`
+}
+
+// Unlimited reports whether q imposes no limit at all.
+func (q Quota) Unlimited() bool {
+	return q.MaxEntries == 0 && q.MaxBytes == 0 && q.MaxDailyCreates == 0
+}
+
+// QuotaOverride replaces some of the default limits for one agent. Nil
+// fields keep the default.
+type QuotaOverride struct {
+	MaxEntries      *int64 `json:"max_entries"`
+	MaxBytes        *int64 `json:"max_bytes"`
+	MaxDailyCreates *int64 `json:"max_daily_creates"`
+}
+
+// Apply returns defaults with o's limits in place of those it sets.
+func (o QuotaOverride) Apply(defaults Quota) Quota {
+	q := defaults
+	if o.MaxEntries != nil {
+		q.MaxEntries = *o.MaxEntries
+	}
+	if o.MaxBytes != nil {
+		q.MaxBytes = *o.MaxBytes
+	}
+	if o.MaxDailyCreates != nil {
+		q.MaxDailyCreates = *o.MaxDailyCreates
+	}
+	return q
+}
+
+// Validate rejects negative limits.
+func (o QuotaOverride) Validate() error {
+	for _, v := range []*int64{o.MaxEntries, o.MaxBytes, o.MaxDailyCreates} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("%w: limits must not be negative", ErrInvalidQuota)
+		}
+	}
+	return nil
+}
+
+// Usage is what an agent currently stores, against its quota.
+type Usage struct {
+	AgentID      string `json:"agent_id"`
+	Entries      int64  `json:"entries"`
+	Bytes        int64  `json:"bytes"`
+	CreatesToday int64  `json:"creates_today"`
+	Quota        Quota  `json:"quota"`
+	// Custom is set when the agent has its own quota override.
+	Custom bool `json:"custom_quota"`
+}
+
+// QuotaError reports which limit a create would exceed.
+type QuotaError struct {
+	AgentID string     `json:"agent_id"`
+	Limit   QuotaLimit `json:"limit"`
+	Max     int64      `json:"max"`
+	Used    int64      `json:"used"`
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("agent %s would exceed its %s quota (%d of %d used)", e.AgentID, e.Limit, e.Used, e.Max)
+}
+
+// Is lets errors.Is(err, ErrQuotaExceeded) match.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Check returns a *QuotaError if one more entry of size bytes would exceed
+// u's quota, or nil if it fits.
+func (u Usage) Check(size int64) error {
+	q := u.Quota
+	switch {
+	case q.MaxEntries > 0 && u.Entries+1 > q.MaxEntries:
+		return &QuotaError{AgentID: u.AgentID, Limit: QuotaEntries, Max: q.MaxEntries, Used: u.Entries}
+	case q.MaxBytes > 0 && u.Bytes+size > q.MaxBytes:
+		return &QuotaError{AgentID: u.AgentID, Limit: QuotaBytes, Max: q.MaxBytes, Used: u.Bytes}
+	case q.MaxDailyCreates > 0 && u.CreatesToday+1 > q.MaxDailyCreates:
+		return &QuotaError{AgentID: u.AgentID, Limit: QuotaDailyCreates, Max: q.MaxDailyCreates, Used: u.CreatesToday}
+	}
+	return nil
+}
+
+// EntrySize is the number of bytes an entry counts against QuotaBytes.
+func EntrySize(content string, summary *string) int64 {
+	n := int64(len(content))
+	if summary != nil {
+		n += int64(len(*summary))
+	}
+	return n
+}
+
+// entrySizeSQL computes EntrySize in Postgres.
+const entrySizeSQL = `(octet_length(content) + COALESCE(octet_length(summary), 0))`
+
+// SetQuota sets the default quota applied to agents without an override.
+func (s *KnowledgeStore) SetQuota(defaults Quota) {
+	s.quota = defaults
+}
+
+// startOfDay is midnight UTC of the day containing t.
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// agentQuota returns agentID's effective quota and whether it has an override.
+func (s *KnowledgeStore) agentQuota(ctx context.Context, db DBTX, agentID string) (Quota, bool, error) {
+	var o QuotaOverride
+	err := db.QueryRow(ctx,
+		"SELECT max_entries, max_bytes, max_daily_creates FROM vault_agent_quotas WHERE agent_id = $1", agentID,
+	).Scan(&o.MaxEntries, &o.MaxBytes, &o.MaxDailyCreates)
+	if err == pgx.ErrNoRows {
+		return s.quota, false, nil
+	}
+	if err != nil {
+		return Quota{}, false, fmt.Errorf("loading agent quota: %w", err)
+	}
+	return o.Apply(s.quota), true, nil
+}
+
+// agentUsage counts what agentID stores, without its quota.
+func agentUsage(ctx context.Context, db DBTX, agentID string) (Usage, error) {
+	u := Usage{AgentID: agentID}
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE deleted_at IS NULL),
+		       COALESCE(SUM(`+entrySizeSQL+`) FILTER (WHERE deleted_at IS NULL), 0),
+		       COUNT(*) FILTER (WHERE created_at >= $2)
+		FROM vault_knowledge
+		WHERE source_agent = $1`,
+		agentID, startOfDay(time.Now()),
+	).Scan(&u.Entries, &u.Bytes, &u.CreatesToday)
+	if err != nil {
+		return u, fmt.Errorf("counting agent usage: %w", err)
+	}
+	return u, nil
+}
+
+// lockQuota returns agentID's quota and, unless it is unlimited, serialises
+// the agent's creates for the rest of tx so concurrent writers cannot both
+// squeeze under a limit. Take it before any other lock in tx.
+func (s *KnowledgeStore) lockQuota(ctx context.Context, tx pgx.Tx, agentID string) (Quota, error) {
+	quota, _, err := s.agentQuota(ctx, tx, agentID)
+	if err != nil || quota.Unlimited() {
+		return quota, err
+	}
+	if _, err := tx.Exec(ctx,
+		"SELECT pg_advisory_xact_lock(hashtextextended('vault_knowledge_quota:' || $1, 0))", agentID,
+	); err != nil {
+		return quota, fmt.Errorf("locking agent quota: %w", err)
+	}
+	return quota, nil
+}
+
+// checkQuota fails with a *QuotaError if inserting input would take its
+// source agent over quota, as returned by lockQuota.
+func checkQuota(ctx context.Context, tx pgx.Tx, input KnowledgeCreateInput, quota Quota) error {
+	if quota.Unlimited() {
+		return nil
+	}
+	usage, err := agentUsage(ctx, tx, input.SourceAgent)
+	if err != nil {
+		return err
+	}
+	usage.Quota = quota
+	return usage.Check(EntrySize(input.Content, input.Summary))
+}
+
+// AgentUsage returns what agentID stores against its quota.
+func (s *KnowledgeStore) AgentUsage(ctx context.Context, agentID string) (*Usage, error) {
+	quota, custom, err := s.agentQuota(ctx, s.db.Pool, agentID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := agentUsage(ctx, s.db.Pool, agentID)
+	if err != nil {
+		return nil, err
+	}
+	usage.Quota = quota
+	usage.Custom = custom
+	return &usage, nil
+}
+
+// UsageByAgent returns the usage of every agent with live entries or a
+// quota override, largest stores first.
+func (s *KnowledgeStore) UsageByAgent(ctx context.Context) ([]Usage, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		WITH usage AS (
+			SELECT source_agent AS agent_id,
+			       COUNT(*) FILTER (WHERE deleted_at IS NULL) AS entries,
+			       COALESCE(SUM(`+entrySizeSQL+`) FILTER (WHERE deleted_at IS NULL), 0) AS bytes,
+			       COUNT(*) FILTER (WHERE created_at >= $1) AS creates_today
+			FROM vault_knowledge
+			GROUP BY source_agent
+		)
+		SELECT COALESCE(u.agent_id, q.agent_id), COALESCE(u.entries, 0), COALESCE(u.bytes, 0), COALESCE(u.creates_today, 0),
+		       q.agent_id IS NOT NULL, q.max_entries, q.max_bytes, q.max_daily_creates
+		FROM usage u
+		FULL JOIN vault_agent_quotas q ON q.agent_id = u.agent_id
+		WHERE COALESCE(u.entries, 0) > 0 OR COALESCE(u.creates_today, 0) > 0 OR q.agent_id IS NOT NULL
+		ORDER BY 3 DESC, 1`,
+		startOfDay(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("listing agent usage: %w", err)
+	}
+	defer rows.Close()
+
+	usages := []Usage{}
+	for rows.Next() {
+		var u Usage
+		var o QuotaOverride
+		if err := rows.Scan(&u.AgentID, &u.Entries, &u.Bytes, &u.CreatesToday,
+			&u.Custom, &o.MaxEntries, &o.MaxBytes, &o.MaxDailyCreates); err != nil {
+			return nil, fmt.Errorf("scanning agent usage: %w", err)
+		}
+		u.Quota = o.Apply(s.quota)
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
+
+// SetAgentQuota saves agentID's quota override.
+func (s *KnowledgeStore) SetAgentQuota(ctx context.Context, agentID string, o QuotaOverride, updatedBy string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	_, err := s.db.Pool.Exec(ctx, `
+		INSERT INTO vault_agent_quotas (agent_id, max_entries, max_bytes, max_daily_creates, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (agent_id) DO UPDATE SET
+			max_entries = EXCLUDED.max_entries, max_bytes = EXCLUDED.max_bytes,
+			max_daily_creates = EXCLUDED.max_daily_creates,
+			updated_by = EXCLUDED.updated_by, updated_at = NOW()`,
+		agentID, o.MaxEntries, o.MaxBytes, o.MaxDailyCreates, updatedBy)
+	if err != nil {
+		return fmt.Errorf("saving agent quota: %w", err)
+	}
+	return nil
+}
+
+// DeleteAgentQuota removes agentID's override, returning it to the default
+// quota. It reports whether there was one.
+func (s *KnowledgeStore) DeleteAgentQuota(ctx context.Context, agentID string) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx, "DELETE FROM vault_agent_quotas WHERE agent_id = $1", agentID)
+	if err != nil {
+		return false, fmt.Errorf("deleting agent quota: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExpiredKnowledge describes an entry soft-deleted by the reaper.
+type ExpiredKnowledge struct {
+	ID          string            `json:"id"`
+	SourceAgent string            `json:"source_agent"`
+	Category    KnowledgeCategory `json:"category"`
+	Reason      string            `json:"reason"` // "expired" or "decayed"
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// KnowledgeLifecycleStats summarises knowledge awaiting or in the trash.
+type KnowledgeLifecycleStats struct {
+	SoftDeleted   int64 `json:"soft_deleted"`
+	PendingExpiry int64 `json:"pending_expiry"`
+}
+
+// ExpireKnowledge soft-deletes up to limit live entries whose expires_at has
+// passed, plus ephemeral entries older than ephemeralTTL (whose decayed
+// relevance is effectively zero). A zero ephemeralTTL disables the latter.
+func (s *KnowledgeStore) ExpireKnowledge(ctx context.Context, ephemeralTTL time.Duration, limit int) ([]ExpiredKnowledge, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	cutoff := time.Time{}
+	if ephemeralTTL > 0 {
+		cutoff = time.Now().Add(-ephemeralTTL)
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		UPDATE vault_knowledge SET deleted_at = NOW()
+		WHERE id IN (
+			SELECT id FROM vault_knowledge
+			WHERE deleted_at IS NULL
+			AND (expires_at <= NOW() OR ($1 AND relevance_decay = 'ephemeral' AND created_at < $2))
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, source_agent, category,
+			CASE WHEN expires_at <= NOW() THEN 'expired' ELSE 'decayed' END,
+			expires_at, created_at`,
+		ephemeralTTL > 0, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("expiring knowledge: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []ExpiredKnowledge
+	for rows.Next() {
+		var e ExpiredKnowledge
+		if err := rows.Scan(&e.ID, &e.SourceAgent, &e.Category, &e.Reason, &e.ExpiresAt, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning expired knowledge: %w", err)
+		}
+		expired = append(expired, e)
+	}
+	return expired, rows.Err()
+}
+
+// PurgeDeletedKnowledge permanently removes up to limit entries that have been
+// soft-deleted for longer than retention. Entries still referenced as another
+// entry's replacement are kept so supersession chains stay intact.
+func (s *KnowledgeStore) PurgeDeletedKnowledge(ctx context.Context, retention time.Duration, limit int) (int64, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	tag, err := s.db.Pool.Exec(ctx, `
+		DELETE FROM vault_knowledge
+		WHERE id IN (
+			SELECT k.id FROM vault_knowledge k
+			WHERE k.deleted_at IS NOT NULL AND k.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM vault_knowledge r WHERE r.superseded_by = k.id)
+			ORDER BY k.deleted_at
+			LIMIT $2
+		)`,
+		time.Now().Add(-retention), limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("purging knowledge: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// LifecycleStats returns counts of soft-deleted entries and live entries whose
+// expiry has passed but have not yet been reaped.
+func (s *KnowledgeStore) LifecycleStats(ctx context.Context) (KnowledgeLifecycleStats, error) {
+	var st KnowledgeLifecycleStats
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE deleted_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE deleted_at IS NULL AND expires_at <= NOW())
+		FROM vault_knowledge`,
+	).Scan(&st.SoftDeleted, &st.PendingExpiry)
+	return st, err
+}
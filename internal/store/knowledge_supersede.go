@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrAlreadySuperseded is returned when superseding an entry that already has a replacement.
+var ErrAlreadySuperseded = errors.New("already superseded")
+
+// maxLineageDepth bounds lineage traversal in case superseded_by links form a cycle.
+const maxLineageDepth = 100
+
+// Supersede creates a replacement for an entry and links the original to it
+// in one transaction. Only the owner of the original or admin may supersede.
+// The link is recorded as a new version of the original entry.
+func (s *KnowledgeStore) Supersede(ctx context.Context, id, agentID string, input KnowledgeCreateInput) (old, replacement *KnowledgeEntry, err error) {
+	err = s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		quota, err := s.lockQuota(ctx, tx, input.SourceAgent)
+		if err != nil {
+			return err
+		}
+		if err := lockKnowledgeForWrite(ctx, tx, id, agentID); err != nil {
+			return err
+		}
+
+		var supersededBy *string
+		if err := tx.QueryRow(ctx, "SELECT superseded_by FROM vault_knowledge WHERE id = $1", id).Scan(&supersededBy); err != nil {
+			return fmt.Errorf("checking supersession: %w", err)
+		}
+		if supersededBy != nil {
+			return ErrAlreadySuperseded
+		}
+
+		if err := checkQuota(ctx, tx, input, quota); err != nil {
+			return err
+		}
+		replacement, err = insertKnowledge(ctx, tx, input)
+		if err != nil {
+			return err
+		}
+
+		if err := snapshotKnowledgeVersion(ctx, tx, id, agentID); err != nil {
+			return err
+		}
+
+		old = &KnowledgeEntry{}
+		err = tx.QueryRow(ctx, `
+			UPDATE vault_knowledge SET superseded_by = $1, version = version + 1
+			WHERE id = $2
+			RETURNING `+knowledgeColumns,
+			replacement.ID, id,
+		).Scan(knowledgeScanDest(old)...)
+		if err != nil {
+			return fmt.Errorf("linking superseded entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return old, replacement, nil
+}
+
+// Lineage returns the supersession chain containing id, oldest to newest:
+// every entry that was (transitively) superseded into id, id itself, and
+// every entry that (transitively) supersedes it. Entries the agent cannot
+// access are omitted. Returns ErrKnowledgeNotFound if id is not visible.
+func (s *KnowledgeStore) Lineage(ctx context.Context, id, agentID string) ([]KnowledgeEntry, error) {
+	query := fmt.Sprintf(`
+		WITH RECURSIVE
+		older AS (
+			SELECT id, 0 AS depth FROM vault_knowledge WHERE id = $1
+			UNION ALL
+			SELECT k.id, o.depth - 1 FROM vault_knowledge k
+			JOIN older o ON k.superseded_by = o.id
+			WHERE o.depth > -%[1]d
+		),
+		newer AS (
+			SELECT id, superseded_by, 0 AS depth FROM vault_knowledge WHERE id = $1
+			UNION ALL
+			SELECT k.id, k.superseded_by, n.depth + 1 FROM vault_knowledge k
+			JOIN newer n ON k.id = n.superseded_by
+			WHERE n.depth < %[1]d
+		),
+		chain AS (
+			SELECT id, MIN(depth) AS depth FROM (
+				SELECT id, depth FROM older
+				UNION ALL
+				SELECT id, depth FROM newer
+			) c GROUP BY id
+		)
+		SELECT %[2]s
+		FROM vault_knowledge
+		JOIN chain USING (id)
+		WHERE deleted_at IS NULL
+		ORDER BY chain.depth, created_at`,
+		maxLineageDepth, knowledgeColumns)
+
+	rows, err := s.db.Pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("walking knowledge lineage: %w", err)
+	}
+	defer rows.Close()
+
+	var chain []KnowledgeEntry
+	found := false
+	for rows.Next() {
+		var e KnowledgeEntry
+		if err := rows.Scan(knowledgeScanDest(&e)...); err != nil {
+			return nil, fmt.Errorf("scanning lineage entry: %w", err)
+		}
+		if !canAccess(&e, agentID) {
+			continue
+		}
+		if e.ID == id {
+			found = true
+		}
+		chain = append(chain, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrKnowledgeNotFound
+	}
+	return chain, nil
+}
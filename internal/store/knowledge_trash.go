@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TrashedKnowledge is a soft-deleted knowledge entry.
+type TrashedKnowledge struct {
+	KnowledgeEntry
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashFilter controls trash listing.
+type TrashFilter struct {
+	AgentID      string     // requesting agent; non-admins only see their own entries
+	SourceAgent  *string    // admin-only filter on owner
+	DeletedAfter *time.Time // only entries deleted at or after this time
+}
+
+// ListTrash returns soft-deleted entries newest first, by creation like
+// every other listing, using keyset pagination. Agents see only entries they
+// own; warren sees all.
+func (s *KnowledgeStore) ListTrash(ctx context.Context, filter TrashFilter, page PageRequest) ([]TrashedKnowledge, PageInfo, error) {
+	var info PageInfo
+	conditions := []string{"deleted_at IS NOT NULL"}
+	var args []any
+	argN := 1
+
+	owner := filter.SourceAgent
+	if filter.AgentID != "warren" {
+		owner = &filter.AgentID
+	}
+	if owner != nil {
+		conditions = append(conditions, fmt.Sprintf("source_agent = $%d", argN))
+		args = append(args, *owner)
+		argN++
+	}
+	if filter.DeletedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("deleted_at >= $%d", argN))
+		args = append(args, *filter.DeletedAfter)
+		argN++
+	}
+
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_knowledge", conditions, args)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, conditions, args, argN)
+	if err != nil {
+		return nil, info, err
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`
+		SELECT %s, deleted_at
+		FROM vault_knowledge
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT %d`,
+		knowledgeColumns, strings.Join(conditions, " AND "), limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing trash: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []TrashedKnowledge
+	for rows.Next() {
+		var e TrashedKnowledge
+		if err := rows.Scan(append(knowledgeScanDest(&e.KnowledgeEntry), &e.DeletedAt)...); err != nil {
+			return nil, info, fmt.Errorf("scanning trashed knowledge: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	entries, info.NextCursor = trimPage(entries, limit, func(e TrashedKnowledge) string {
+		return EncodeCursor(timeCursorKey(e.CreatedAt), e.ID)
+	})
+	return entries, info, nil
+}
+
+// Restore undeletes a soft-deleted entry. Only the owner or admin can restore.
+// An expiry that has already passed is cleared, and an ephemeral entry's age
+// restarts, so the reaper does not immediately delete the entry again. If a
+// live entry has since been created for the same source event, the restored
+// entry gives up its source event ID. The
+// entry counts against its owner's quota like a create, except that only an
+// ephemeral entry, whose age restarts, counts as created today.
+func (s *KnowledgeStore) Restore(ctx context.Context, id, agentID string) (*KnowledgeEntry, error) {
+	var entry KnowledgeEntry
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		// The quota lock must come first, so the owner is read unlocked.
+		var owner string
+		err := tx.QueryRow(ctx,
+			"SELECT source_agent FROM vault_knowledge WHERE id = $1 AND deleted_at IS NOT NULL", id,
+		).Scan(&owner)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrKnowledgeNotFound
+			}
+			return fmt.Errorf("checking ownership: %w", err)
+		}
+		if !canModify(owner, agentID) {
+			return ErrAccessDenied
+		}
+
+		quota, err := s.lockQuota(ctx, tx, owner)
+		if err != nil {
+			return err
+		}
+		var ephemeral bool
+		var size int64
+		err = tx.QueryRow(ctx, `
+			SELECT relevance_decay = 'ephemeral', `+entrySizeSQL+`
+			FROM vault_knowledge
+			WHERE id = $1 AND source_agent = $2 AND deleted_at IS NOT NULL
+			FOR UPDATE`, id, owner,
+		).Scan(&ephemeral, &size)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrKnowledgeNotFound // restored or reassigned meanwhile
+			}
+			return fmt.Errorf("locking knowledge entry: %w", err)
+		}
+		if !quota.Unlimited() {
+			usage, err := agentUsage(ctx, tx, owner)
+			if err != nil {
+				return err
+			}
+			if !ephemeral {
+				quota.MaxDailyCreates = 0
+			}
+			usage.Quota = quota
+			if err := usage.Check(size); err != nil {
+				return err
+			}
+		}
+
+		return tx.QueryRow(ctx, fmt.Sprintf(`
+			UPDATE vault_knowledge
+			SET deleted_at = NULL,
+				expires_at = CASE WHEN expires_at <= NOW() THEN NULL ELSE expires_at END,
+				created_at = CASE WHEN relevance_decay = 'ephemeral' THEN NOW() ELSE created_at END,
+				source_event_id = CASE WHEN EXISTS (
+					SELECT 1 FROM vault_knowledge o
+					WHERE o.source_agent = vault_knowledge.source_agent
+					AND o.source_event_id = vault_knowledge.source_event_id
+					AND o.deleted_at IS NULL
+				) THEN NULL ELSE source_event_id END
+			WHERE id = $1
+			RETURNING %s`, knowledgeColumns), id,
+		).Scan(knowledgeScanDest(&entry)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// KnowledgeVersion is a snapshot of a knowledge entry as it was before an edit.
+type KnowledgeVersion struct {
+	KnowledgeID    string            `json:"knowledge_id"`
+	Version        int               `json:"version"`
+	Content        string            `json:"content,omitempty"`
+	Summary        *string           `json:"summary,omitempty"`
+	Category       KnowledgeCategory `json:"category"`
+	Scope          KnowledgeScope    `json:"scope"`
+	SharedWith     []string          `json:"shared_with,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Metadata       map[string]any    `json:"metadata,omitempty"`
+	Confidence     float64           `json:"confidence"`
+	RelevanceDecay RelevanceDecay    `json:"relevance_decay"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	SupersededBy   *string           `json:"superseded_by,omitempty"`
+	EditedBy       string            `json:"edited_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// snapshotKnowledgeVersion copies the current state of an entry into
+// vault_knowledge_versions. editedBy is the agent whose edit replaces it.
+func snapshotKnowledgeVersion(ctx context.Context, tx pgx.Tx, id, editedBy string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO vault_knowledge_versions (knowledge_id, version, content, summary, category, scope, shared_with,
+		       tags, embedding, embedding_model, metadata, confidence, relevance_decay, expires_at, superseded_by, edited_by)
+		SELECT id, version, content, summary, category, scope, shared_with,
+		       tags, embedding, embedding_model, metadata, confidence, relevance_decay, expires_at, superseded_by, $2
+		FROM vault_knowledge WHERE id = $1`,
+		id, editedBy)
+	if err != nil {
+		return fmt.Errorf("recording knowledge version: %w", err)
+	}
+	return nil
+}
+
+// checkKnowledgeOwner verifies a live entry exists and agentID may modify it.
+func (s *KnowledgeStore) checkKnowledgeOwner(ctx context.Context, id, agentID string) error {
+	var owner string
+	err := s.db.Pool.QueryRow(ctx, "SELECT source_agent FROM vault_knowledge WHERE id = $1 AND deleted_at IS NULL", id).Scan(&owner)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return ErrKnowledgeNotFound
+		}
+		return fmt.Errorf("checking ownership: %w", err)
+	}
+	if !canModify(owner, agentID) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// ListVersions returns the edit history of an entry, newest first, without
+// content bodies. Only the owning agent or admin can read history.
+func (s *KnowledgeStore) ListVersions(ctx context.Context, id, agentID string) ([]KnowledgeVersion, error) {
+	if err := s.checkKnowledgeOwner(ctx, id, agentID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT knowledge_id, version, summary, category, scope, shared_with, tags, metadata,
+		       confidence, relevance_decay, expires_at, superseded_by, edited_by, created_at
+		FROM vault_knowledge_versions
+		WHERE knowledge_id = $1
+		ORDER BY version DESC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("listing knowledge versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []KnowledgeVersion
+	for rows.Next() {
+		var v KnowledgeVersion
+		if err := rows.Scan(
+			&v.KnowledgeID, &v.Version, &v.Summary, &v.Category, &v.Scope, &v.SharedWith, &v.Tags, &v.Metadata,
+			&v.Confidence, &v.RelevanceDecay, &v.ExpiresAt, &v.SupersededBy, &v.EditedBy, &v.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning knowledge version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns a single historical version of an entry. It returns
+// nil, nil if the entry exists but has no such version.
+func (s *KnowledgeStore) GetVersion(ctx context.Context, id, agentID string, version int) (*KnowledgeVersion, error) {
+	if err := s.checkKnowledgeOwner(ctx, id, agentID); err != nil {
+		return nil, err
+	}
+
+	v := &KnowledgeVersion{}
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT knowledge_id, version, content, summary, category, scope, shared_with, tags, metadata,
+		       confidence, relevance_decay, expires_at, superseded_by, edited_by, created_at
+		FROM vault_knowledge_versions
+		WHERE knowledge_id = $1 AND version = $2`, id, version,
+	).Scan(
+		&v.KnowledgeID, &v.Version, &v.Content, &v.Summary, &v.Category, &v.Scope, &v.SharedWith, &v.Tags, &v.Metadata,
+		&v.Confidence, &v.RelevanceDecay, &v.ExpiresAt, &v.SupersededBy, &v.EditedBy, &v.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting knowledge version: %w", err)
+	}
+	return v, nil
+}
+
+// Revert restores an entry to a historical version. The state being replaced
+// is itself recorded as a version, so a revert can be undone.
+func (s *KnowledgeStore) Revert(ctx context.Context, id, agentID string, version int) (*KnowledgeEntry, error) {
+	entry := &KnowledgeEntry{}
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockKnowledgeForWrite(ctx, tx, id, agentID); err != nil {
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM vault_knowledge_versions WHERE knowledge_id = $1 AND version = $2)",
+			id, version,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("checking knowledge version: %w", err)
+		}
+		if !exists {
+			return ErrVersionNotFound
+		}
+
+		if err := snapshotKnowledgeVersion(ctx, tx, id, agentID); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, `
+			UPDATE vault_knowledge k SET
+				content = v.content, summary = v.summary, category = v.category, scope = v.scope,
+				shared_with = v.shared_with, tags = v.tags, embedding = v.embedding,
+				embedding_model = v.embedding_model, metadata = v.metadata,
+				confidence = v.confidence, relevance_decay = v.relevance_decay, expires_at = v.expires_at,
+				superseded_by = v.superseded_by, version = k.version + 1
+			FROM vault_knowledge_versions v
+			WHERE k.id = $1 AND v.knowledge_id = k.id AND v.version = $2
+			RETURNING k.id, k.content, k.summary, k.source_agent, k.category, k.scope, k.shared_with, k.tags, k.metadata,
+			          k.source_event_id, k.confidence, k.relevance_decay, k.expires_at, k.superseded_by, k.version,
+			          k.created_at, k.updated_at`,
+			id, version,
+		).Scan(knowledgeScanDest(entry)...)
+		if err != nil {
+			return fmt.Errorf("reverting knowledge entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// PageRequest describes one page of a keyset-paginated listing. Listings
+// are ordered newest first by (created_at, id), so a walk sees each row that
+// existed when it started exactly once, but not rows created since: those
+// sort ahead of the cursor.
+type PageRequest struct {
+	Limit        int    // page size; defaults to 50, capped at 500
+	Cursor       string // opaque next_cursor from a previous page
+	IncludeTotal bool   // also count all rows matching the filter
+}
+
+// PageInfo describes where a page sits in the full listing.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// pageCursor is the decoded form of an opaque cursor: the sort key and ID of
+// the last row on the previous page.
+type pageCursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// EncodeCursor builds an opaque cursor from a sort key and row ID.
+func EncodeCursor(key, id string) string {
+	data, _ := json.Marshal(pageCursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor.
+func DecodeCursor(cursor string) (key, id string, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return "", "", ErrInvalidCursor
+	}
+	return c.Key, c.ID, nil
+}
+
+// timeCursorKey formats a timestamp sort key losslessly.
+func timeCursorKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// pageLimit normalises the requested page size.
+func (p PageRequest) pageLimit() int {
+	if p.Limit <= 0 {
+		return defaultPageSize
+	}
+	if p.Limit > maxPageSize {
+		return maxPageSize
+	}
+	return p.Limit
+}
+
+// keysetCondition appends a keyset predicate for "ORDER BY col, id" (or DESC)
+// when the page has a cursor. keyType is the SQL type the sort key is cast to.
+// It returns the updated conditions, args and next placeholder number.
+func (p PageRequest) keysetCondition(col, keyType string, desc bool, conditions []string, args []any, argN int) ([]string, []any, int, error) {
+	if p.Cursor == "" {
+		return conditions, args, argN, nil
+	}
+	key, id, err := DecodeCursor(p.Cursor)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d::uuid)", col, op, argN, keyType, argN+1))
+	args = append(args, key, id)
+	return conditions, args, argN + 2, nil
+}
+
+// trimPage cuts a result fetched with limit+1 rows down to limit and reports
+// the cursor for the following page.
+func trimPage[T any](rows []T, limit int, cursorOf func(T) string) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, cursorOf(rows[limit-1])
+}
+
+// countWhere counts rows in table matching conditions, for PageInfo.Total.
+func countWhere(ctx context.Context, db DBTX, table string, conditions []string, args []any) (*int64, error) {
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	var total int64
+	if err := db.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("counting %s: %w", table, err)
+	}
+	return &total, nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -127,6 +128,58 @@ func (s *PersonStore) List(ctx context.Context) ([]Person, error) {
 	return people, rows.Err()
 }
 
+// ListPage returns people newest first using keyset pagination.
+func (s *PersonStore) ListPage(ctx context.Context, page PageRequest) ([]Person, PageInfo, error) {
+	var info PageInfo
+	if page.IncludeTotal {
+		total, err := countWhere(ctx, s.db.Pool, "vault_people", nil, nil)
+		if err != nil {
+			return nil, info, err
+		}
+		info.Total = total
+	}
+
+	conditions, args, _, err := page.keysetCondition("created_at", "timestamptz", true, nil, nil, 1)
+	if err != nil {
+		return nil, info, err
+	}
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	limit := page.pageLimit()
+
+	query := fmt.Sprintf(`
+		SELECT id, name, identifier, metadata, created_at, updated_at
+		FROM vault_people WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, where, limit+1)
+
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, info, fmt.Errorf("listing people: %w", err)
+	}
+	defer rows.Close()
+
+	var people []Person
+	for rows.Next() {
+		var p Person
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Identifier,
+			&p.Metadata, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, info, fmt.Errorf("scanning person: %w", err)
+		}
+		people = append(people, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, info, err
+	}
+
+	people, info.NextCursor = trimPage(people, limit, func(p Person) string {
+		return EncodeCursor(timeCursorKey(p.CreatedAt), p.ID)
+	})
+	return people, info, nil
+}
+
 // Update updates a person.
 func (s *PersonStore) Update(ctx context.Context, id string, input PersonUpdateInput) (*Person, error) {
 	// Build dynamic query
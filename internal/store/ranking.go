@@ -0,0 +1,281 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrProfileNotFound is returned for an unknown ranking profile.
+	ErrProfileNotFound = errors.New("ranking profile not found")
+	// ErrInvalidProfile is returned for a malformed ranking profile.
+	ErrInvalidProfile = errors.New("invalid ranking profile")
+)
+
+// DefaultProfileName is the profile used when a request names none.
+const DefaultProfileName = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// HalfLives maps each decay class to its half-life in days. A class that
+// is missing or zero does not decay.
+type HalfLives map[RelevanceDecay]float64
+
+// DefaultHalfLives are the half-lives used when none are configured.
+func DefaultHalfLives() HalfLives {
+	return HalfLives{DecaySlow: 30, DecayFast: 7, DecayEphemeral: 1}
+}
+
+// RankingProfile decides how a match's base score (similarity or text rank)
+// becomes its relevance:
+//
+//	relevance = score × decay × confidence factor × category weight × agent trust
+//
+// where decay is 0.5^(age / half-life) and the confidence factor is
+// 1 − w + w × confidence for ConfidenceWeight w, so w = 0 ignores confidence
+// and w = 1 scales by it fully. Categories and agents that are not listed
+// weigh 1.
+type RankingProfile struct {
+	Name             string                        `json:"name"`
+	Description      string                        `json:"description,omitempty"`
+	ConfidenceWeight float64                       `json:"confidence_weight"`
+	HalfLifeDays     HalfLives                     `json:"half_life_days"`
+	CategoryWeights  map[KnowledgeCategory]float64 `json:"category_weights,omitempty"`
+	AgentTrust       map[string]float64            `json:"agent_trust,omitempty"`
+	BuiltIn          bool                          `json:"built_in"`
+	UpdatedAt        *time.Time                    `json:"updated_at,omitempty"`
+}
+
+// RankingFactors are the multipliers a profile applied to one entry.
+type RankingFactors struct {
+	HalfLifeDays     float64
+	AgeDays          float64
+	Decay            float64
+	ConfidenceFactor float64
+	CategoryWeight   float64
+	AgentTrust       float64
+}
+
+// Multiplier is the product of all factors.
+func (f RankingFactors) Multiplier() float64 {
+	return f.Decay * f.ConfidenceFactor * f.CategoryWeight * f.AgentTrust
+}
+
+// Factors computes the multipliers p applies to e as of now.
+func (p *RankingProfile) Factors(e *KnowledgeEntry, now time.Time) RankingFactors {
+	f := RankingFactors{
+		AgeDays:          now.Sub(e.CreatedAt).Hours() / 24,
+		Decay:            1,
+		ConfidenceFactor: 1 - p.ConfidenceWeight + p.ConfidenceWeight*e.Confidence,
+		CategoryWeight:   1,
+		AgentTrust:       1,
+	}
+	if e.RelevanceDecay != DecayNone {
+		if hl := p.HalfLifeDays[e.RelevanceDecay]; hl > 0 {
+			f.HalfLifeDays = hl
+			f.Decay = math.Pow(0.5, math.Max(f.AgeDays, 0)/hl)
+		}
+	}
+	if w, ok := p.CategoryWeights[e.Category]; ok {
+		f.CategoryWeight = w
+	}
+	if w, ok := p.AgentTrust[e.SourceAgent]; ok {
+		f.AgentTrust = w
+	}
+	return f
+}
+
+// Validate checks p's name and weights.
+func (p *RankingProfile) Validate() error {
+	if !profileNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits, '-' or '_'", ErrInvalidProfile)
+	}
+	if p.ConfidenceWeight < 0 || p.ConfidenceWeight > 1 {
+		return fmt.Errorf("%w: confidence_weight must be between 0 and 1", ErrInvalidProfile)
+	}
+	for decay, days := range p.HalfLifeDays {
+		switch decay {
+		case DecaySlow, DecayFast, DecayEphemeral:
+		default:
+			return fmt.Errorf("%w: half_life_days has unknown decay class %q", ErrInvalidProfile, decay)
+		}
+		if days < 0 {
+			return fmt.Errorf("%w: half_life_days must not be negative", ErrInvalidProfile)
+		}
+	}
+	for category, w := range p.CategoryWeights {
+		switch category {
+		case CategoryDiscovery, CategoryLesson, CategoryPreference, CategoryFact,
+			CategoryEvent, CategoryDecision, CategoryRelationship:
+		default:
+			return fmt.Errorf("%w: category_weights has unknown category %q", ErrInvalidProfile, category)
+		}
+		if w < 0 {
+			return fmt.Errorf("%w: category weights must not be negative", ErrInvalidProfile)
+		}
+	}
+	for _, w := range p.AgentTrust {
+		if w < 0 {
+			return fmt.Errorf("%w: agent trust weights must not be negative", ErrInvalidProfile)
+		}
+	}
+	return nil
+}
+
+// BuiltInProfiles returns the profiles available without configuration.
+// "default" reproduces plain decayed similarity; "confident" also scales by
+// confidence; "recent" decays four times as fast.
+func BuiltInProfiles(halfLives HalfLives) []RankingProfile {
+	recent := HalfLives{}
+	for decay, days := range halfLives {
+		recent[decay] = days / 4
+	}
+	return []RankingProfile{
+		{Name: DefaultProfileName, Description: "Similarity with relevance decay", HalfLifeDays: halfLives, BuiltIn: true},
+		{Name: "confident", Description: "Decayed similarity scaled by confidence", ConfidenceWeight: 1, HalfLifeDays: halfLives, BuiltIn: true},
+		{Name: "recent", Description: "Favours recent entries with quarter half-lives", ConfidenceWeight: 0.5, HalfLifeDays: recent, BuiltIn: true},
+	}
+}
+
+// RankingStore provides named ranking profiles. Profiles saved in Postgres
+// override built-in profiles of the same name.
+type RankingStore struct {
+	db       *DB
+	builtIns map[string]RankingProfile
+}
+
+// NewRankingStore creates a new RankingStore whose built-in profiles use
+// halfLives.
+func NewRankingStore(db *DB, halfLives HalfLives) *RankingStore {
+	builtIns := make(map[string]RankingProfile)
+	for _, p := range BuiltInProfiles(halfLives) {
+		builtIns[p.Name] = p
+	}
+	return &RankingStore{db: db, builtIns: builtIns}
+}
+
+// Default returns the built-in default profile, without consulting Postgres.
+func (s *RankingStore) Default() *RankingProfile {
+	p := s.builtIns[DefaultProfileName]
+	return &p
+}
+
+// Get returns the named profile; an empty name means the default.
+func (s *RankingStore) Get(ctx context.Context, name string) (*RankingProfile, error) {
+	if name == "" {
+		name = DefaultProfileName
+	}
+	var raw []byte
+	var updatedAt time.Time
+	err := s.db.Pool.QueryRow(ctx,
+		"SELECT profile, updated_at FROM vault_ranking_profiles WHERE name = $1", name,
+	).Scan(&raw, &updatedAt)
+	if err == pgx.ErrNoRows {
+		if p, ok := s.builtIns[name]; ok {
+			return &p, nil
+		}
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting ranking profile: %w", err)
+	}
+	return decodeProfile(name, raw, updatedAt)
+}
+
+// List returns every profile, saved and built-in, by name.
+func (s *RankingStore) List(ctx context.Context) ([]RankingProfile, error) {
+	rows, err := s.db.Pool.Query(ctx, "SELECT name, profile, updated_at FROM vault_ranking_profiles")
+	if err != nil {
+		return nil, fmt.Errorf("listing ranking profiles: %w", err)
+	}
+	defer rows.Close()
+
+	byName := make(map[string]RankingProfile, len(s.builtIns))
+	for name, p := range s.builtIns {
+		byName[name] = p
+	}
+	for rows.Next() {
+		var name string
+		var raw []byte
+		var updatedAt time.Time
+		if err := rows.Scan(&name, &raw, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scanning ranking profile: %w", err)
+		}
+		p, err := decodeProfile(name, raw, updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		byName[name] = *p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	profiles := make([]RankingProfile, 0, len(byName))
+	for _, p := range byName {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// Put validates and saves p, replacing any saved profile of the same name.
+// A profile without half-lives gets the configured ones.
+func (s *RankingStore) Put(ctx context.Context, p RankingProfile, updatedBy string) (*RankingProfile, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	p.BuiltIn = false
+	p.UpdatedAt = nil
+	if p.HalfLifeDays == nil {
+		p.HalfLifeDays = s.builtIns[DefaultProfileName].HalfLifeDays
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("encoding ranking profile: %w", err)
+	}
+
+	var updatedAt time.Time
+	err = s.db.Pool.QueryRow(ctx, `
+		INSERT INTO vault_ranking_profiles (name, profile, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET profile = EXCLUDED.profile, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at`,
+		p.Name, raw, updatedBy,
+	).Scan(&updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("saving ranking profile: %w", err)
+	}
+	p.UpdatedAt = &updatedAt
+	return &p, nil
+}
+
+// Delete removes a saved profile. A built-in profile it overrode comes back.
+func (s *RankingStore) Delete(ctx context.Context, name string) error {
+	tag, err := s.db.Pool.Exec(ctx, "DELETE FROM vault_ranking_profiles WHERE name = $1", name)
+	if err != nil {
+		return fmt.Errorf("deleting ranking profile: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProfileNotFound
+	}
+	return nil
+}
+
+func decodeProfile(name string, raw []byte, updatedAt time.Time) (*RankingProfile, error) {
+	p := &RankingProfile{}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return nil, fmt.Errorf("decoding ranking profile %q: %w", name, err)
+	}
+	p.Name = name
+	p.UpdatedAt = &updatedAt
+	return p, nil
+}
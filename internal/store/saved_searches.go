@@ -0,0 +1,415 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+var (
+	// ErrSavedSearchNotFound is returned when a saved search does not exist
+	// or belongs to another agent.
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	// ErrInvalidSavedSearch is returned for a malformed saved search.
+	ErrInvalidSavedSearch = errors.New("invalid saved search")
+	// ErrSavedSearchExists is returned when the agent already has a saved
+	// search of the same name.
+	ErrSavedSearchExists = errors.New("saved search name already in use")
+)
+
+// SavedSearch is a search an agent wants to be told about: every new
+// knowledge entry the agent may see is checked against it, and matches are
+// published to swarm.vault.watch.<agent>.matched and the optional webhook.
+type SavedSearch struct {
+	ID           string              `json:"id"`
+	AgentID      string              `json:"agent_id"`
+	Name         string              `json:"name"`
+	Query        string              `json:"query"`
+	Mode         SearchMode          `json:"mode"`
+	Scope        *KnowledgeScope     `json:"scope,omitempty"`
+	Categories   []KnowledgeCategory `json:"categories,omitempty"`
+	MinRelevance float64             `json:"min_relevance,omitempty"`
+	WebhookURL   *string             `json:"webhook_url,omitempty"`
+	// WebhookSecret signs webhook deliveries; it is never returned.
+	WebhookSecret  *string    `json:"-"`
+	HasSecret      bool       `json:"has_webhook_secret"`
+	Enabled        bool       `json:"enabled"`
+	EmbeddingModel string     `json:"-"`
+	MatchCount     int64      `json:"match_count"`
+	LastMatchedAt  *time.Time `json:"last_matched_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// SavedSearchInput creates or replaces a saved search.
+type SavedSearchInput struct {
+	Name          string              `json:"name"`
+	Query         string              `json:"query"`
+	Mode          SearchMode          `json:"mode,omitempty"`
+	Scope         *KnowledgeScope     `json:"scope,omitempty"`
+	Categories    []KnowledgeCategory `json:"categories,omitempty"`
+	MinRelevance  float64             `json:"min_relevance,omitempty"`
+	WebhookURL    *string             `json:"webhook_url,omitempty"`
+	WebhookSecret *string             `json:"webhook_secret,omitempty"`
+	Enabled       *bool               `json:"enabled,omitempty"`
+
+	QueryEmbedding pgvector.Vector `json:"-"`
+	EmbeddingModel string          `json:"-"`
+}
+
+// Validate checks the input and fills in the default mode.
+func (in *SavedSearchInput) Validate() error {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Query = strings.TrimSpace(in.Query)
+	if in.Name == "" || len(in.Name) > 100 {
+		return fmt.Errorf("%w: name is required and at most 100 characters", ErrInvalidSavedSearch)
+	}
+	if in.Query == "" {
+		return fmt.Errorf("%w: query is required", ErrInvalidSavedSearch)
+	}
+	switch in.Mode {
+	case "":
+		in.Mode = SearchModeVector
+	case SearchModeVector, SearchModeLexical, SearchModeHybrid:
+	default:
+		return fmt.Errorf("%w: mode must be 'vector', 'lexical', or 'hybrid'", ErrInvalidSavedSearch)
+	}
+	if in.Scope != nil {
+		switch *in.Scope {
+		case ScopePublic, ScopePrivate, ScopeShared:
+		default:
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidSavedSearch, *in.Scope)
+		}
+	}
+	for _, c := range in.Categories {
+		switch c {
+		case CategoryDiscovery, CategoryLesson, CategoryPreference, CategoryFact,
+			CategoryEvent, CategoryDecision, CategoryRelationship:
+		default:
+			return fmt.Errorf("%w: unknown category %q", ErrInvalidSavedSearch, c)
+		}
+	}
+	if in.MinRelevance < 0 || in.MinRelevance > 1 {
+		return fmt.Errorf("%w: min_relevance must be between 0 and 1", ErrInvalidSavedSearch)
+	}
+	if in.WebhookURL != nil && *in.WebhookURL != "" {
+		u, err := url.Parse(*in.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook_url must be an absolute http or https URL", ErrInvalidSavedSearch)
+		}
+		// Names are checked again when delivering, once they resolve.
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return fmt.Errorf("%w: webhook_url must not point at this host", ErrInvalidSavedSearch)
+		}
+		if addr, err := netip.ParseAddr(host); err == nil && !PublicAddr(addr) {
+			return fmt.Errorf("%w: webhook_url must be a public address", ErrInvalidSavedSearch)
+		}
+	}
+	return nil
+}
+
+// nonPublicPrefixes are reserved ranges netip has no predicate for.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+}
+
+// PublicAddr reports whether addr is a public unicast address: not
+// loopback, private, link-local (which includes cloud metadata endpoints),
+// multicast, unspecified or otherwise reserved. Webhooks are only delivered
+// to public addresses.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// SavedSearchCandidate is a saved search that may match an entry: the
+// entry passes its filters and is visible to its owner. Cosine is nil when
+// either side has no embedding from the same model.
+type SavedSearchCandidate struct {
+	Search  SavedSearch
+	Cosine  *float64
+	Lexical bool
+}
+
+// Matches reports whether the entry scored by c satisfies the saved
+// search, and the score to report. Vector matches need a cosine similarity
+// of at least min_relevance, or the search default when it is unset;
+// lexical matches need every query term; hybrid takes either.
+func (c SavedSearchCandidate) Matches() (float64, bool) {
+	threshold := c.Search.MinRelevance
+	if threshold <= 0 {
+		threshold = defaultMinSimilarity
+	}
+	var score float64
+	vector := false
+	if c.Cosine != nil {
+		score = *c.Cosine
+		vector = score >= threshold
+	}
+	switch c.Search.Mode {
+	case SearchModeLexical:
+		return score, c.Lexical
+	case SearchModeHybrid:
+		return score, vector || c.Lexical
+	default:
+		return score, vector
+	}
+}
+
+// SavedSearchStore handles saved search persistence.
+type SavedSearchStore struct {
+	db *DB
+}
+
+// NewSavedSearchStore creates a new SavedSearchStore.
+func NewSavedSearchStore(db *DB) *SavedSearchStore {
+	return &SavedSearchStore{db: db}
+}
+
+const savedSearchColumns = `id, agent_id, name, query, mode, scope, categories, min_relevance,
+	webhook_url, webhook_secret, enabled, COALESCE(embedding_model, ''), match_count, last_matched_at, created_at, updated_at`
+
+func savedSearchScanDest(s *SavedSearch) []any {
+	return []any{&s.ID, &s.AgentID, &s.Name, &s.Query, &s.Mode, &s.Scope, &s.Categories, &s.MinRelevance,
+		&s.WebhookURL, &s.WebhookSecret, &s.Enabled, &s.EmbeddingModel, &s.MatchCount, &s.LastMatchedAt, &s.CreatedAt, &s.UpdatedAt}
+}
+
+func scanSavedSearch(row pgx.Row) (*SavedSearch, error) {
+	s := &SavedSearch{}
+	if err := row.Scan(savedSearchScanDest(s)...); err != nil {
+		return nil, err
+	}
+	s.HasSecret = s.WebhookSecret != nil && *s.WebhookSecret != ""
+	return s, nil
+}
+
+// savedSearchArgs returns input's column values, with an empty webhook URL
+// or secret stored as NULL and the search enabled unless it says otherwise.
+func savedSearchArgs(input SavedSearchInput) []any {
+	enabled := input.Enabled == nil || *input.Enabled
+	categories := make([]string, len(input.Categories))
+	for i, c := range input.Categories {
+		categories[i] = string(c)
+	}
+	var embedding any
+	if input.QueryEmbedding.Slice() != nil {
+		embedding = input.QueryEmbedding
+	}
+	return []any{input.Name, input.Query, input.Mode, input.Scope, categories, input.MinRelevance,
+		nullIfEmpty(input.WebhookURL), nullIfEmpty(input.WebhookSecret), enabled, embedding, input.EmbeddingModel}
+}
+
+func nullIfEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// Create saves a new search owned by agentID.
+func (s *SavedSearchStore) Create(ctx context.Context, agentID string, input SavedSearchInput) (*SavedSearch, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	args := append([]any{agentID}, savedSearchArgs(input)...)
+	search, err := scanSavedSearch(s.db.Pool.QueryRow(ctx, `
+		INSERT INTO vault_saved_searches (agent_id, name, query, mode, scope, categories, min_relevance,
+			webhook_url, webhook_secret, enabled, query_embedding, embedding_model)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+		RETURNING `+savedSearchColumns, args...))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: %q", ErrSavedSearchExists, input.Name)
+		}
+		return nil, fmt.Errorf("creating saved search: %w", err)
+	}
+	return search, nil
+}
+
+// Get returns the saved search id if agentID owns it.
+func (s *SavedSearchStore) Get(ctx context.Context, id, agentID string) (*SavedSearch, error) {
+	search, err := scanSavedSearch(s.db.Pool.QueryRow(ctx,
+		"SELECT "+savedSearchColumns+" FROM vault_saved_searches WHERE id = $1 AND agent_id = $2", id, agentID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting saved search: %w", err)
+	}
+	return search, nil
+}
+
+// List returns agentID's saved searches by name.
+func (s *SavedSearchStore) List(ctx context.Context, agentID string) ([]SavedSearch, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		"SELECT "+savedSearchColumns+" FROM vault_saved_searches WHERE agent_id = $1 ORDER BY name", agentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning saved search: %w", err)
+		}
+		searches = append(searches, *search)
+	}
+	return searches, rows.Err()
+}
+
+// Update replaces the saved search id owned by agentID. A nil webhook
+// secret keeps the current one; an empty one clears it.
+func (s *SavedSearchStore) Update(ctx context.Context, id, agentID string, input SavedSearchInput) (*SavedSearch, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	keepSecret := input.WebhookSecret == nil
+	args := append([]any{id, agentID}, savedSearchArgs(input)...)
+	args = append(args, keepSecret)
+	search, err := scanSavedSearch(s.db.Pool.QueryRow(ctx, `
+		UPDATE vault_saved_searches SET
+			name = $3, query = $4, mode = $5, scope = $6, categories = $7, min_relevance = $8,
+			webhook_url = $9, webhook_secret = CASE WHEN $14 THEN webhook_secret ELSE $10 END,
+			enabled = $11, query_embedding = $12, embedding_model = NULLIF($13, ''), updated_at = NOW()
+		WHERE id = $1 AND agent_id = $2
+		RETURNING `+savedSearchColumns, args...))
+	if err == pgx.ErrNoRows {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: %q", ErrSavedSearchExists, input.Name)
+		}
+		return nil, fmt.Errorf("updating saved search: %w", err)
+	}
+	return search, nil
+}
+
+// Delete removes the saved search id owned by agentID.
+func (s *SavedSearchStore) Delete(ctx context.Context, id, agentID string) error {
+	tag, err := s.db.Pool.Exec(ctx, "DELETE FROM vault_saved_searches WHERE id = $1 AND agent_id = $2", id, agentID)
+	if err != nil {
+		return fmt.Errorf("deleting saved search: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// Candidates returns the enabled saved searches that knowledge entry
+// entryID passes the filters of, scored against it. An entry is only
+// offered to searches whose owner may see it and did not write it;
+// superseded, deleted and expired entries are offered to none.
+func (s *SavedSearchStore) Candidates(ctx context.Context, entryID string) ([]SavedSearchCandidate, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		WITH entry AS (
+			SELECT embedding AS e_embedding, embedding_model AS e_model, search_tsv AS e_tsv,
+			       source_agent AS e_agent, scope::TEXT AS e_scope, shared_with AS e_shared,
+			       category::TEXT AS e_category
+			FROM vault_knowledge
+			WHERE id = $1 AND deleted_at IS NULL AND superseded_by IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+		)
+		SELECT `+savedSearchColumns+`,
+		       CASE WHEN query_embedding IS NOT NULL AND e_embedding IS NOT NULL
+		                 AND embedding_model IS NOT DISTINCT FROM e_model
+		            THEN (1 - (e_embedding <=> query_embedding))::FLOAT END,
+		       COALESCE(e_tsv @@ websearch_to_tsquery('simple', query), FALSE)
+		FROM vault_saved_searches, entry
+		WHERE enabled
+		AND agent_id <> e_agent
+		AND (e_scope = 'public' OR (e_scope = 'shared' AND agent_id = ANY(e_shared)))
+		AND (scope IS NULL OR scope = e_scope)
+		AND (cardinality(categories) = 0 OR e_category = ANY(categories))`,
+		entryID)
+	if err != nil {
+		return nil, fmt.Errorf("listing saved search candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []SavedSearchCandidate
+	for rows.Next() {
+		var c SavedSearchCandidate
+		dest := append(savedSearchScanDest(&c.Search), &c.Cosine, &c.Lexical)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("scanning saved search candidate: %w", err)
+		}
+		c.Search.HasSecret = c.Search.WebhookSecret != nil && *c.Search.WebhookSecret != ""
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// ListStaleQueries returns enabled saved searches whose query embedding is
+// missing or was made by a model other than model.
+func (s *SavedSearchStore) ListStaleQueries(ctx context.Context, model string) ([]SavedSearch, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT `+savedSearchColumns+` FROM vault_saved_searches
+		WHERE enabled AND mode <> 'lexical'
+		AND (query_embedding IS NULL OR embedding_model IS DISTINCT FROM $1)`,
+		model)
+	if err != nil {
+		return nil, fmt.Errorf("listing stale saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []SavedSearch
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning saved search: %w", err)
+		}
+		searches = append(searches, *search)
+	}
+	return searches, rows.Err()
+}
+
+// SetQueryEmbedding stores the embedding of a saved search's query, unless
+// the query has changed since it was read.
+func (s *SavedSearchStore) SetQueryEmbedding(ctx context.Context, id, query string, embedding pgvector.Vector, model string) error {
+	_, err := s.db.Pool.Exec(ctx,
+		"UPDATE vault_saved_searches SET query_embedding = $3, embedding_model = $4 WHERE id = $1 AND query = $2",
+		id, query, embedding, model)
+	if err != nil {
+		return fmt.Errorf("storing saved search embedding: %w", err)
+	}
+	return nil
+}
+
+// RecordMatch counts a match of the saved search id.
+func (s *SavedSearchStore) RecordMatch(ctx context.Context, id string) error {
+	_, err := s.db.Pool.Exec(ctx,
+		"UPDATE vault_saved_searches SET match_count = match_count + 1, last_matched_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("recording saved search match: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
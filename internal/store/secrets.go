@@ -6,41 +6,56 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
 )
 
 // Secret represents a stored encrypted secret.
 type Secret struct {
-	ID                   string     `json:"id"`
-	Name                 string     `json:"name"`
-	EncryptedValue       string     `json:"-"`
-	Description          *string    `json:"description,omitempty"`
-	Scope                []string   `json:"scope"`
-	RotationIntervalDays *int       `json:"rotation_interval_days,omitempty"`
-	LastRotatedAt        *time.Time `json:"last_rotated_at,omitempty"`
-	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
-	CreatedBy            string     `json:"created_by"`
-	OwnerType            *string    `json:"owner_type,omitempty"`
-	OwnerID              *string    `json:"owner_id,omitempty"`
-	AgentID              *string    `json:"agent_id,omitempty"` // For backward compatibility
-	CreatedAt            time.Time  `json:"created_at"`
-	UpdatedAt            time.Time  `json:"updated_at"`
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name"`
+	EncryptedValue       string         `json:"-"`
+	WrappedKey           string         `json:"-"`
+	KeyID                string         `json:"key_id,omitempty"` // master key wrapping the data key; empty for legacy values
+	Version              int            `json:"version"`          // bumped by every update, rotation and rollback
+	Description          *string        `json:"description,omitempty"`
+	Scope                []string       `json:"scope"`
+	RotationIntervalDays *int           `json:"rotation_interval_days,omitempty"`
+	RotationGenerator    *string        `json:"rotation_generator,omitempty"` // produces the value for scheduled rotation
+	RotationOptions      map[string]any `json:"rotation_options,omitempty"`
+	LastRotatedAt        *time.Time     `json:"last_rotated_at,omitempty"`
+	ExpiresAt            *time.Time     `json:"expires_at,omitempty"`
+	CreatedBy            string         `json:"created_by"`
+	OwnerType            *string        `json:"owner_type,omitempty"`
+	OwnerID              *string        `json:"owner_id,omitempty"`
+	AgentID              *string        `json:"agent_id,omitempty"` // For backward compatibility
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+// Sealed returns the secret's encrypted value.
+func (s *Secret) Sealed() encryption.Sealed {
+	return encryption.Sealed{Ciphertext: s.EncryptedValue, WrappedKey: s.WrappedKey, KeyID: s.KeyID}
 }
 
 // SecretCreateInput is the input for creating a secret.
 type SecretCreateInput struct {
-	Name                 string   `json:"name"`
-	EncryptedValue       string   `json:"-"`
-	Description          *string  `json:"description,omitempty"`
-	Scope                []string `json:"scope"`
-	RotationIntervalDays *int     `json:"rotation_interval_days,omitempty"`
-	CreatedBy            string   `json:"created_by"`
-	OwnerType            *string  `json:"owner_type,omitempty"`
-	OwnerID              *string  `json:"owner_id,omitempty"`
+	Name                 string            `json:"name"`
+	Value                encryption.Sealed `json:"-"`
+	Description          *string           `json:"description,omitempty"`
+	Scope                []string          `json:"scope"`
+	RotationIntervalDays *int              `json:"rotation_interval_days,omitempty"`
+	RotationGenerator    *string           `json:"rotation_generator,omitempty"`
+	RotationOptions      map[string]any    `json:"rotation_options,omitempty"`
+	CreatedBy            string            `json:"created_by"`
+	OwnerType            *string           `json:"owner_type,omitempty"`
+	OwnerID              *string           `json:"owner_id,omitempty"`
 }
 
 // SecretStore provides secret CRUD operations.
 type SecretStore struct {
-	db *DB
+	db           *DB
+	historyLimit int // previous versions kept per secret; 0 keeps all
 }
 
 // NewSecretStore creates a new SecretStore.
@@ -51,9 +66,9 @@ func NewSecretStore(db *DB) *SecretStore {
 // Create inserts a new secret.
 func (s *SecretStore) Create(ctx context.Context, input SecretCreateInput) (*Secret, error) {
 	query := `
-		INSERT INTO vault_secrets (name, encrypted_value, description, scope, rotation_interval_days, created_by, owner_type, owner_id, agent_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, name, description, scope, rotation_interval_days, last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at`
+		INSERT INTO vault_secrets (name, encrypted_value, wrapped_key, key_id, description, scope, rotation_interval_days, rotation_generator, rotation_options, created_by, owner_type, owner_id, agent_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, COALESCE($9::jsonb, '{}'), $10, $11, $12, $13)
+		RETURNING id, name, version, description, scope, rotation_interval_days, rotation_generator, rotation_options, last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at`
 
 	// For backward compatibility, if owner_type is 'agent', also set agent_id
 	var agentID *string
@@ -61,13 +76,17 @@ func (s *SecretStore) Create(ctx context.Context, input SecretCreateInput) (*Sec
 		agentID = input.OwnerID
 	}
 
-	secret := &Secret{EncryptedValue: input.EncryptedValue}
+	secret := &Secret{
+		EncryptedValue: input.Value.Ciphertext,
+		WrappedKey:     input.Value.WrappedKey,
+		KeyID:          input.Value.KeyID,
+	}
 	err := s.db.Pool.QueryRow(ctx, query,
-		input.Name, input.EncryptedValue, input.Description, input.Scope,
-		input.RotationIntervalDays, input.CreatedBy, input.OwnerType, input.OwnerID, agentID,
+		input.Name, input.Value.Ciphertext, input.Value.WrappedKey, input.Value.KeyID, input.Description, input.Scope,
+		input.RotationIntervalDays, input.RotationGenerator, input.RotationOptions, input.CreatedBy, input.OwnerType, input.OwnerID, agentID,
 	).Scan(
-		&secret.ID, &secret.Name, &secret.Description, &secret.Scope,
-		&secret.RotationIntervalDays, &secret.LastRotatedAt, &secret.ExpiresAt,
+		&secret.ID, &secret.Name, &secret.Version, &secret.Description, &secret.Scope,
+		&secret.RotationIntervalDays, &secret.RotationGenerator, &secret.RotationOptions, &secret.LastRotatedAt, &secret.ExpiresAt,
 		&secret.CreatedBy, &secret.OwnerType, &secret.OwnerID, &secret.AgentID,
 		&secret.CreatedAt, &secret.UpdatedAt,
 	)
@@ -80,14 +99,14 @@ func (s *SecretStore) Create(ctx context.Context, input SecretCreateInput) (*Sec
 // GetByName retrieves a secret by name. Returns encrypted value.
 func (s *SecretStore) GetByName(ctx context.Context, name string) (*Secret, error) {
 	query := `
-		SELECT id, name, encrypted_value, description, scope, rotation_interval_days,
-		       last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at
-		FROM vault_secrets WHERE name = $1`
+		SELECT id, name, encrypted_value, COALESCE(wrapped_key, ''), COALESCE(key_id, ''), version, description, scope, rotation_interval_days,
+		       rotation_generator, rotation_options, last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at
+		FROM vault_secrets WHERE name = $1 AND name <> $2`
 
 	secret := &Secret{}
-	err := s.db.Pool.QueryRow(ctx, query, name).Scan(
-		&secret.ID, &secret.Name, &secret.EncryptedValue, &secret.Description,
-		&secret.Scope, &secret.RotationIntervalDays, &secret.LastRotatedAt,
+	err := s.db.Pool.QueryRow(ctx, query, name, CanarySecretName).Scan(
+		&secret.ID, &secret.Name, &secret.EncryptedValue, &secret.WrappedKey, &secret.KeyID, &secret.Version, &secret.Description,
+		&secret.Scope, &secret.RotationIntervalDays, &secret.RotationGenerator, &secret.RotationOptions, &secret.LastRotatedAt,
 		&secret.ExpiresAt, &secret.CreatedBy, &secret.OwnerType, &secret.OwnerID,
 		&secret.AgentID, &secret.CreatedAt, &secret.UpdatedAt,
 	)
@@ -103,11 +122,11 @@ func (s *SecretStore) GetByName(ctx context.Context, name string) (*Secret, erro
 // List returns all secrets (without values).
 func (s *SecretStore) List(ctx context.Context) ([]Secret, error) {
 	query := `
-		SELECT id, name, description, scope, rotation_interval_days,
-		       last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at
-		FROM vault_secrets ORDER BY name`
+		SELECT id, name, COALESCE(key_id, ''), version, description, scope, rotation_interval_days,
+		       rotation_generator, rotation_options, last_rotated_at, expires_at, created_by, owner_type, owner_id, agent_id, created_at, updated_at
+		FROM vault_secrets WHERE name <> $1 ORDER BY name`
 
-	rows, err := s.db.Pool.Query(ctx, query)
+	rows, err := s.db.Pool.Query(ctx, query, CanarySecretName)
 	if err != nil {
 		return nil, fmt.Errorf("listing secrets: %w", err)
 	}
@@ -117,8 +136,8 @@ func (s *SecretStore) List(ctx context.Context) ([]Secret, error) {
 	for rows.Next() {
 		var s Secret
 		if err := rows.Scan(
-			&s.ID, &s.Name, &s.Description, &s.Scope, &s.RotationIntervalDays,
-			&s.LastRotatedAt, &s.ExpiresAt, &s.CreatedBy, &s.OwnerType, &s.OwnerID,
+			&s.ID, &s.Name, &s.KeyID, &s.Version, &s.Description, &s.Scope, &s.RotationIntervalDays,
+			&s.RotationGenerator, &s.RotationOptions, &s.LastRotatedAt, &s.ExpiresAt, &s.CreatedBy, &s.OwnerType, &s.OwnerID,
 			&s.AgentID, &s.CreatedAt, &s.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scanning secret: %w", err)
@@ -128,12 +147,41 @@ func (s *SecretStore) List(ctx context.Context) ([]Secret, error) {
 	return secrets, rows.Err()
 }
 
-// Update updates a secret's encrypted value.
-func (s *SecretStore) Update(ctx context.Context, name, encryptedValue string) error {
-	_, err := s.db.Pool.Exec(ctx,
-		"UPDATE vault_secrets SET encrypted_value = $1 WHERE name = $2",
-		encryptedValue, name)
-	return err
+// Update replaces a secret's value, keeping the old one in history and
+// bumping the version as Rotate does. Unlike Rotate it leaves
+// last_rotated_at, and so the rotation schedule, alone.
+func (s *SecretStore) Update(ctx context.Context, name string, value encryption.Sealed, updatedBy string) error {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var secretID string
+	err = tx.QueryRow(ctx,
+		"SELECT id FROM vault_secrets WHERE name = $1 FOR UPDATE", name,
+	).Scan(&secretID)
+	if err != nil {
+		return fmt.Errorf("getting current secret: %w", err)
+	}
+
+	if err := snapshotSecretVersion(ctx, tx, secretID, updatedBy); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE vault_secrets SET encrypted_value = $1, wrapped_key = NULLIF($2, ''), key_id = NULLIF($3, ''),
+		       version = version + 1
+		WHERE id = $4`,
+		value.Ciphertext, value.WrappedKey, value.KeyID, secretID)
+	if err != nil {
+		return fmt.Errorf("updating secret: %w", err)
+	}
+
+	if err := s.pruneHistory(ctx, tx, secretID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 // Delete removes a secret.
@@ -149,7 +197,13 @@ func (s *SecretStore) Delete(ctx context.Context, name string) error {
 }
 
 // Rotate stores the old value in history and updates with new value.
-func (s *SecretStore) Rotate(ctx context.Context, name, newEncryptedValue, rotatedBy string) error {
+func (s *SecretStore) Rotate(ctx context.Context, name string, newValue encryption.Sealed, rotatedBy string) error {
+	return s.rotate(ctx, name, newValue, rotatedBy, nil)
+}
+
+// rotate is Rotate, skipped with ErrSecretChanged if expect is set and the
+// secret's version or last rotation no longer match it.
+func (s *SecretStore) rotate(ctx context.Context, name string, newValue encryption.Sealed, rotatedBy string, expect *RotationDue) error {
 	tx, err := s.db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
@@ -157,30 +211,37 @@ func (s *SecretStore) Rotate(ctx context.Context, name, newEncryptedValue, rotat
 	defer func() { _ = tx.Rollback(ctx) }()
 
 	// Get current secret
-	var secretID, oldValue string
+	var secretID string
+	var version int
+	var lastRotated *time.Time
 	err = tx.QueryRow(ctx,
-		"SELECT id, encrypted_value FROM vault_secrets WHERE name = $1", name,
-	).Scan(&secretID, &oldValue)
+		"SELECT id, version, last_rotated_at FROM vault_secrets WHERE name = $1 FOR UPDATE", name,
+	).Scan(&secretID, &version, &lastRotated)
 	if err != nil {
 		return fmt.Errorf("getting current secret: %w", err)
 	}
+	if expect != nil && (version != expect.Version || !sameTime(lastRotated, expect.LastRotatedAt)) {
+		return ErrSecretChanged
+	}
 
 	// Save old value to history
-	_, err = tx.Exec(ctx,
-		"INSERT INTO vault_secret_history (secret_id, encrypted_value, rotated_by) VALUES ($1, $2, $3)",
-		secretID, oldValue, rotatedBy)
-	if err != nil {
-		return fmt.Errorf("saving history: %w", err)
+	if err := snapshotSecretVersion(ctx, tx, secretID, rotatedBy); err != nil {
+		return err
 	}
 
 	// Update with new value
-	_, err = tx.Exec(ctx,
-		"UPDATE vault_secrets SET encrypted_value = $1, last_rotated_at = NOW() WHERE name = $2",
-		newEncryptedValue, name)
+	_, err = tx.Exec(ctx, `
+		UPDATE vault_secrets SET encrypted_value = $1, wrapped_key = NULLIF($2, ''), key_id = NULLIF($3, ''),
+		       version = version + 1, last_rotated_at = NOW()
+		WHERE id = $4`,
+		newValue.Ciphertext, newValue.WrappedKey, newValue.KeyID, secretID)
 	if err != nil {
 		return fmt.Errorf("updating secret: %w", err)
 	}
 
+	if err := s.pruneHistory(ctx, tx, secretID); err != nil {
+		return err
+	}
 	return tx.Commit(ctx)
 }
 
@@ -212,6 +273,6 @@ func (s *SecretStore) CanAccess(secret *Secret, agentID string) bool {
 // Count returns the total number of secrets.
 func (s *SecretStore) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := s.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM vault_secrets").Scan(&count)
+	err := s.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM vault_secrets WHERE name <> $1", CanarySecretName).Scan(&count)
 	return count, err
 }
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+)
+
+// CanarySecretName names the vault_secrets row the startup self-test
+// decrypts. It is hidden from the secrets API, counts and listings.
+const CanarySecretName = "alexandria.encryption-canary"
+
+// canaryPlaintext is the known value sealed in the canary row.
+const canaryPlaintext = "alexandria encryption canary"
+
+// ErrKeyMismatch is returned by CheckCanary when stored secrets do not
+// decrypt with the configured keys.
+var ErrKeyMismatch = errors.New("encryption keys cannot decrypt stored secrets")
+
+// Canary self-test outcomes.
+const (
+	CanaryVerified = "verified" // the canary decrypted
+	CanaryCreated  = "created"  // no canary yet; the newest secret, if any, decrypted
+)
+
+// CheckCanary verifies that enc can decrypt the canary row. Without a
+// canary it decrypts the most recently updated secret instead, if there is
+// one, and then writes the canary. It fails with ErrKeyMismatch if either
+// does not decrypt.
+func (s *SecretStore) CheckCanary(ctx context.Context, enc encryption.Encryptor) (string, error) {
+	var canary Secret
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT encrypted_value, COALESCE(wrapped_key, ''), COALESCE(key_id, '')
+		FROM vault_secrets WHERE name = $1`,
+		CanarySecretName,
+	).Scan(&canary.EncryptedValue, &canary.WrappedKey, &canary.KeyID)
+	if err == nil {
+		value, err := enc.Open(ctx, canary.Sealed())
+		if err != nil {
+			return "", fmt.Errorf("%w: canary does not decrypt: %v", ErrKeyMismatch, err)
+		}
+		if value != canaryPlaintext {
+			return "", fmt.Errorf("%w: canary decrypts to the wrong value", ErrKeyMismatch)
+		}
+		return CanaryVerified, nil
+	}
+	if err != pgx.ErrNoRows {
+		return "", fmt.Errorf("reading encryption canary: %w", err)
+	}
+
+	var newest Secret
+	err = s.db.Pool.QueryRow(ctx, `
+		SELECT name, encrypted_value, COALESCE(wrapped_key, ''), COALESCE(key_id, '')
+		FROM vault_secrets ORDER BY updated_at DESC LIMIT 1`,
+	).Scan(&newest.Name, &newest.EncryptedValue, &newest.WrappedKey, &newest.KeyID)
+	switch {
+	case err == nil:
+		if _, err := enc.Open(ctx, newest.Sealed()); err != nil {
+			return "", fmt.Errorf("%w: secret %q does not decrypt: %v", ErrKeyMismatch, newest.Name, err)
+		}
+	case err != pgx.ErrNoRows:
+		return "", fmt.Errorf("reading newest secret: %w", err)
+	}
+
+	sealed, err := enc.Seal(ctx, canaryPlaintext)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.Pool.Exec(ctx, `
+		INSERT INTO vault_secrets (name, encrypted_value, wrapped_key, key_id, description, created_by)
+		VALUES ($1, $2, $3, $4, 'Encryption self-test; do not delete', 'alexandria')
+		ON CONFLICT (name) DO NOTHING`,
+		CanarySecretName, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyID)
+	if err != nil {
+		return "", fmt.Errorf("writing encryption canary: %w", err)
+	}
+	return CanaryCreated, nil
+}
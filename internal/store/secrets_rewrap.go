@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+)
+
+// CiphertextTable names a table holding sealed secret values.
+type CiphertextTable string
+
+const (
+	CiphertextSecrets CiphertextTable = "vault_secrets"
+	CiphertextHistory CiphertextTable = "vault_secret_history"
+)
+
+// CiphertextTables lists every table a re-wrap job must cover.
+var CiphertextTables = []CiphertextTable{CiphertextSecrets, CiphertextHistory}
+
+// nameSQL selects the secret name for a row of t.
+func (t CiphertextTable) nameSQL() string {
+	if t == CiphertextHistory {
+		return "(SELECT name FROM vault_secrets s WHERE s.id = t.secret_id)"
+	}
+	return "t.name"
+}
+
+// SealedRow is one sealed value awaiting re-wrap.
+type SealedRow struct {
+	Table CiphertextTable
+	ID    string
+	Name  string // the secret's name
+	Value encryption.Sealed
+}
+
+// CountRewrap counts the sealed values, current and historical, not
+// wrapped by the master key keyID.
+func (s *SecretStore) CountRewrap(ctx context.Context, keyID string) (int64, error) {
+	var count int64
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM vault_secrets WHERE key_id IS DISTINCT FROM $1)
+		     + (SELECT COUNT(*) FROM vault_secret_history WHERE key_id IS DISTINCT FROM $1)`,
+		keyID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("counting secrets to re-wrap: %w", err)
+	}
+	return count, nil
+}
+
+// ListRewrap returns up to limit rows of table not wrapped by keyID, in ID
+// order after afterID (empty for the first page).
+func (s *SecretStore) ListRewrap(ctx context.Context, table CiphertextTable, keyID, afterID string, limit int) ([]SealedRow, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var after any
+	if afterID != "" {
+		after = afterID
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT t.id, COALESCE(`+table.nameSQL()+`, ''), t.encrypted_value, COALESCE(t.wrapped_key, ''), COALESCE(t.key_id, '')
+		FROM `+string(table)+` t
+		WHERE t.key_id IS DISTINCT FROM $1 AND ($2::uuid IS NULL OR t.id > $2::uuid)
+		ORDER BY t.id
+		LIMIT $3`,
+		keyID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing secrets to re-wrap: %w", err)
+	}
+	defer rows.Close()
+
+	var sealed []SealedRow
+	for rows.Next() {
+		r := SealedRow{Table: table}
+		if err := rows.Scan(&r.ID, &r.Name, &r.Value.Ciphertext, &r.Value.WrappedKey, &r.Value.KeyID); err != nil {
+			return nil, fmt.Errorf("scanning secret to re-wrap: %w", err)
+		}
+		sealed = append(sealed, r)
+	}
+	return sealed, rows.Err()
+}
+
+// SetRewrapped replaces row's sealed value with value unless the row was
+// changed since it was listed, and reports whether it did.
+func (s *SecretStore) SetRewrapped(ctx context.Context, row SealedRow, value encryption.Sealed) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx, `
+		UPDATE `+string(row.Table)+`
+		SET encrypted_value = $2, wrapped_key = $3, key_id = $4
+		WHERE id = $1 AND encrypted_value = $5 AND key_id IS NOT DISTINCT FROM NULLIF($6, '')`,
+		row.ID, value.Ciphertext, value.WrappedKey, value.KeyID, row.Value.Ciphertext, row.Value.KeyID)
+	if err != nil {
+		return false, fmt.Errorf("storing re-wrapped secret: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
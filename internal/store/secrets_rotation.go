@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+)
+
+// rotationDueSQL is when a secret with a rotation interval next needs
+// rotating.
+const rotationDueSQL = `(COALESCE(last_rotated_at, created_at) + make_interval(days => rotation_interval_days))`
+
+// ErrSecretChanged means a secret was rotated or updated after it was
+// listed as due, so the scheduled rotation was skipped.
+var ErrSecretChanged = errors.New("secret changed since it was listed")
+
+// RotationDue is a secret whose rotation interval has elapsed.
+type RotationDue struct {
+	ID        string         `json:"-"`
+	Name      string         `json:"name"`
+	Generator string         `json:"generator,omitempty"` // empty if it must be rotated by hand
+	Options   map[string]any `json:"-"`
+	DueAt     time.Time      `json:"due_at"`
+
+	// Version and LastRotatedAt are as listed; RotateDue skips the secret
+	// if either has changed since.
+	Version       int        `json:"-"`
+	LastRotatedAt *time.Time `json:"-"`
+}
+
+// RotationPolicy is how and how often a secret is rotated on schedule.
+type RotationPolicy struct {
+	IntervalDays *int           `json:"rotation_interval_days"`
+	Generator    *string        `json:"rotation_generator"`
+	Options      map[string]any `json:"rotation_options"`
+}
+
+// ListRotationDue returns secrets whose rotation interval has elapsed,
+// longest overdue first. If after is set, the list resumes just past it, so
+// callers can page through every due secret. limit <= 0 returns all.
+func (s *SecretStore) ListRotationDue(ctx context.Context, after *RotationDue, limit int) ([]RotationDue, error) {
+	var lim any
+	if limit > 0 {
+		lim = limit
+	}
+	var afterDue *time.Time
+	var afterID *string
+	if after != nil {
+		afterDue, afterID = &after.DueAt, &after.ID
+	}
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT id, name, COALESCE(rotation_generator, ''), rotation_options, `+rotationDueSQL+`, version, last_rotated_at
+		FROM vault_secrets
+		WHERE rotation_interval_days IS NOT NULL AND rotation_interval_days > 0
+		  AND `+rotationDueSQL+` <= NOW() AND name <> $1
+		  AND ($3::timestamptz IS NULL OR (`+rotationDueSQL+`, id) > ($3, $4::uuid))
+		ORDER BY `+rotationDueSQL+`, id
+		LIMIT $2`,
+		CanarySecretName, lim, afterDue, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("listing secrets due for rotation: %w", err)
+	}
+	defer rows.Close()
+
+	var due []RotationDue
+	for rows.Next() {
+		var d RotationDue
+		if err := rows.Scan(&d.ID, &d.Name, &d.Generator, &d.Options, &d.DueAt, &d.Version, &d.LastRotatedAt); err != nil {
+			return nil, fmt.Errorf("scanning secret due for rotation: %w", err)
+		}
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// RotateDue rotates a secret listed by ListRotationDue, as Rotate does. If
+// the secret has been rotated or updated since it was listed, it is left
+// alone and ErrSecretChanged is returned.
+func (s *SecretStore) RotateDue(ctx context.Context, d RotationDue, newValue encryption.Sealed, rotatedBy string) error {
+	return s.rotate(ctx, d.Name, newValue, rotatedBy, &d)
+}
+
+// sameTime reports whether a and b are both nil or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// SetRotationPolicy replaces a secret's rotation interval, generator and
+// generator options.
+func (s *SecretStore) SetRotationPolicy(ctx context.Context, name string, policy RotationPolicy) error {
+	ct, err := s.db.Pool.Exec(ctx, `
+		UPDATE vault_secrets SET rotation_interval_days = $1, rotation_generator = $2, rotation_options = COALESCE($3::jsonb, '{}')
+		WHERE name = $4`,
+		policy.IntervalDays, policy.Generator, policy.Options, name)
+	if err != nil {
+		return fmt.Errorf("setting rotation policy: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("not found")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+)
+
+// SecretVersion is a value a secret held before an update, rotation or
+// rollback.
+type SecretVersion struct {
+	Version        int       `json:"version"`
+	EncryptedValue string    `json:"-"`
+	WrappedKey     string    `json:"-"`
+	KeyID          string    `json:"key_id,omitempty"`
+	RotatedAt      time.Time `json:"rotated_at"`
+	RotatedBy      string    `json:"rotated_by"` // agent whose change replaced this version
+}
+
+// Sealed returns the version's encrypted value.
+func (v *SecretVersion) Sealed() encryption.Sealed {
+	return encryption.Sealed{Ciphertext: v.EncryptedValue, WrappedKey: v.WrappedKey, KeyID: v.KeyID}
+}
+
+// SetHistoryLimit sets how many previous versions are kept per secret.
+// Older ones are pruned on the next update, rotation or rollback; 0 keeps
+// all.
+func (s *SecretStore) SetHistoryLimit(n int) {
+	s.historyLimit = n
+}
+
+// snapshotSecretVersion copies the current value of a secret into
+// vault_secret_history. rotatedBy is the agent whose change replaces it.
+func snapshotSecretVersion(ctx context.Context, tx pgx.Tx, secretID, rotatedBy string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO vault_secret_history (secret_id, version, encrypted_value, wrapped_key, key_id, rotated_by)
+		SELECT id, version, encrypted_value, wrapped_key, key_id, $2 FROM vault_secrets WHERE id = $1`,
+		secretID, rotatedBy)
+	if err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+	return nil
+}
+
+// pruneHistory deletes all but the newest historyLimit versions of a secret.
+func (s *SecretStore) pruneHistory(ctx context.Context, tx pgx.Tx, secretID string) error {
+	if s.historyLimit <= 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		DELETE FROM vault_secret_history
+		WHERE secret_id = $1 AND id NOT IN (
+			SELECT id FROM vault_secret_history WHERE secret_id = $1 ORDER BY version DESC LIMIT $2
+		)`,
+		secretID, s.historyLimit)
+	if err != nil {
+		return fmt.Errorf("pruning secret history: %w", err)
+	}
+	return nil
+}
+
+// ListVersions returns the previous versions of a secret, newest first,
+// without their values.
+func (s *SecretStore) ListVersions(ctx context.Context, secretID string) ([]SecretVersion, error) {
+	rows, err := s.db.Pool.Query(ctx, `
+		SELECT version, COALESCE(key_id, ''), rotated_at, rotated_by
+		FROM vault_secret_history
+		WHERE secret_id = $1
+		ORDER BY version DESC`, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("listing secret versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []SecretVersion
+	for rows.Next() {
+		var v SecretVersion
+		if err := rows.Scan(&v.Version, &v.KeyID, &v.RotatedAt, &v.RotatedBy); err != nil {
+			return nil, fmt.Errorf("scanning secret version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns a previous version of a secret with its encrypted
+// value. It returns nil, nil if there is no such version.
+func (s *SecretStore) GetVersion(ctx context.Context, secretID string, version int) (*SecretVersion, error) {
+	v := &SecretVersion{}
+	err := s.db.Pool.QueryRow(ctx, `
+		SELECT version, encrypted_value, COALESCE(wrapped_key, ''), COALESCE(key_id, ''), rotated_at, rotated_by
+		FROM vault_secret_history
+		WHERE secret_id = $1 AND version = $2`, secretID, version,
+	).Scan(&v.Version, &v.EncryptedValue, &v.WrappedKey, &v.KeyID, &v.RotatedAt, &v.RotatedBy)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting secret version: %w", err)
+	}
+	return v, nil
+}
+
+// Rollback restores a secret to a previous version as a new version. The
+// value being replaced is itself recorded, so a rollback can be undone. It
+// returns the new version number, or ErrVersionNotFound.
+func (s *SecretStore) Rollback(ctx context.Context, secretID string, version int, rolledBackBy string) (int, error) {
+	var newVersion int
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var current int
+		err := tx.QueryRow(ctx,
+			"SELECT version FROM vault_secrets WHERE id = $1 FOR UPDATE", secretID,
+		).Scan(&current)
+		if err != nil {
+			return fmt.Errorf("getting current secret: %w", err)
+		}
+
+		var exists bool
+		if err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM vault_secret_history WHERE secret_id = $1 AND version = $2)",
+			secretID, version,
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("checking secret version: %w", err)
+		}
+		if !exists {
+			return ErrVersionNotFound
+		}
+
+		if err := snapshotSecretVersion(ctx, tx, secretID, rolledBackBy); err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `
+			UPDATE vault_secrets s SET
+				encrypted_value = h.encrypted_value, wrapped_key = h.wrapped_key, key_id = h.key_id,
+				version = s.version + 1, last_rotated_at = NOW()
+			FROM vault_secret_history h
+			WHERE s.id = $1 AND h.secret_id = s.id AND h.version = $2
+			RETURNING s.version`,
+			secretID, version,
+		).Scan(&newVersion)
+		if err != nil {
+			return fmt.Errorf("rolling back secret: %w", err)
+		}
+
+		return s.pruneHistory(ctx, tx, secretID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return newVersion, nil
+}
@@ -0,0 +1,267 @@
+// Package watch checks new knowledge entries against agents' saved searches
+// and tells the owners about matches: on Hermes, at
+// swarm.vault.watch.<agent>.matched, and by POSTing to the saved search's
+// webhook when it has one.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// EventMatched is the event type of a match, on Hermes and in webhooks.
+const EventMatched = "vault.watch.matched"
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body, keyed with the
+// saved search's webhook secret, as "sha256=<hex>".
+const SignatureHeader = "X-Alexandria-Signature"
+
+// Publisher announces matches on the event bus.
+type Publisher interface {
+	WatchMatched(ctx context.Context, search *store.SavedSearch, entry *store.KnowledgeEntry, score float64) error
+}
+
+// Match is the webhook payload for a saved search match.
+type Match struct {
+	Event         string                `json:"event"`
+	SavedSearchID string                `json:"saved_search_id"`
+	SavedSearch   string                `json:"saved_search"`
+	AgentID       string                `json:"agent_id"`
+	Query         string                `json:"query"`
+	Score         float64               `json:"score"`
+	Knowledge     *store.KnowledgeEntry `json:"knowledge"`
+	MatchedAt     time.Time             `json:"matched_at"`
+}
+
+// Config controls webhook delivery.
+type Config struct {
+	WebhookTimeout time.Duration
+	// AllowPrivate lets webhooks reach loopback, private and link-local
+	// addresses. Only tests should need it.
+	AllowPrivate bool
+}
+
+// queueSize is how many new entries may wait to be checked.
+const queueSize = 256
+
+// Watcher matches new entries against saved searches in the background.
+type Watcher struct {
+	searches  *store.SavedSearchStore
+	embedder  embeddings.Provider
+	publisher Publisher
+	client    *http.Client
+	logger    *slog.Logger
+	queue     chan *store.KnowledgeEntry
+}
+
+// New creates a Watcher. publisher may be nil when Hermes is unavailable,
+// in which case only webhooks are notified.
+func New(searches *store.SavedSearchStore, embedder embeddings.Provider, publisher Publisher, cfg Config, logger *slog.Logger) *Watcher {
+	if cfg.WebhookTimeout <= 0 {
+		cfg.WebhookTimeout = 10 * time.Second
+	}
+	return &Watcher{
+		searches:  searches,
+		embedder:  embedder,
+		publisher: publisher,
+		client:    webhookClient(cfg),
+		logger:    logger,
+		queue:     make(chan *store.KnowledgeEntry, queueSize),
+	}
+}
+
+// webhookClient returns the client webhooks are POSTed with. It ignores
+// proxy settings, so the address it checks is the one it talks to; refuses
+// to connect to non-public addresses, whatever name resolved to them; and
+// does not follow redirects, which would otherwise bypass that check. A
+// redirect is reported as a failed delivery.
+func webhookClient(cfg Config) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.WebhookTimeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   cfg.WebhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivate is a net.Dialer Control func that only lets connections
+// to public addresses through.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook address %q: %w", address, err)
+	}
+	if !store.PublicAddr(ap.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", ap.Addr())
+	}
+	return nil
+}
+
+// Enqueue asks for a newly created entry to be checked against saved
+// searches. It never blocks: the queue is in memory and holds queueSize
+// entries, so entries arriving while it is full, or still queued when the
+// process stops, are never checked and their matches are lost.
+func (w *Watcher) Enqueue(entry *store.KnowledgeEntry) {
+	select {
+	case w.queue <- entry:
+	default:
+		w.logger.Warn("saved search queue full, entry not checked", "id", entry.ID)
+	}
+}
+
+// Start launches the watcher loop. It runs until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) {
+	w.logger.Info("saved search watcher starting")
+	go w.runLoop(ctx)
+}
+
+func (w *Watcher) runLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("saved search watcher shutting down")
+			return
+		case entry := <-w.queue:
+			n, err := w.Check(ctx, entry)
+			if err != nil {
+				w.logger.Warn("checking saved searches", "id", entry.ID, "error", err)
+			}
+			if n > 0 {
+				w.logger.Info("saved searches matched", "id", entry.ID, "matches", n)
+			}
+		}
+	}
+}
+
+// Check matches entry against every saved search and notifies the owners
+// of those it satisfies. It returns the number of matches.
+func (w *Watcher) Check(ctx context.Context, entry *store.KnowledgeEntry) (int, error) {
+	if err := w.refreshQueries(ctx); err != nil {
+		w.logger.Warn("re-embedding saved search queries", "error", err)
+	}
+
+	candidates, err := w.searches.Candidates(ctx, entry.ID)
+	if err != nil {
+		return 0, err
+	}
+	matched := 0
+	for _, c := range candidates {
+		score, ok := c.Matches()
+		if !ok {
+			continue
+		}
+		matched++
+		w.notify(ctx, &c.Search, entry, score)
+	}
+	return matched, nil
+}
+
+// refreshQueries embeds saved search queries that have no embedding from
+// the current model, e.g. after EMBEDDING_BACKEND changed.
+func (w *Watcher) refreshQueries(ctx context.Context) error {
+	model := embeddings.ModelName(w.embedder)
+	stale, err := w.searches.ListStaleQueries(ctx, model)
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+	texts := make([]string, len(stale))
+	for i, s := range stale {
+		texts[i] = s.Query
+	}
+	vecs, err := embeddings.EmbedAll(ctx, w.embedder, texts)
+	if err != nil {
+		return fmt.Errorf("embedding %d saved search queries: %w", len(stale), err)
+	}
+	for i, s := range stale {
+		if err := w.searches.SetQueryEmbedding(ctx, s.ID, s.Query, vecs[i], model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) notify(ctx context.Context, search *store.SavedSearch, entry *store.KnowledgeEntry, score float64) {
+	if err := w.searches.RecordMatch(ctx, search.ID); err != nil {
+		w.logger.Warn("recording saved search match", "saved_search_id", search.ID, "error", err)
+	}
+	if w.publisher != nil {
+		if err := w.publisher.WatchMatched(ctx, search, entry, score); err != nil {
+			w.logger.Warn("publishing saved search match", "saved_search_id", search.ID, "error", err)
+		}
+	}
+	if search.WebhookURL == nil {
+		return
+	}
+	err := w.Deliver(ctx, search, Match{
+		Event:         EventMatched,
+		SavedSearchID: search.ID,
+		SavedSearch:   search.Name,
+		AgentID:       search.AgentID,
+		Query:         search.Query,
+		Score:         score,
+		Knowledge:     entry,
+		MatchedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		w.logger.Warn("delivering saved search webhook", "saved_search_id", search.ID, "error", err)
+	}
+}
+
+// Deliver POSTs m as JSON to search's webhook, signed with its secret if
+// it has one. Any status other than 2xx, redirects included, is an error;
+// failed deliveries are not retried.
+func (w *Watcher) Deliver(ctx context.Context, search *store.SavedSearch, m Match) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *search.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "alexandria")
+	req.Header.Set("X-Alexandria-Event", EventMatched)
+	if search.WebhookSecret != nil && *search.WebhookSecret != "" {
+		req.Header.Set(SignatureHeader, Sign(*search.WebhookSecret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value for body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,37 @@
+-- Migration 004: Lexical search support for hybrid knowledge search
+-- Adds a full-text search vector over content, summary, and tags so exact
+-- identifiers (hostnames, error codes, ticket IDs) can be matched alongside
+-- pgvector similarity.
+
+BEGIN;
+
+ALTER TABLE vault_knowledge ADD COLUMN IF NOT EXISTS search_tsv TSVECTOR;
+
+-- The 'simple' configuration is used deliberately: identifiers should match
+-- verbatim rather than being stemmed or dropped as stop words.
+CREATE OR REPLACE FUNCTION vault_knowledge_search_tsv()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.search_tsv :=
+    setweight(to_tsvector('simple', coalesce(NEW.summary, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(array_to_string(NEW.tags, ' '), '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(NEW.content, '')), 'B');
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS vault_knowledge_search_tsv_update ON vault_knowledge;
+CREATE TRIGGER vault_knowledge_search_tsv_update
+  BEFORE INSERT OR UPDATE OF content, summary, tags ON vault_knowledge
+  FOR EACH ROW EXECUTE FUNCTION vault_knowledge_search_tsv();
+
+-- Backfill existing rows
+UPDATE vault_knowledge SET search_tsv =
+  setweight(to_tsvector('simple', coalesce(summary, '')), 'A') ||
+  setweight(to_tsvector('simple', coalesce(array_to_string(tags, ' '), '')), 'A') ||
+  setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+WHERE search_tsv IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_vault_knowledge_search_tsv ON vault_knowledge USING GIN (search_tsv);
+
+COMMIT;
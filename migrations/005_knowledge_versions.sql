@@ -0,0 +1,32 @@
+-- Migration 005: Knowledge edit history
+-- Every update snapshots the previous state of an entry into
+-- vault_knowledge_versions so corrupted facts can be inspected and reverted.
+
+BEGIN;
+
+ALTER TABLE vault_knowledge ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS vault_knowledge_versions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    knowledge_id    UUID NOT NULL REFERENCES vault_knowledge(id) ON DELETE CASCADE,
+    version         INT NOT NULL,
+    content         TEXT NOT NULL,
+    summary         TEXT,
+    category        vault_knowledge_category NOT NULL,
+    scope           vault_knowledge_scope NOT NULL,
+    shared_with     TEXT[] DEFAULT '{}',
+    tags            TEXT[] DEFAULT '{}',
+    embedding       vector(384),
+    metadata        JSONB DEFAULT '{}',
+    confidence      FLOAT,
+    relevance_decay vault_relevance_decay NOT NULL,
+    expires_at      TIMESTAMPTZ,
+    superseded_by   UUID,
+    edited_by       TEXT NOT NULL, -- agent whose edit replaced this version
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(knowledge_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vault_knowledge_versions_knowledge ON vault_knowledge_versions (knowledge_id, version DESC);
+
+COMMIT;
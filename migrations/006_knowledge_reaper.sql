@@ -0,0 +1,20 @@
+-- Migration 006: Knowledge expiry reaper
+-- Indexes for the background reaper and relaxed FK so purged knowledge
+-- does not block on relationships that cite it.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS vault_knowledge_expires_idx
+    ON vault_knowledge (expires_at)
+    WHERE deleted_at IS NULL AND expires_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS vault_knowledge_deleted_idx
+    ON vault_knowledge (deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE vault_relationships DROP CONSTRAINT IF EXISTS vault_relationships_knowledge_id_fkey;
+ALTER TABLE vault_relationships
+    ADD CONSTRAINT vault_relationships_knowledge_id_fkey
+    FOREIGN KEY (knowledge_id) REFERENCES vault_knowledge(id) ON DELETE SET NULL;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration 007: Knowledge trash and restore
+-- Audit actions for listing and restoring soft-deleted knowledge.
+
+BEGIN;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'knowledge.trash';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'knowledge.restore';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
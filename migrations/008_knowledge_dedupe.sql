@@ -0,0 +1,35 @@
+-- Migration 008: Content-hash deduplication for knowledge
+-- Stores a hash of whitespace- and case-normalized content so repeated
+-- reports of the same discovery can be detected on ingestion.
+
+BEGIN;
+
+CREATE OR REPLACE FUNCTION vault_knowledge_content_hash(content TEXT)
+RETURNS TEXT AS $$
+  SELECT encode(sha256(convert_to(lower(regexp_replace(btrim(content), '\s+', ' ', 'g')), 'UTF8')), 'hex');
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+ALTER TABLE vault_knowledge ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+CREATE OR REPLACE FUNCTION vault_knowledge_set_content_hash()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.content_hash := vault_knowledge_content_hash(NEW.content);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS vault_knowledge_content_hash_update ON vault_knowledge;
+CREATE TRIGGER vault_knowledge_content_hash_update
+  BEFORE INSERT OR UPDATE OF content ON vault_knowledge
+  FOR EACH ROW EXECUTE FUNCTION vault_knowledge_set_content_hash();
+
+-- Backfill existing rows
+UPDATE vault_knowledge SET content_hash = vault_knowledge_content_hash(content)
+WHERE content_hash IS NULL;
+
+CREATE INDEX IF NOT EXISTS vault_knowledge_content_hash_idx
+    ON vault_knowledge (content_hash)
+    WHERE deleted_at IS NULL;
+
+COMMIT;
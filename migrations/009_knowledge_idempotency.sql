@@ -0,0 +1,21 @@
+-- Migration 009: Idempotent knowledge creation
+-- A source event (Hermes message ID or Idempotency-Key header) may only
+-- create one entry per source agent.
+
+BEGIN;
+
+-- Clear the event ID on later duplicates so the unique index can be built.
+UPDATE vault_knowledge k SET source_event_id = NULL
+WHERE source_event_id IS NOT NULL
+AND EXISTS (
+    SELECT 1 FROM vault_knowledge o
+    WHERE o.source_agent = k.source_agent
+    AND o.source_event_id = k.source_event_id
+    AND (o.created_at, o.id) < (k.created_at, k.id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS vault_knowledge_source_event_uniq
+    ON vault_knowledge (source_agent, source_event_id)
+    WHERE source_event_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration 010: Knowledge export and import
+-- Audit actions for NDJSON bulk export and import.
+
+BEGIN;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'knowledge.export';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'knowledge.import';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
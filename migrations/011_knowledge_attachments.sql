@@ -0,0 +1,42 @@
+-- Migration 011: Knowledge attachments
+-- Binary artifacts (logs, diffs, screenshots) attached to knowledge entries.
+-- Bytes live in the blob backend; this table holds their metadata. Purging a
+-- knowledge entry orphans its attachments (knowledge_id NULL) so the reaper
+-- can delete the blobs before the rows.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vault_knowledge_attachments (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    knowledge_id UUID REFERENCES vault_knowledge(id) ON DELETE SET NULL,
+    filename     TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes   BIGINT NOT NULL CHECK (size_bytes >= 0),
+    sha256       TEXT NOT NULL,
+    storage_key  TEXT NOT NULL UNIQUE,
+    uploaded_by  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vault_knowledge_attachments_knowledge
+    ON vault_knowledge_attachments (knowledge_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_vault_knowledge_attachments_orphaned
+    ON vault_knowledge_attachments (created_at) WHERE knowledge_id IS NULL;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'attachment.upload';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'attachment.download';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'attachment.delete';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
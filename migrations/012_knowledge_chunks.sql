@@ -0,0 +1,26 @@
+-- Migration 012: Knowledge chunks
+-- Long entries are split into overlapping chunks with their own embeddings,
+-- since the embedding model only sees the first ~256 tokens of a text.
+-- source_hash is the parent's content_hash when the chunks were made; a
+-- mismatch marks them stale.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vault_knowledge_chunks (
+    knowledge_id UUID NOT NULL REFERENCES vault_knowledge(id) ON DELETE CASCADE,
+    chunk_index  INT NOT NULL,
+    content      TEXT NOT NULL,
+    start_offset INT NOT NULL,
+    end_offset   INT NOT NULL,
+    embedding    vector(384) NOT NULL,
+    source_hash  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (knowledge_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vault_knowledge_chunks_embedding_hnsw
+    ON vault_knowledge_chunks
+    USING hnsw (embedding vector_cosine_ops)
+    WITH (m = 16, ef_construction = 64);
+
+COMMIT;
@@ -0,0 +1,71 @@
+-- Migration 013: Knowledge embedding provenance
+-- Records which model produced each knowledge embedding and the content_hash
+-- it was computed from, so missing or stale vectors can be found and redone.
+-- embedding_model is unknown (NULL) for rows embedded before this migration.
+
+BEGIN;
+
+ALTER TABLE vault_knowledge ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+ALTER TABLE vault_knowledge ADD COLUMN IF NOT EXISTS embedded_hash TEXT;
+ALTER TABLE vault_knowledge_versions ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+
+-- Stamp embedded_hash whenever the embedding is written. Fires after
+-- vault_knowledge_content_hash_update (triggers run in name order), so
+-- content_hash is already current. A content edit that keeps the old vector
+-- leaves embedded_hash behind, which marks the row stale.
+CREATE OR REPLACE FUNCTION vault_knowledge_track_embedding()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.embedding IS NULL THEN
+    NEW.embedded_hash := NULL;
+    NEW.embedding_model := NULL;
+  ELSIF TG_OP = 'INSERT' OR NEW.embedding IS DISTINCT FROM OLD.embedding THEN
+    NEW.embedded_hash := NEW.content_hash;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS vault_knowledge_embedding_state ON vault_knowledge;
+CREATE TRIGGER vault_knowledge_embedding_state
+  BEFORE INSERT OR UPDATE ON vault_knowledge
+  FOR EACH ROW EXECUTE FUNCTION vault_knowledge_track_embedding();
+
+-- Re-embedding is maintenance, not an edit: only bump updated_at when a
+-- column an agent can change does. Derived columns (content_hash,
+-- search_tsv, embedded_hash) follow content and are left out.
+CREATE OR REPLACE FUNCTION vault_knowledge_touch()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF (NEW.content, NEW.summary, NEW.source_agent, NEW.category, NEW.scope, NEW.shared_with,
+      NEW.tags, NEW.metadata, NEW.source_event_id, NEW.confidence, NEW.relevance_decay,
+      NEW.expires_at, NEW.superseded_by, NEW.deleted_at, NEW.version)
+     IS DISTINCT FROM
+     (OLD.content, OLD.summary, OLD.source_agent, OLD.category, OLD.scope, OLD.shared_with,
+      OLD.tags, OLD.metadata, OLD.source_event_id, OLD.confidence, OLD.relevance_decay,
+      OLD.expires_at, OLD.superseded_by, OLD.deleted_at, OLD.version) THEN
+    NEW.updated_at = NOW();
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS vault_knowledge_updated ON vault_knowledge;
+CREATE TRIGGER vault_knowledge_updated
+  BEFORE UPDATE ON vault_knowledge
+  FOR EACH ROW EXECUTE FUNCTION vault_knowledge_touch();
+
+-- Existing vectors are assumed to match their content
+UPDATE vault_knowledge SET embedded_hash = content_hash
+WHERE embedding IS NOT NULL AND embedded_hash IS NULL;
+
+CREATE INDEX IF NOT EXISTS vault_knowledge_embedding_stale_idx
+    ON vault_knowledge (updated_at)
+    WHERE deleted_at IS NULL AND (embedding IS NULL OR embedded_hash IS DISTINCT FROM content_hash);
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'knowledge.reembed';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
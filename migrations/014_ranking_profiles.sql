@@ -0,0 +1,21 @@
+-- Migration 014: Ranking profiles
+-- Named weightings of similarity, confidence, decay half-lives, categories
+-- and source agents, selectable per search and per briefing. Saved profiles
+-- override the built-in ones of the same name.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vault_ranking_profiles (
+    name       TEXT PRIMARY KEY,
+    profile    JSONB NOT NULL,
+    updated_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'ranking.write';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
@@ -0,0 +1,36 @@
+-- Migration 015: Saved searches
+-- Persistent searches owned by an agent. Each new knowledge entry is checked
+-- against them and matches are pushed over Hermes and, optionally, to a
+-- webhook, so agents no longer poll /knowledge/search.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vault_saved_searches (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id        TEXT NOT NULL,
+    name            TEXT NOT NULL,
+    query           TEXT NOT NULL,
+    mode            TEXT NOT NULL DEFAULT 'vector' CHECK (mode IN ('vector', 'lexical', 'hybrid')),
+    scope           TEXT CHECK (scope IN ('public', 'private', 'shared')),
+    categories      TEXT[] NOT NULL DEFAULT '{}',
+    min_relevance   FLOAT NOT NULL DEFAULT 0,
+    query_embedding vector(384),
+    embedding_model TEXT,
+    webhook_url     TEXT,
+    webhook_secret  TEXT,
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    match_count     BIGINT NOT NULL DEFAULT 0,
+    last_matched_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (agent_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_enabled ON vault_saved_searches (agent_id) WHERE enabled;
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'saved_search.write';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Migration 016: Per-agent knowledge quotas
+-- Overrides of the configured default quotas on entry count, stored bytes
+-- and daily creates. A NULL limit falls back to the default; 0 is unlimited.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vault_agent_quotas (
+    agent_id          TEXT PRIMARY KEY,
+    max_entries       BIGINT CHECK (max_entries >= 0),
+    max_bytes         BIGINT CHECK (max_bytes >= 0),
+    max_daily_creates BIGINT CHECK (max_daily_creates >= 0),
+    updated_by        TEXT NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Daily create counts scan an agent's entries by creation time.
+CREATE INDEX IF NOT EXISTS idx_knowledge_agent_created ON vault_knowledge (source_agent, created_at);
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'quota.write';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
@@ -0,0 +1,22 @@
+-- Migration 017: Envelope encryption for secrets
+-- Each secret value is encrypted with its own data key, stored wrapped by a
+-- versioned master key. key_id names that master key; rows where it is NULL
+-- predate envelope encryption and are encrypted directly with a master key.
+
+BEGIN;
+
+ALTER TABLE vault_secrets ADD COLUMN IF NOT EXISTS wrapped_key TEXT;
+ALTER TABLE vault_secrets ADD COLUMN IF NOT EXISTS key_id TEXT;
+ALTER TABLE vault_secret_history ADD COLUMN IF NOT EXISTS wrapped_key TEXT;
+ALTER TABLE vault_secret_history ADD COLUMN IF NOT EXISTS key_id TEXT;
+
+-- Re-wrap jobs find the values not yet under the newest master key.
+CREATE INDEX IF NOT EXISTS idx_secrets_key_id ON vault_secrets (key_id);
+CREATE INDEX IF NOT EXISTS idx_secret_history_key_id ON vault_secret_history (key_id);
+
+DO $$ BEGIN
+    ALTER TYPE vault_access_action ADD VALUE IF NOT EXISTS 'secret.rewrap';
+EXCEPTION WHEN undefined_object THEN NULL;
+END $$;
+
+COMMIT;
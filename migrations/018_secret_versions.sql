@@ -0,0 +1,27 @@
+-- Migration 018: Secret version numbers
+-- Every rotation bumps vault_secrets.version and records the replaced value
+-- in vault_secret_history under its version, so old values can be read back
+-- and rolled back to.
+
+BEGIN;
+
+ALTER TABLE vault_secrets ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE vault_secret_history ADD COLUMN IF NOT EXISTS version INT;
+
+-- Number existing history in rotation order; the current value follows it.
+UPDATE vault_secret_history h SET version = r.n
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY secret_id ORDER BY rotated_at, id) AS n
+    FROM vault_secret_history
+) r
+WHERE h.id = r.id AND h.version IS NULL;
+
+UPDATE vault_secrets s SET version = h.latest + 1
+FROM (SELECT secret_id, MAX(version) AS latest FROM vault_secret_history GROUP BY secret_id) h
+WHERE s.id = h.secret_id AND s.version <= h.latest;
+
+ALTER TABLE vault_secret_history ALTER COLUMN version SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_secret_history_version ON vault_secret_history (secret_id, version DESC);
+
+COMMIT;
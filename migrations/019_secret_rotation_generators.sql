@@ -0,0 +1,17 @@
+-- Migration 019: Scheduled secret rotation
+-- rotation_generator names the generator that produces a secret's next
+-- value when it is rotated on schedule (every rotation_interval_days);
+-- rotation_options are passed to it. Secrets without a generator are only
+-- reported as overdue.
+
+BEGIN;
+
+ALTER TABLE vault_secrets ADD COLUMN IF NOT EXISTS rotation_generator TEXT;
+ALTER TABLE vault_secrets ADD COLUMN IF NOT EXISTS rotation_options JSONB NOT NULL DEFAULT '{}';
+
+-- The scheduler scans only secrets with a rotation interval.
+CREATE INDEX IF NOT EXISTS idx_secrets_rotation_due
+    ON vault_secrets (last_rotated_at)
+    WHERE rotation_interval_days IS NOT NULL;
+
+COMMIT;
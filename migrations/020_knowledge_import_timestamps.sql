@@ -0,0 +1,28 @@
+-- Migration 020: Keep imported knowledge timestamps
+-- An import restores each entry's exported updated_at. The touch trigger
+-- would overwrite it, so it steps aside while the transaction has set
+-- alexandria.preserve_updated_at (set_config(..., true) scopes it to the
+-- transaction).
+
+BEGIN;
+
+CREATE OR REPLACE FUNCTION vault_knowledge_touch()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF current_setting('alexandria.preserve_updated_at', true) = 'on' THEN
+    RETURN NEW;
+  END IF;
+  IF (NEW.content, NEW.summary, NEW.source_agent, NEW.category, NEW.scope, NEW.shared_with,
+      NEW.tags, NEW.metadata, NEW.source_event_id, NEW.confidence, NEW.relevance_decay,
+      NEW.expires_at, NEW.superseded_by, NEW.deleted_at, NEW.version)
+     IS DISTINCT FROM
+     (OLD.content, OLD.summary, OLD.source_agent, OLD.category, OLD.scope, OLD.shared_with,
+      OLD.tags, OLD.metadata, OLD.source_event_id, OLD.confidence, OLD.relevance_decay,
+      OLD.expires_at, OLD.superseded_by, OLD.deleted_at, OLD.version) THEN
+    NEW.updated_at = NOW();
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
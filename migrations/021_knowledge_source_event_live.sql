@@ -0,0 +1,14 @@
+-- Migration 021: Source events belong to live knowledge
+-- A deleted entry no longer answers for its source event, so a retried
+-- request or redelivered event after a delete creates a fresh entry. The
+-- unique index only covers live entries to allow that.
+
+BEGIN;
+
+DROP INDEX IF EXISTS vault_knowledge_source_event_uniq;
+
+CREATE UNIQUE INDEX IF NOT EXISTS vault_knowledge_source_event_uniq
+    ON vault_knowledge (source_agent, source_event_id)
+    WHERE source_event_id IS NOT NULL AND deleted_at IS NULL;
+
+COMMIT;
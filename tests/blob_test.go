@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/blob"
+)
+
+func TestFilesystemBlobRoundTrip(t *testing.T) {
+	fs := blob.NewFilesystem(t.TempDir())
+	ctx := context.Background()
+	key := "6f1c0c2e-knowledge/9a4e-attachment"
+
+	n, err := fs.Put(ctx, key, strings.NewReader("panic: runtime error"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if n != int64(len("panic: runtime error")) {
+		t.Errorf("expected %d bytes written, got %d", len("panic: runtime error"), n)
+	}
+
+	rc, err := fs.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "panic: runtime error" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	if err := fs.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := fs.Get(ctx, key); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := fs.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing blob should succeed, got %v", err)
+	}
+}
+
+func TestFilesystemBlobRejectsEscapingKeys(t *testing.T) {
+	fs := blob.NewFilesystem(t.TempDir())
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b"} {
+		if _, err := fs.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
+
+func TestFilesystemBlobFailedPutLeavesNothing(t *testing.T) {
+	fs := blob.NewFilesystem(t.TempDir())
+	ctx := context.Background()
+
+	failing := io.MultiReader(strings.NewReader("partial"), &errReader{err: errors.New("client went away")})
+	if _, err := fs.Put(ctx, "k/a", failing); err == nil {
+		t.Fatal("expected Put to fail")
+	}
+	if _, err := fs.Get(ctx, "k/a"); !errors.Is(err, blob.ErrNotFound) {
+		t.Errorf("expected no blob after failed put, got %v", err)
+	}
+}
+
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestNewBlobBackend(t *testing.T) {
+	if _, err := blob.New("filesystem", t.TempDir()); err != nil {
+		t.Errorf("filesystem backend: %v", err)
+	}
+	if _, err := blob.New("s3", ""); err == nil {
+		t.Error("expected unknown backend to fail")
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name     string
+		head     []byte
+		declared string
+		filename string
+		want     string
+	}{
+		{"sniffed png wins over declaration", png, "text/plain", "shot.txt", "image/png"},
+		{"declared json for text", []byte(`{"level":"error"}`), "application/json", "log.json", "application/json"},
+		{"declared text subtype", []byte("host,status\nnfs-02,down\n"), "text/csv", "hosts.csv", "text/csv; charset=utf-8"},
+		{"extension for text", []byte(`{"level":"error"}`), "", "log.json", "application/json"},
+		{"html declaration refused", []byte("hello"), "text/html", "page.html", "text/plain; charset=utf-8"},
+		{"binary cannot be declared text", []byte{0x00, 0x01, 0x02, 0xff}, "text/plain", "dump.txt", "application/octet-stream"},
+		{"sniffed html kept as sniffed", []byte("<html><body>x</body></html>"), "", "x", "text/html; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if got := blob.SniffContentType(tt.head, tt.declared, tt.filename); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/chunking"
+)
+
+func TestSplitLeavesShortContentWhole(t *testing.T) {
+	if chunks := chunking.Split("restart nfs-02 before the build", 1000, 200); chunks != nil {
+		t.Errorf("expected no chunks for short content, got %d", len(chunks))
+	}
+}
+
+func TestSplitCoversContentWithOverlap(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 60; i++ {
+		b.WriteString("Step: drain the node, wait for pods to reschedule, then cordon it. ")
+		if i%5 == 4 {
+			b.WriteString("\n\n")
+		}
+	}
+	text := b.String()
+
+	chunks := chunking.Split(text, 500, 100)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	if chunks[0].Start != 0 || chunks[len(chunks)-1].End != len(text) {
+		t.Errorf("chunks do not span the content: %d..%d of %d", chunks[0].Start, chunks[len(chunks)-1].End, len(text))
+	}
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("chunk %d has index %d", i, c.Index)
+		}
+		if c.End-c.Start > 500 {
+			t.Errorf("chunk %d is %d bytes, over the limit", i, c.End-c.Start)
+		}
+		if c.Text != text[c.Start:c.End] {
+			t.Errorf("chunk %d text does not match its offsets", i)
+		}
+		if i > 0 {
+			prev := chunks[i-1]
+			if c.Start >= prev.End {
+				t.Errorf("chunk %d does not overlap chunk %d", i, i-1)
+			}
+			if c.Start <= prev.Start {
+				t.Errorf("chunk %d does not advance", i)
+			}
+		}
+		if i < len(chunks)-1 && !strings.HasSuffix(c.Text, " ") && !strings.HasSuffix(c.Text, "\n") {
+			t.Errorf("chunk %d ends mid-word: %q", i, c.Text[len(c.Text)-10:])
+		}
+	}
+}
+
+func TestSplitKeepsUTF8Intact(t *testing.T) {
+	// No spaces, so chunks must fall back to cutting between runes.
+	text := strings.Repeat("日本語のテキスト", 200)
+
+	chunks := chunking.Split(text, 301, 50)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if !utf8.ValidString(c.Text) {
+			t.Errorf("chunk %d splits a UTF-8 sequence", i)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+//go:build integration
+
+// Integration tests for the knowledge store against a real database.
+// Run with: go test ./tests/ -tags=integration -run TestE2E_KnowledgeStore -v
+// Requires a migrated database; set DATABASE_URL to point at it.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+// e2eKnowledgeStore connects a KnowledgeStore to DATABASE_URL, skipping the
+// test if it is unset.
+func e2eKnowledgeStore(t *testing.T) *store.KnowledgeStore {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+	db, err := store.NewDB(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connecting to database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return store.NewKnowledgeStore(db)
+}
+
+// e2eKnowledge creates an entry and deletes it when the test ends.
+func e2eKnowledge(t *testing.T, ks *store.KnowledgeStore, input store.KnowledgeCreateInput) *store.KnowledgeEntry {
+	t.Helper()
+	entry, err := ks.Create(context.Background(), input)
+	if err != nil {
+		t.Fatalf("creating knowledge: %v", err)
+	}
+	t.Cleanup(func() { _ = ks.Delete(context.Background(), entry.ID, entry.SourceAgent) })
+	return entry
+}
+
+func TestE2E_KnowledgeStoreDedupeLeavesOtherAgentsEntry(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	content := fmt.Sprintf("e2e dedupe ownership %d", time.Now().UnixNano())
+
+	original := e2eKnowledge(t, ks, store.KnowledgeCreateInput{
+		Content:     content,
+		SourceAgent: "e2e-dedupe-a",
+		Category:    store.CategoryDiscovery,
+		Scope:       store.ScopePublic,
+		Tags:        []string{"a"},
+		Confidence:  0.5,
+		Dedupe:      store.DedupeNone,
+	})
+
+	for _, policy := range []store.DedupePolicy{store.DedupeMergeTags, store.DedupeBumpConfidence} {
+		result, err := ks.CreateWithOutcome(ctx, store.KnowledgeCreateInput{
+			Content:     content,
+			SourceAgent: "e2e-dedupe-b",
+			Category:    store.CategoryDiscovery,
+			Scope:       store.ScopePublic,
+			Tags:        []string{"b"},
+			Confidence:  0.9,
+			Dedupe:      policy,
+		})
+		if err != nil {
+			t.Fatalf("%s: create: %v", policy, err)
+		}
+		t.Cleanup(func() { _ = ks.Delete(ctx, result.Entry.ID, "e2e-dedupe-b") })
+		if result.Outcome != store.OutcomeCreated || result.Entry.ID == original.ID || result.DuplicateOf != original.ID {
+			t.Errorf("%s: expected a new entry duplicating %s, got %s %s (duplicate of %q)",
+				policy, original.ID, result.Outcome, result.Entry.ID, result.DuplicateOf)
+		}
+		link, _ := result.Entry.Metadata["duplicate_of"].(map[string]any)
+		if link["id"] != original.ID {
+			t.Errorf("%s: expected metadata.duplicate_of to name %s, got %v", policy, original.ID, result.Entry.Metadata)
+		}
+	}
+
+	got, err := ks.GetByID(ctx, original.ID, "e2e-dedupe-a")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !reflect.DeepEqual(got.Tags, original.Tags) || got.Confidence != original.Confidence || got.Version != original.Version {
+		t.Errorf("expected agent A's entry unchanged, got tags=%v confidence=%v version=%d", got.Tags, got.Confidence, got.Version)
+	}
+}
+
+func TestE2E_KnowledgeStoreDedupeIgnoresExpiredEntries(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	agent := "e2e-dedupe-expired"
+	content := fmt.Sprintf("e2e dedupe expired %d", time.Now().UnixNano())
+
+	past := time.Now().Add(-time.Minute)
+	expired := e2eKnowledge(t, ks, store.KnowledgeCreateInput{
+		Content:     content,
+		SourceAgent: agent,
+		Category:    store.CategoryFact,
+		Scope:       store.ScopePrivate,
+		Confidence:  0.5,
+		ExpiresAt:   &past,
+		Dedupe:      store.DedupeNone,
+	})
+
+	result, err := ks.CreateWithOutcome(ctx, store.KnowledgeCreateInput{
+		Content:     content,
+		SourceAgent: agent,
+		Category:    store.CategoryFact,
+		Scope:       store.ScopePrivate,
+		Confidence:  0.5,
+		Dedupe:      store.DedupeMergeTags,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	t.Cleanup(func() { _ = ks.Delete(ctx, result.Entry.ID, agent) })
+	if result.Outcome != store.OutcomeCreated || result.Entry.ID == expired.ID {
+		t.Errorf("expected a new entry rather than a merge into expired %s, got %s %s", expired.ID, result.Outcome, result.Entry.ID)
+	}
+}
+
+// e2eSupersede supersedes id with a new entry and deletes the replacement
+// when the test ends.
+func e2eSupersede(t *testing.T, ks *store.KnowledgeStore, id string, input store.KnowledgeCreateInput) *store.KnowledgeEntry {
+	t.Helper()
+	_, replacement, err := ks.Supersede(context.Background(), id, input.SourceAgent, input)
+	if err != nil {
+		t.Fatalf("superseding %s: %v", id, err)
+	}
+	t.Cleanup(func() { _ = ks.Delete(context.Background(), replacement.ID, replacement.SourceAgent) })
+	return replacement
+}
+
+func e2eFact(agent, content string, scope store.KnowledgeScope) store.KnowledgeCreateInput {
+	return store.KnowledgeCreateInput{
+		Content:     fmt.Sprintf("%s %d", content, time.Now().UnixNano()),
+		SourceAgent: agent,
+		Category:    store.CategoryFact,
+		Scope:       scope,
+		Confidence:  0.8,
+		Dedupe:      store.DedupeNone,
+	}
+}
+
+func TestE2E_KnowledgeStoreSupersedeTwice(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	agent := "e2e-supersede"
+
+	original := e2eKnowledge(t, ks, e2eFact(agent, "e2e supersede original", store.ScopePublic))
+	e2eSupersede(t, ks, original.ID, e2eFact(agent, "e2e supersede first", store.ScopePublic))
+
+	_, _, err := ks.Supersede(context.Background(), original.ID, agent, e2eFact(agent, "e2e supersede second", store.ScopePublic))
+	if !errors.Is(err, store.ErrAlreadySuperseded) {
+		t.Errorf("expected ErrAlreadySuperseded, got %v", err)
+	}
+}
+
+func TestE2E_KnowledgeStoreLineageHidesInaccessibleEntries(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	owner := "e2e-lineage-owner"
+
+	original := e2eKnowledge(t, ks, e2eFact(owner, "e2e lineage public", store.ScopePublic))
+	private := e2eSupersede(t, ks, original.ID, e2eFact(owner, "e2e lineage private", store.ScopePrivate))
+
+	chain, err := ks.Lineage(ctx, original.ID, owner)
+	if err != nil || len(chain) != 2 {
+		t.Fatalf("expected the owner to see both entries, got %d (%v)", len(chain), err)
+	}
+
+	chain, err = ks.Lineage(ctx, original.ID, "e2e-lineage-other")
+	if err != nil {
+		t.Fatalf("lineage: %v", err)
+	}
+	if len(chain) != 1 || chain[0].ID != original.ID {
+		t.Errorf("expected only the public entry, got %+v", chain)
+	}
+
+	if _, err := ks.Lineage(ctx, private.ID, "e2e-lineage-other"); !errors.Is(err, store.ErrKnowledgeNotFound) {
+		t.Errorf("expected ErrKnowledgeNotFound for a private entry, got %v", err)
+	}
+}
+
+func TestE2E_KnowledgeStoreLineageStopsAtMaxDepth(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	agent := "e2e-lineage-depth"
+	const maxDepth = 100 // store.maxLineageDepth
+
+	first := e2eKnowledge(t, ks, e2eFact(agent, "e2e lineage depth", store.ScopePrivate))
+	id := first.ID
+	for i := 0; i < maxDepth+5; i++ {
+		id = e2eSupersede(t, ks, id, e2eFact(agent, fmt.Sprintf("e2e lineage depth %d", i), store.ScopePrivate)).ID
+	}
+
+	chain, err := ks.Lineage(context.Background(), first.ID, agent)
+	if err != nil {
+		t.Fatalf("lineage: %v", err)
+	}
+	if len(chain) != maxDepth+1 {
+		t.Errorf("expected %d entries (the start and %d newer), got %d", maxDepth+1, maxDepth, len(chain))
+	}
+	if chain[0].ID != first.ID {
+		t.Errorf("expected the chain to start at %s, got %s", first.ID, chain[0].ID)
+	}
+}
+
+func TestE2E_KnowledgeStoreReimportChangesNothing(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	updatedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var file []store.KnowledgeExportRecord
+	for i := 0; i < 3; i++ {
+		summary := fmt.Sprintf("summary %d", i)
+		rec := store.KnowledgeExportRecord{KnowledgeEntry: store.KnowledgeEntry{
+			ID:             uuid.NewString(),
+			Content:        fmt.Sprintf("e2e reimport %d %d", i, time.Now().UnixNano()),
+			Summary:        &summary,
+			SourceAgent:    "e2e-import",
+			Category:       store.CategoryFact,
+			Scope:          store.ScopePublic,
+			SharedWith:     []string{},
+			Tags:           []string{"import"},
+			Metadata:       map[string]any{"n": float64(i)},
+			Confidence:     0.7,
+			RelevanceDecay: store.DecaySlow,
+			Version:        3,
+			CreatedAt:      updatedAt.Add(-time.Hour),
+			UpdatedAt:      updatedAt,
+		}}
+		file = append(file, rec)
+		t.Cleanup(func() { _ = ks.Delete(ctx, rec.ID, "warren") })
+	}
+
+	importFile := func() map[store.ImportOutcome]int {
+		counts := map[store.ImportOutcome]int{}
+		for _, rec := range file {
+			entry, outcome, err := ks.ImportKnowledge(ctx, rec, pgvector.Vector{}, "warren")
+			if err != nil {
+				t.Fatalf("importing %s: %v", rec.ID, err)
+			}
+			if !entry.UpdatedAt.Equal(updatedAt) {
+				t.Errorf("%s: expected updated_at %v to be kept, got %v", rec.ID, updatedAt, entry.UpdatedAt)
+			}
+			counts[outcome]++
+		}
+		return counts
+	}
+
+	if counts := importFile(); counts[store.ImportInserted] != len(file) {
+		t.Fatalf("first import: expected %d inserted, got %v", len(file), counts)
+	}
+	if counts := importFile(); counts[store.ImportUpdated] != 0 || counts[store.ImportUnchanged] != len(file) {
+		t.Errorf("second import: expected 0 updated and %d unchanged, got %v", len(file), counts)
+	}
+}
+
+func TestE2E_KnowledgeStoreRestoreRestartsEphemeralAge(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	createdAt := time.Now().Add(-30 * 24 * time.Hour)
+
+	rec := store.KnowledgeExportRecord{KnowledgeEntry: store.KnowledgeEntry{
+		ID:             uuid.NewString(),
+		Content:        fmt.Sprintf("e2e restore ephemeral %d", time.Now().UnixNano()),
+		SourceAgent:    "e2e-restore",
+		Category:       store.CategoryEvent,
+		Scope:          store.ScopePrivate,
+		SharedWith:     []string{},
+		Tags:           []string{},
+		Confidence:     0.5,
+		RelevanceDecay: store.DecayEphemeral,
+		Version:        1,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+	}}
+	if _, _, err := ks.ImportKnowledge(ctx, rec, pgvector.Vector{}, "warren"); err != nil {
+		t.Fatalf("importing: %v", err)
+	}
+	t.Cleanup(func() { _ = ks.Delete(ctx, rec.ID, "warren") })
+
+	if err := ks.Delete(ctx, rec.ID, rec.SourceAgent); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	restored, err := ks.Restore(ctx, rec.ID, rec.SourceAgent)
+	if err != nil {
+		t.Fatalf("restoring: %v", err)
+	}
+	if time.Since(restored.CreatedAt) > time.Hour {
+		t.Errorf("expected the ephemeral entry's age to restart, got created_at %v", restored.CreatedAt)
+	}
+}
+
+func TestE2E_KnowledgeStoreSourceEventIgnoresDeletedEntries(t *testing.T) {
+	ks := e2eKnowledgeStore(t)
+	ctx := context.Background()
+	agent := "e2e-source-event"
+	eventID := fmt.Sprintf("e2e-event-%d", time.Now().UnixNano())
+
+	input := e2eFact(agent, "e2e source event", store.ScopePrivate)
+	input.SourceEventID = &eventID
+	first := e2eKnowledge(t, ks, input)
+	if err := ks.Delete(ctx, first.ID, agent); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+
+	result, err := ks.CreateWithOutcome(ctx, input)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	t.Cleanup(func() { _ = ks.Delete(ctx, result.Entry.ID, agent) })
+	if result.Outcome != store.OutcomeCreated || result.Entry.ID == first.ID {
+		t.Errorf("expected a new entry rather than a replay of deleted %s, got %s %s", first.ID, result.Outcome, result.Entry.ID)
+	}
+
+	restored, err := ks.Restore(ctx, first.ID, agent)
+	if err != nil {
+		t.Fatalf("restoring: %v", err)
+	}
+	if restored.SourceEventID != nil {
+		t.Errorf("expected the restored entry to give up source event %s, got %v", eventID, *restored.SourceEventID)
+	}
+}
@@ -198,6 +198,85 @@ func TestE2E_SecretRotation(t *testing.T) {
 	}
 }
 
+func TestE2E_SecretRollback(t *testing.T) {
+	baseURL := alexandriaURL()
+	agent := "e2e-rollback"
+	secretName := "e2e-rollback-secret"
+
+	body := fmt.Sprintf(`{"name":%q,"value":"good-value","scope":["*"]}`, secretName)
+	resp := e2eRequest(t, "POST", baseURL+"/api/v1/secrets", agent, body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", resp.StatusCode)
+	}
+
+	defer func() {
+		resp := e2eRequest(t, "DELETE", baseURL+"/api/v1/secrets/"+secretName, agent, "")
+		resp.Body.Close()
+	}()
+
+	// A bad rotation
+	resp = e2eRequest(t, "POST", baseURL+"/api/v1/secrets/"+secretName+"/rotate", agent, `{"new_value":"bad-value"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("rotate: expected 200, got %d", resp.StatusCode)
+	}
+
+	// History lists version 1 without its value
+	resp = e2eRequest(t, "GET", baseURL+"/api/v1/secrets/"+secretName+"/versions", agent, "")
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("versions: expected 200, got %d", resp.StatusCode)
+	}
+	if strings.Contains(string(data), "good-value") || !strings.Contains(string(data), `"version":1`) {
+		t.Errorf("expected version 1 metadata without the value, got %s", data)
+	}
+
+	// Old values need admin: the secret's scope lets the agent read the
+	// current value but not version 1.
+	resp = e2eRequest(t, "GET", baseURL+"/api/v1/secrets/"+secretName+"/versions/1", agent, "")
+	data, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("version 1 as %s: expected 403, got %d: %s", agent, resp.StatusCode, data)
+	}
+	if strings.Contains(string(data), "good-value") {
+		t.Errorf("expected no value in the denial, got %s", data)
+	}
+
+	// Version 1 decrypts for warren
+	resp = e2eRequest(t, "GET", baseURL+"/api/v1/secrets/"+secretName+"/versions/1", "warren", "")
+	data, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("version 1 as warren: expected 200, got %d: %s", resp.StatusCode, data)
+	}
+	if !strings.Contains(string(data), `"value":"good-value"`) {
+		t.Errorf("expected version 1 to hold 'good-value', got %s", data)
+	}
+
+	// Roll back, then read the current value
+	resp = e2eRequest(t, "POST", baseURL+"/api/v1/secrets/"+secretName+"/rollback/1", agent, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("rollback: expected 200, got %d", resp.StatusCode)
+	}
+
+	resp = e2eRequest(t, "GET", baseURL+"/api/v1/secrets/"+secretName, agent, "")
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			Value string `json:"value"`
+		} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if result.Data.Value != "good-value" {
+		t.Errorf("expected 'good-value' after rollback, got %q", result.Data.Value)
+	}
+}
+
 func TestE2E_HealthEndpoint(t *testing.T) {
 	baseURL := alexandriaURL()
 
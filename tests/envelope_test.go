@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/encryption"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+)
+
+func newMasterKey(t *testing.T) string {
+	t.Helper()
+	k, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k.Encode()
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	enc, err := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{
+		{ID: "v1", Key: newMasterKey(t)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := enc.Seal(ctx, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.KeyID != "v1" || sealed.WrappedKey == "" || sealed.Legacy() {
+		t.Errorf("expected a value sealed under v1, got %+v", sealed)
+	}
+
+	got, err := enc.Open(ctx, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hunter2" {
+		t.Errorf("expected 'hunter2', got %q", got)
+	}
+}
+
+func TestMasterKeyRotationKeepsOldSecretsReadable(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := newMasterKey(t), newMasterKey(t)
+	before, _ := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{{ID: "v1", Key: oldKey}}})
+	legacy, _ := encryption.NewEncryptor(oldKey)
+
+	sealed, _ := before.Seal(ctx, "sealed-value")
+	legacyToken, _ := legacy.Encrypt("legacy-value")
+
+	after, err := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{
+		{ID: "v1", Key: oldKey},
+		{ID: "v2", Key: newKey},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.PrimaryKeyID() != "v2" {
+		t.Fatalf("expected the last key to be primary, got %q", after.PrimaryKeyID())
+	}
+
+	for _, tc := range []struct {
+		value encryption.Sealed
+		want  string
+	}{
+		{sealed, "sealed-value"},
+		{encryption.Sealed{Ciphertext: legacyToken}, "legacy-value"},
+	} {
+		got, err := after.Open(ctx, tc.value)
+		if err != nil || got != tc.want {
+			t.Fatalf("expected %q before re-wrap, got %q (%v)", tc.want, got, err)
+		}
+
+		rewrapped, changed, err := after.Rewrap(ctx, tc.value)
+		if err != nil || !changed {
+			t.Fatalf("expected %q to be re-wrapped, got changed=%v err=%v", tc.want, changed, err)
+		}
+		if rewrapped.KeyID != "v2" {
+			t.Errorf("expected re-wrap under v2, got %q", rewrapped.KeyID)
+		}
+
+		// Once re-wrapped, the old master key can be retired.
+		retired, _ := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{{ID: "v2", Key: newKey}}})
+		got, err = retired.Open(ctx, rewrapped)
+		if err != nil || got != tc.want {
+			t.Errorf("expected %q after retiring v1, got %q (%v)", tc.want, got, err)
+		}
+		if _, changed, _ := retired.Rewrap(ctx, rewrapped); changed {
+			t.Error("a value under the primary key should not be re-wrapped again")
+		}
+	}
+}
+
+func TestRewrapKeepsCiphertext(t *testing.T) {
+	ctx := context.Background()
+	oldKey := newMasterKey(t)
+	enc, _ := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{{ID: "v1", Key: oldKey}}})
+	sealed, _ := enc.Seal(ctx, "value")
+
+	rotated, _ := encryption.NewKeyringEncryptor(encryption.Keyring{Primary: "v2", Keys: []encryption.MasterKey{
+		{ID: "v2", Key: newMasterKey(t)},
+		{ID: "v1", Key: oldKey},
+	}})
+	rewrapped, _, err := rotated.Rewrap(ctx, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.Ciphertext != sealed.Ciphertext || rewrapped.WrappedKey == sealed.WrappedKey {
+		t.Error("expected only the data key to be re-wrapped")
+	}
+
+	unknown, _ := encryption.NewKeyringEncryptor(encryption.Keyring{Keys: []encryption.MasterKey{{ID: "v2", Key: newMasterKey(t)}}})
+	if _, err := unknown.Open(ctx, sealed); !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey without v1, got %v", err)
+	}
+}
+
+func TestKeyringValidation(t *testing.T) {
+	key := newMasterKey(t)
+	invalid := []encryption.Keyring{
+		{},
+		{Keys: []encryption.MasterKey{{ID: "v1", Key: ""}}},
+		{Keys: []encryption.MasterKey{{ID: "v1", Key: "not-a-fernet-key"}}},
+		{Keys: []encryption.MasterKey{{ID: "v1", Key: key}, {ID: "v1", Key: newMasterKey(t)}}},
+		{Primary: "v9", Keys: []encryption.MasterKey{{ID: "v1", Key: key}}},
+	}
+	for i, kr := range invalid {
+		if _, err := encryption.NewKeyringEncryptor(kr); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestKeyringIncludeAddsLegacyKeyAsOldest(t *testing.T) {
+	legacyKey, primaryKey := newMasterKey(t), newMasterKey(t)
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[{"id":"v2","key":"`+primaryKey+`"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := encryption.LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr.Include(legacyKey)
+	kr.Include(primaryKey)
+
+	enc, err := encryption.NewFernet(*kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := enc.KeyIDs(); len(ids) != 2 || ids[1] != "v2" {
+		t.Errorf("expected the legacy key before v2, got %v", ids)
+	}
+	if enc.PrimaryKeyID() != "v2" {
+		t.Errorf("expected v2 to stay primary, got %q", enc.PrimaryKeyID())
+	}
+
+	// A single ENCRYPTION_KEY is identified by its fingerprint.
+	single, _ := encryption.NewEncryptor(legacyKey)
+	if single.PrimaryKeyID() != enc.KeyIDs()[0] {
+		t.Errorf("expected fingerprint IDs to match, got %q and %q", single.PrimaryKeyID(), enc.KeyIDs()[0])
+	}
+}
+
+func TestRewrapEndpoints(t *testing.T) {
+	h := api.NewAdminHandler(newTestReembedWorker(), nil)
+
+	for _, tc := range []struct {
+		method string
+		agent  string
+		fn     http.HandlerFunc
+		want   int
+	}{
+		{"POST", "kai", h.Rewrap, http.StatusForbidden},
+		{"GET", "kai", h.RewrapStatus, http.StatusForbidden},
+		{"POST", "warren", h.Rewrap, http.StatusServiceUnavailable},
+		{"GET", "warren", h.RewrapStatus, http.StatusServiceUnavailable},
+	} {
+		req := httptest.NewRequest(tc.method, "/admin/secrets/rewrap", nil)
+		req.Header.Set("X-Agent-ID", tc.agent)
+		rec := httptest.NewRecorder()
+
+		middleware.AgentAuth("")(tc.fn).ServeHTTP(rec, req)
+
+		if rec.Code != tc.want {
+			t.Errorf("%s as %s: expected %d, got %d", tc.method, tc.agent, tc.want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestAtomicBatchRejectsInvalidEntriesBeforeStoring(t *testing.T) {
+	// Nil stores: the handler must fail validation before touching them.
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+
+	body := `{"atomic":true,"entries":[{"content":"valid entry"},{"content":""},{"content":"bad","dedupe":"sometimes"}]}`
+	req := httptest.NewRequest("POST", "/knowledge/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.BatchCreate(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Error struct {
+			Code    string                `json:"code"`
+			Details []api.BatchItemResult `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Error.Code != "VALIDATION_ERROR" {
+		t.Errorf("expected VALIDATION_ERROR, got %s", resp.Error.Code)
+	}
+	if len(resp.Error.Details) != 3 {
+		t.Fatalf("expected 3 item results, got %d", len(resp.Error.Details))
+	}
+	if resp.Error.Details[0].Error != nil {
+		t.Errorf("entry 0 should be valid, got %+v", resp.Error.Details[0].Error)
+	}
+	for _, i := range []int{1, 2} {
+		item := resp.Error.Details[i]
+		if item.Index != i || item.Status != "error" || item.Error == nil || item.Error.Code != "VALIDATION_ERROR" {
+			t.Errorf("entry %d: unexpected result %+v", i, item)
+		}
+	}
+}
+
+func TestBatchFailsWhenNoEntryIsStored(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+
+	// Nothing valid means nothing reaches the (nil) store.
+	for _, body := range []string{
+		`{"entries":[{"content":""},{"content":""}]}`,
+		`{"entries":[{"content":""},{"content":"x","dedupe":"sometimes"}]}`,
+	} {
+		req := httptest.NewRequest("POST", "/knowledge/batch", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.BatchCreate(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d: %s", body, rec.Code, rec.Body.String())
+			continue
+		}
+		var resp struct {
+			Error struct {
+				Code    string                `json:"code"`
+				Details []api.BatchItemResult `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Error.Code != "VALIDATION_ERROR" || len(resp.Error.Details) != 2 {
+			t.Errorf("%s: expected VALIDATION_ERROR with 2 item results, got %s with %d", body, resp.Error.Code, len(resp.Error.Details))
+		}
+	}
+}
+
+func TestBatchItemErrorUnwraps(t *testing.T) {
+	dup := &store.DuplicateError{Existing: &store.KnowledgeEntry{ID: "k-1"}, Similarity: 1}
+	var err error = &store.BatchItemError{Index: 3, Err: dup}
+
+	if !errors.Is(err, store.ErrDuplicateKnowledge) {
+		t.Error("expected BatchItemError to unwrap to ErrDuplicateKnowledge")
+	}
+	var itemErr *store.BatchItemError
+	if !errors.As(err, &itemErr) || itemErr.Index != 3 {
+		t.Errorf("expected index 3, got %+v", itemErr)
+	}
+	if !strings.Contains(err.Error(), "batch entry 3") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Embed(_ context.Context, text string) (pgvector.Vector, error) {
+	p.calls++
+	return pgvector.NewVector([]float32{float32(len(text))}), nil
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+type countingBatchProvider struct {
+	countingProvider
+	batches int
+}
+
+func (p *countingBatchProvider) EmbedBatch(_ context.Context, texts []string) ([]pgvector.Vector, error) {
+	p.batches++
+	vecs := make([]pgvector.Vector, len(texts))
+	for i, text := range texts {
+		vecs[i] = pgvector.NewVector([]float32{float32(len(text))})
+	}
+	return vecs, nil
+}
+
+func TestEmbedAllUsesBatchProvider(t *testing.T) {
+	texts := []string{"a", "bb", "ccc"}
+
+	single := &countingProvider{}
+	vecs, err := embeddings.EmbedAll(context.Background(), single, texts)
+	if err != nil {
+		t.Fatalf("EmbedAll: %v", err)
+	}
+	if single.calls != 3 || len(vecs) != 3 {
+		t.Errorf("expected 3 single calls and 3 vectors, got %d calls, %d vectors", single.calls, len(vecs))
+	}
+
+	batch := &countingBatchProvider{}
+	vecs, err = embeddings.EmbedAll(context.Background(), batch, texts)
+	if err != nil {
+		t.Fatalf("EmbedAll: %v", err)
+	}
+	if batch.batches != 1 || batch.calls != 0 {
+		t.Errorf("expected one batch call, got %d batches and %d single calls", batch.batches, batch.calls)
+	}
+	for i, v := range vecs {
+		if got := v.Slice()[0]; got != float32(len(texts[i])) {
+			t.Errorf("vector %d out of order: %v", i, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestSearchRequestExplainFlag(t *testing.T) {
+	var req api.SearchRequest
+	if err := json.Unmarshal([]byte(`{"query":"nfs","explain":true}`), &req); err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+	if !req.Explain {
+		t.Error("expected explain to be decoded")
+	}
+}
+
+func TestSearchResultExplanationJSON(t *testing.T) {
+	cosine := 0.82
+	result := store.SearchResult{
+		KnowledgeEntry: store.KnowledgeEntry{ID: "k1", Confidence: 0.9, RelevanceDecay: store.DecayFast},
+		Similarity:     0.41,
+		Explanation: &store.SearchExplanation{
+			Score:           0.82,
+			Cosine:          &cosine,
+			RelevanceDecay:  store.DecayFast,
+			HalfLifeDays:    7,
+			AgeDays:         7,
+			DecayMultiplier: 0.5,
+			Confidence:      0.9,
+			Highlights:      []string{"restart <mark>nfs</mark>-02 first"},
+		},
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("encoding result: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	explanation, ok := decoded["explanation"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected an explanation object, got %s", data)
+	}
+	for _, key := range []string{"score", "cosine", "relevance_decay", "half_life_days", "age_days", "decay_multiplier", "confidence", "highlights"} {
+		if _, ok := explanation[key]; !ok {
+			t.Errorf("explanation is missing %q", key)
+		}
+	}
+	if _, ok := explanation["lexical"]; ok {
+		t.Error("expected lexical to be omitted for a vector-only match")
+	}
+
+	result.Explanation = nil
+	data, _ = json.Marshal(result)
+	if strings.Contains(string(data), "explanation") {
+		t.Errorf("expected no explanation unless asked for, got %s", data)
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestKnowledgeImportRequiresWarren(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+	handler := middleware.AgentAuth("")(http.HandlerFunc(h.Import))
+
+	req := httptest.NewRequest("POST", "/knowledge/import", strings.NewReader(`{"id":"x","content":"y"}`))
+	req.Header.Set("X-Agent-ID", "kai")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestKnowledgeImportRequiresSummary(t *testing.T) {
+	record := `{"id":"k-1","content":"nfs-02 holds the build cache","source_agent":"kai"}`
+	cases := map[string]string{
+		"missing":       record + "\n",
+		"count":         record + "\n" + `{"export_summary":{"count":2}}` + "\n",
+		"checksum":      record + "\n" + `{"export_summary":{"count":1,"sha256":"00"}}` + "\n",
+		"after summary": `{"export_summary":{"count":0}}` + "\n" + record + "\n",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+			handler := middleware.AgentAuth("")(http.HandlerFunc(h.Import))
+
+			req := httptest.NewRequest("POST", "/knowledge/import", strings.NewReader(body))
+			req.Header.Set("X-Agent-ID", "warren")
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Errorf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestKnowledgeExportRejectsBadDates(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+
+	req := httptest.NewRequest("GET", "/knowledge/export?created_after=yesterday", nil)
+	rec := httptest.NewRecorder()
+
+	h.Export(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "created_after") {
+		t.Errorf("expected created_after in error, got %s", rec.Body.String())
+	}
+}
+
+func TestKnowledgeExportRecordIsFlat(t *testing.T) {
+	rec := store.KnowledgeExportRecord{
+		KnowledgeEntry: store.KnowledgeEntry{ID: "k-1", Content: "nfs-02 holds the build cache", SourceAgent: "kai"},
+		Vector:         []float32{0.5, 0.25},
+		EmbeddingModel: "all-MiniLM-L6-v2",
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(data, &line); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, key := range []string{"id", "content", "source_agent", "embedding", "embedding_model"} {
+		if _, ok := line[key]; !ok {
+			t.Errorf("expected top-level %q in %s", key, data)
+		}
+	}
+
+	var back store.KnowledgeExportRecord
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("unmarshal record: %v", err)
+	}
+	if back.ID != "k-1" || len(back.Vector) != 2 || back.EmbeddingModel != "all-MiniLM-L6-v2" {
+		t.Errorf("round trip lost fields: %+v", back)
+	}
+}
+
+func TestEmbeddingModelName(t *testing.T) {
+	if got := embeddings.ModelName(embeddings.NewSimpleProvider()); got != "simple" {
+		t.Errorf("expected simple provider to fall back to its name, got %q", got)
+	}
+	if got := embeddings.ModelName(embeddings.NewOpenAIProvider("key", "")); got != "text-embedding-3-small" {
+		t.Errorf("expected default OpenAI model, got %q", got)
+	}
+}
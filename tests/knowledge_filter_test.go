@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestParseMetadataPredicate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want store.MetadataPredicate
+	}{
+		{"host:eq:nfs-02", store.MetadataPredicate{Path: []string{"host"}, Op: store.MetadataEq, Value: "nfs-02"}},
+		{"source.url:eq:https://example.com", store.MetadataPredicate{Path: []string{"source", "url"}, Op: store.MetadataEq, Value: "https://example.com"}},
+		{"retries:gte:3", store.MetadataPredicate{Path: []string{"retries"}, Op: store.MetadataGte, Value: "3"}},
+		{"linked_reports:exists", store.MetadataPredicate{Path: []string{"linked_reports"}, Op: store.MetadataExists}},
+		{"env:ne:", store.MetadataPredicate{Path: []string{"env"}, Op: store.MetadataNe}},
+	}
+	for _, tt := range tests {
+		got, err := store.ParseMetadataPredicate(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMetadataPredicateRejectsMalformed(t *testing.T) {
+	for _, in := range []string{
+		"host",            // no operator
+		":eq:x",           // no path
+		"a..b:eq:x",       // empty segment
+		"host:like:nfs%",  // unknown operator
+		"host:eq",         // missing value
+		"retries:gt:many", // non-numeric comparison
+		"host:exists:yes", // exists takes no value
+	} {
+		if _, err := store.ParseMetadataPredicate(in); !errors.Is(err, store.ErrInvalidFilter) {
+			t.Errorf("%q: expected ErrInvalidFilter, got %v", in, err)
+		}
+	}
+}
+
+func TestKnowledgeListAndFacetsRejectBadFilters(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"list min_confidence", h.List, "/knowledge?min_confidence=2"},
+		{"list created_before", h.List, "/knowledge?created_before=last-week"},
+		{"facets metadata", h.Facets, "/knowledge/facets?metadata=host:like:nfs"},
+	} {
+		rec := httptest.NewRecorder()
+		tc.handler(rec, httptest.NewRequest("GET", tc.url, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestKnowledgeCreateRejectsOverlongIdempotencyKey(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+
+	for _, path := range []string{"/knowledge", "/knowledge/batch"} {
+		body := `{"content":"the build cache lives on nfs-02"}`
+		handler := h.Create
+		if strings.HasSuffix(path, "batch") {
+			body = `{"entries":[{"content":"the build cache lives on nfs-02"}]}`
+			handler = h.BatchCreate
+		}
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d", path, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "Idempotency-Key") {
+			t.Errorf("%s: expected Idempotency-Key validation message, got %s", path, rec.Body.String())
+		}
+	}
+}
+
+func TestReplayedOutcomeIsDistinct(t *testing.T) {
+	outcomes := map[store.CreateOutcome]bool{}
+	for _, o := range []store.CreateOutcome{store.OutcomeCreated, store.OutcomeMerged, store.OutcomeLinked, store.OutcomeReplayed} {
+		if outcomes[o] {
+			t.Errorf("duplicate outcome value %q", o)
+		}
+		outcomes[o] = true
+	}
+	if store.OutcomeReplayed != "replayed" {
+		t.Errorf("unexpected replayed outcome %q", store.OutcomeReplayed)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func candidate(id string, similarity float64, decay store.RelevanceDecay, age time.Duration, now time.Time) store.SearchResult {
+	return store.SearchResult{
+		KnowledgeEntry: store.KnowledgeEntry{ID: id, RelevanceDecay: decay, CreatedAt: now.Add(-age), Confidence: 1},
+		Similarity:     similarity,
+	}
+}
+
+func TestRankResultsOrdersMixedDecayByFinalScore(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	profile := store.BuiltInProfiles(store.DefaultHalfLives())[0]
+
+	// Candidates arrive in raw-similarity order.
+	candidates := []store.SearchResult{
+		candidate("stale-ephemeral", 0.95, store.DecayEphemeral, 3*day, now), // 0.95 / 8
+		candidate("old-fast", 0.90, store.DecayFast, 14*day, now),            // 0.90 / 4
+		candidate("timeless", 0.70, store.DecayNone, 365*day, now),           // 0.70
+		candidate("fresh-slow", 0.80, store.DecaySlow, 0, now),               // 0.80
+	}
+
+	results := store.RankResults(candidates, &profile, 0, 10, now, false)
+
+	want := []string{"fresh-slow", "timeless", "old-fast", "stale-ephemeral"}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, id := range want {
+		if results[i].ID != id {
+			t.Errorf("position %d: expected %s, got %s (%.3f)", i, id, results[i].ID, results[i].Similarity)
+		}
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Similarity > results[i-1].Similarity {
+			t.Errorf("results not sorted by final score at %d", i)
+		}
+	}
+}
+
+func TestRankResultsAppliesThresholdAfterDecay(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	profile := store.BuiltInProfiles(store.DefaultHalfLives())[0]
+
+	candidates := []store.SearchResult{
+		candidate("decayed", 0.9, store.DecayFast, 7*day, now), // 0.45 after decay
+		candidate("fresh", 0.6, store.DecayFast, 0, now),
+		candidate("weak", 0.4, store.DecayNone, 0, now),
+	}
+
+	results := store.RankResults(candidates, &profile, 0.5, 10, now, true)
+
+	if len(results) != 1 || results[0].ID != "fresh" {
+		t.Fatalf("expected only the fresh entry over 0.5, got %+v", results)
+	}
+	exp := results[0].Explanation
+	if exp == nil || exp.Score != 0.6 || exp.DecayMultiplier != 1 {
+		t.Errorf("expected an explanation of the raw score, got %+v", exp)
+	}
+}
+
+func TestRankResultsLimitsAfterRanking(t *testing.T) {
+	now := time.Now()
+	profile := store.BuiltInProfiles(store.DefaultHalfLives())[0]
+
+	// The freshest entry is last by raw similarity but must survive the limit.
+	candidates := []store.SearchResult{
+		candidate("a", 0.99, store.DecayEphemeral, 10*24*time.Hour, now),
+		candidate("b", 0.98, store.DecayEphemeral, 10*24*time.Hour, now),
+		candidate("c", 0.70, store.DecaySlow, 0, now),
+	}
+
+	results := store.RankResults(candidates, &profile, 0, 1, now, false)
+
+	if len(results) != 1 || results[0].ID != "c" {
+		t.Errorf("expected the fresh entry to take the only slot, got %+v", results)
+	}
+}
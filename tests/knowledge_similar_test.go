@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	pgvector "github.com/pgvector/pgvector-go"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+)
+
+func TestCombineNormalisesPositives(t *testing.T) {
+	got, err := embeddings.Combine([]pgvector.Vector{
+		pgvector.NewVector([]float32{2, 0}),
+		pgvector.NewVector([]float32{0, 5}),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := got.Slice()
+	// Both examples count equally regardless of magnitude.
+	if math.Abs(float64(s[0]-s[1])) > 1e-6 || math.Abs(float64(s[0]*s[0]+s[1]*s[1])-1) > 1e-6 {
+		t.Errorf("expected a unit vector halfway between the examples, got %v", s)
+	}
+}
+
+func TestCombineSteersAwayFromNegatives(t *testing.T) {
+	got, err := embeddings.Combine(
+		[]pgvector.Vector{pgvector.NewVector([]float32{1, 1})},
+		[]pgvector.Vector{pgvector.NewVector([]float32{0, 1})},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := got.Slice()
+	if s[0] <= s[1] {
+		t.Errorf("expected the negative example's dimension to shrink, got %v", s)
+	}
+}
+
+func TestCombineRejectsBadInput(t *testing.T) {
+	if _, err := embeddings.Combine(nil, []pgvector.Vector{pgvector.NewVector([]float32{1})}); err == nil {
+		t.Error("expected an error without positive examples")
+	}
+	_, err := embeddings.Combine([]pgvector.Vector{
+		pgvector.NewVector([]float32{1, 0}),
+		pgvector.NewVector([]float32{1, 0, 0}),
+	}, nil)
+	if err == nil {
+		t.Error("expected an error for mismatched dimensions")
+	}
+}
+
+func TestSearchValidatesExamples(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+	handler := middleware.AgentAuth("")(http.HandlerFunc(h.Search))
+
+	id := "6f1c1a64-8c3e-4d36-9a55-1d2b3c4d5e6f"
+	queries := `"q1","q2","q3","q4","q5","q6","q7","q8","q9","q10","q11"`
+	cases := map[string]string{
+		"negatives only":       `{"negative_ids":["` + id + `"]}`,
+		"invalid example id":   `{"positive_ids":["not-a-uuid"]}`,
+		"lexical with example": `{"query":"nfs","mode":"lexical","positive_ids":["` + id + `"]}`,
+		"too many queries":     `{"queries":[` + queries + `]}`,
+		"blank queries":        `{"query":" ","queries":[""]}`,
+	}
+	for name, body := range cases {
+		req := httptest.NewRequest("POST", "/knowledge/search", strings.NewReader(body))
+		req.Header.Set("X-Agent-ID", "kai")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestSimilarRejectsInvalidID(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+	r := chi.NewRouter()
+	r.Use(middleware.AgentAuth(""))
+	r.Post("/knowledge/{id}/similar", h.Similar)
+
+	req := httptest.NewRequest("POST", "/knowledge/nope/similar", nil)
+	req.Header.Set("X-Agent-ID", "kai")
+	rec := httptest.NewRecorder()
+
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -153,3 +153,66 @@ func pow(base, exp float64) float64 {
 	}
 	return result
 }
+
+func TestFuseRankings(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+
+	vector := []store.SearchResult{
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "a"}, Similarity: 0.9, VectorScore: score(0.9)},
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "b"}, Similarity: 0.8, VectorScore: score(0.8)},
+	}
+	lexical := []store.SearchResult{
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "c"}, Similarity: 0.4, LexicalScore: score(0.4)},
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "b"}, Similarity: 0.2, LexicalScore: score(0.2)},
+	}
+
+	fused := store.FuseRankings(vector, lexical, 60)
+	if len(fused) != 3 {
+		t.Fatalf("expected 3 fused results, got %d", len(fused))
+	}
+
+	// b appears in both lists so it must outrank entries found by one ranker only
+	if fused[0].ID != "b" {
+		t.Errorf("expected 'b' first, got %q", fused[0].ID)
+	}
+	if fused[0].VectorScore == nil || *fused[0].VectorScore != 0.8 {
+		t.Errorf("expected vector score 0.8 carried over for 'b', got %v", fused[0].VectorScore)
+	}
+	if fused[0].LexicalScore == nil || *fused[0].LexicalScore != 0.2 {
+		t.Errorf("expected lexical score 0.2 carried over for 'b', got %v", fused[0].LexicalScore)
+	}
+
+	for _, r := range fused[1:] {
+		if r.VectorScore != nil && r.LexicalScore != nil {
+			t.Errorf("%q matched only one ranker but has both component scores", r.ID)
+		}
+		if r.Similarity <= 0 || r.Similarity > 0.5 {
+			t.Errorf("%q: single-list relevance should be in (0, 0.5], got %f", r.ID, r.Similarity)
+		}
+	}
+}
+
+func TestFilterVectorMatches(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+	vector := []store.SearchResult{
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "a"}, Similarity: 0.9, VectorScore: score(0.9)},
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "b"}, Similarity: 0.5, VectorScore: score(0.5)},
+		{KnowledgeEntry: store.KnowledgeEntry{ID: "c"}, Similarity: 0.2, VectorScore: score(0.2)},
+	}
+
+	kept := store.FilterVectorMatches(vector, 0.5)
+	if len(kept) != 2 || kept[0].ID != "a" || kept[1].ID != "b" {
+		t.Errorf("expected a and b at a threshold of 0.5, got %+v", kept)
+	}
+}
+
+func TestFuseRankings_TopOfBothIsOne(t *testing.T) {
+	only := []store.SearchResult{{KnowledgeEntry: store.KnowledgeEntry{ID: "x"}}}
+	fused := store.FuseRankings(only, only, 60)
+	if len(fused) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(fused))
+	}
+	if diff := fused[0].Similarity - 1.0; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("rank 1 in both lists should normalise to 1.0, got %f", fused[0].Similarity)
+	}
+}
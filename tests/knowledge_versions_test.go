@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestKnowledgeVersionListingOmitsContent(t *testing.T) {
+	// ListVersions does not load content bodies; the JSON should not carry an empty content field.
+	v := store.KnowledgeVersion{
+		KnowledgeID:    "11111111-1111-1111-1111-111111111111",
+		Version:        2,
+		Category:       store.CategoryFact,
+		Scope:          store.ScopePublic,
+		RelevanceDecay: store.DecaySlow,
+		EditedBy:       "kai",
+		CreatedAt:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(data), `"content"`) {
+		t.Errorf("expected content to be omitted, got %s", data)
+	}
+	if !strings.Contains(string(data), `"edited_by":"kai"`) {
+		t.Errorf("expected edited_by in JSON, got %s", data)
+	}
+}
+
+func TestKnowledgeEntryVersionJSON(t *testing.T) {
+	e := store.KnowledgeEntry{ID: "abc", Version: 3}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"version":3`) {
+		t.Errorf("expected version in JSON, got %s", data)
+	}
+}
+
+func TestTrashedKnowledgeJSONIsFlat(t *testing.T) {
+	deleted := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := store.TrashedKnowledge{
+		KnowledgeEntry: store.KnowledgeEntry{ID: "k-1", SourceAgent: "kai", Version: 2},
+		DeletedAt:      deleted,
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded["id"] != "k-1" {
+		t.Errorf("expected embedded entry fields at top level, got %s", data)
+	}
+	if decoded["deleted_at"] != "2025-03-01T12:00:00Z" {
+		t.Errorf("expected deleted_at, got %v", decoded["deleted_at"])
+	}
+}
+
+func TestKnowledgeTrashAuditActions(t *testing.T) {
+	if store.ActionKnowledgeTrash != "knowledge.trash" {
+		t.Errorf("unexpected trash action %q", store.ActionKnowledgeTrash)
+	}
+	if store.ActionKnowledgeRestore != "knowledge.restore" {
+		t.Errorf("unexpected restore action %q", store.ActionKnowledgeRestore)
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/mcpclient"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := store.EncodeCursor("2025-01-01T00:00:00.123456Z", "11111111-1111-1111-1111-111111111111")
+	key, id, err := store.DecodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if key != "2025-01-01T00:00:00.123456Z" {
+		t.Errorf("key mismatch: %q", key)
+	}
+	if id != "11111111-1111-1111-1111-111111111111" {
+		t.Errorf("id mismatch: %q", id)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, c := range []string{"not base64!", "e30", store.EncodeCursor("k", "")} {
+		if _, _, err := store.DecodeCursor(c); !errors.Is(err, store.ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q): expected ErrInvalidCursor, got %v", c, err)
+		}
+	}
+}
+
+func TestMCPClientListEntitiesPage(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"id":"a"},{"id":"b"}],"meta":{"timestamp":"2025-01-01T00:00:00Z","next_cursor":"abc"}}`))
+	}))
+	defer srv.Close()
+
+	client := mcpclient.New(srv.URL)
+	page, err := client.ListEntitiesPage(context.Background(), "person", 2, "prev")
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	if len(page.Entities) != 2 {
+		t.Errorf("expected 2 entities, got %d", len(page.Entities))
+	}
+	if page.NextCursor != "abc" {
+		t.Errorf("expected next_cursor 'abc', got %q", page.NextCursor)
+	}
+	if gotQuery != "cursor=prev&limit=2&type=person" {
+		t.Errorf("unexpected query %q", gotQuery)
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func TestUsageCheckLimits(t *testing.T) {
+	quota := store.Quota{MaxEntries: 10, MaxBytes: 1000, MaxDailyCreates: 5}
+	cases := []struct {
+		name  string
+		usage store.Usage
+		size  int64
+		want  store.QuotaLimit
+	}{
+		{"fits", store.Usage{Entries: 9, Bytes: 900, CreatesToday: 4}, 100, ""},
+		{"entries", store.Usage{Entries: 10}, 1, store.QuotaEntries},
+		{"bytes", store.Usage{Entries: 1, Bytes: 950}, 51, store.QuotaBytes},
+		{"daily creates", store.Usage{Entries: 1, CreatesToday: 5}, 1, store.QuotaDailyCreates},
+	}
+	for _, tc := range cases {
+		tc.usage.AgentID = "kai"
+		tc.usage.Quota = quota
+		err := tc.usage.Check(tc.size)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", tc.name, err)
+			}
+			continue
+		}
+		var qe *store.QuotaError
+		if !errors.As(err, &qe) || qe.Limit != tc.want {
+			t.Errorf("%s: expected %s quota error, got %v", tc.name, tc.want, err)
+			continue
+		}
+		if !errors.Is(err, store.ErrQuotaExceeded) {
+			t.Errorf("%s: expected error to match ErrQuotaExceeded", tc.name)
+		}
+	}
+}
+
+func TestUnlimitedQuotaAllowsAnything(t *testing.T) {
+	u := store.Usage{Entries: 1 << 40, Bytes: 1 << 50, CreatesToday: 1 << 30}
+	if err := u.Check(1 << 20); err != nil {
+		t.Errorf("expected zero limits to be unlimited, got %v", err)
+	}
+}
+
+func TestQuotaOverrideApply(t *testing.T) {
+	zero, bytes := int64(0), int64(2048)
+	defaults := store.Quota{MaxEntries: 100, MaxBytes: 1024, MaxDailyCreates: 10}
+	got := store.QuotaOverride{MaxBytes: &bytes, MaxDailyCreates: &zero}.Apply(defaults)
+	want := store.Quota{MaxEntries: 100, MaxBytes: 2048, MaxDailyCreates: 0}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	negative := int64(-1)
+	if err := (store.QuotaOverride{MaxEntries: &negative}).Validate(); !errors.Is(err, store.ErrInvalidQuota) {
+		t.Errorf("expected ErrInvalidQuota for a negative limit, got %v", err)
+	}
+}
+
+func TestEntrySizeCountsSummary(t *testing.T) {
+	summary := "héllo"
+	if got := store.EntrySize("abc", &summary); got != 9 {
+		t.Errorf("expected 9 bytes, got %d", got)
+	}
+	if got := store.EntrySize("abc", nil); got != 3 {
+		t.Errorf("expected 3 bytes, got %d", got)
+	}
+}
+
+func TestUsageAccessControl(t *testing.T) {
+	h := api.NewUsageHandler(nil, nil)
+	r := chi.NewRouter()
+	r.Use(middleware.AgentAuth(""))
+	r.Get("/agents/{id}/usage", h.Usage)
+	r.Put("/agents/{id}/quota", h.SetQuota)
+	r.Delete("/agents/{id}/quota", h.DeleteQuota)
+
+	cases := []struct{ method, path string }{
+		{"GET", "/agents/lily/usage"},
+		{"PUT", "/agents/kai/quota"},
+		{"DELETE", "/agents/kai/quota"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{}`))
+		req.Header.Set("X-Agent-ID", "kai")
+		rec := httptest.NewRecorder()
+
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d", tc.method, tc.path, rec.Code)
+		}
+	}
+}
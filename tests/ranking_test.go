@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestDefaultProfileDecaysByHalfLife(t *testing.T) {
+	now := time.Now()
+	profile := store.BuiltInProfiles(store.DefaultHalfLives())[0]
+	if profile.Name != store.DefaultProfileName {
+		t.Fatalf("expected the default profile first, got %q", profile.Name)
+	}
+
+	entry := &store.KnowledgeEntry{RelevanceDecay: store.DecayFast, Confidence: 0.2, CreatedAt: now.Add(-7 * 24 * time.Hour)}
+	f := profile.Factors(entry, now)
+	if !approx(f.Decay, 0.5) || f.HalfLifeDays != 7 {
+		t.Errorf("expected a fast entry a week old to be halved, got %+v", f)
+	}
+	if !approx(f.Multiplier(), 0.5) {
+		t.Errorf("expected the default profile to ignore confidence, got %v", f.Multiplier())
+	}
+
+	entry.RelevanceDecay = store.DecayNone
+	if f := profile.Factors(entry, now); f.Decay != 1 || f.HalfLifeDays != 0 {
+		t.Errorf("expected no decay for relevance_decay none, got %+v", f)
+	}
+}
+
+func TestProfileWeightsCombine(t *testing.T) {
+	now := time.Now()
+	profile := store.RankingProfile{
+		Name:             "ops",
+		ConfidenceWeight: 0.5,
+		HalfLifeDays:     store.HalfLives{store.DecaySlow: 10},
+		CategoryWeights:  map[store.KnowledgeCategory]float64{store.CategoryLesson: 2},
+		AgentTrust:       map[string]float64{"dredd": 0.5},
+	}
+	entry := &store.KnowledgeEntry{
+		Category:       store.CategoryLesson,
+		SourceAgent:    "dredd",
+		Confidence:     0.6,
+		RelevanceDecay: store.DecaySlow,
+		CreatedAt:      now.Add(-10 * 24 * time.Hour),
+	}
+
+	f := profile.Factors(entry, now)
+	// decay 0.5 × confidence (1 - 0.5 + 0.5×0.6 = 0.8) × category 2 × trust 0.5
+	if !approx(f.ConfidenceFactor, 0.8) || f.CategoryWeight != 2 || f.AgentTrust != 0.5 {
+		t.Errorf("unexpected factors %+v", f)
+	}
+	if !approx(f.Multiplier(), 0.4) {
+		t.Errorf("expected multiplier 0.4, got %v", f.Multiplier())
+	}
+
+	entry.SourceAgent, entry.Category = "kai", store.CategoryFact
+	if f := profile.Factors(entry, now); f.CategoryWeight != 1 || f.AgentTrust != 1 {
+		t.Errorf("expected unlisted category and agent to weigh 1, got %+v", f)
+	}
+}
+
+func TestRecentProfileUsesShorterHalfLives(t *testing.T) {
+	var recent *store.RankingProfile
+	for _, p := range store.BuiltInProfiles(store.HalfLives{store.DecaySlow: 40}) {
+		if p.Name == "recent" {
+			p := p
+			recent = &p
+		}
+	}
+	if recent == nil {
+		t.Fatal("expected a built-in recent profile")
+	}
+	if recent.HalfLifeDays[store.DecaySlow] != 10 {
+		t.Errorf("expected a quarter of the configured half-life, got %v", recent.HalfLifeDays[store.DecaySlow])
+	}
+}
+
+func TestRankingProfileValidate(t *testing.T) {
+	cases := map[string]store.RankingProfile{
+		"bad name":          {Name: "Has Spaces"},
+		"confidence weight": {Name: "x", ConfidenceWeight: 1.5},
+		"decay class":       {Name: "x", HalfLifeDays: store.HalfLives{"glacial": 90}},
+		"negative days":     {Name: "x", HalfLifeDays: store.HalfLives{store.DecayFast: -1}},
+		"category":          {Name: "x", CategoryWeights: map[store.KnowledgeCategory]float64{"rumour": 1}},
+		"negative trust":    {Name: "x", AgentTrust: map[string]float64{"kai": -0.1}},
+	}
+	for name, p := range cases {
+		if err := p.Validate(); !errors.Is(err, store.ErrInvalidProfile) {
+			t.Errorf("%s: expected ErrInvalidProfile, got %v", name, err)
+		}
+	}
+
+	ok := store.RankingProfile{Name: "ops_v2", ConfidenceWeight: 1, HalfLifeDays: store.DefaultHalfLives()}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("expected a valid profile, got %v", err)
+	}
+}
+
+func TestSearchRejectsUnknownProfile(t *testing.T) {
+	h := api.NewKnowledgeHandler(nil, nil, nil, nil)
+	handler := middleware.AgentAuth("")(http.HandlerFunc(h.Search))
+
+	req := httptest.NewRequest("POST", "/knowledge/search", strings.NewReader(`{"query":"nfs","profile":"nope"}`))
+	req.Header.Set("X-Agent-ID", "kai")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRankingProfileWritesRequireWarren(t *testing.T) {
+	h := api.NewRankingHandler(nil, nil)
+	for method, fn := range map[string]http.HandlerFunc{"PUT": h.Put, "DELETE": h.Delete} {
+		req := httptest.NewRequest(method, "/ranking-profiles/ops", strings.NewReader(`{}`))
+		req.Header.Set("X-Agent-ID", "kai")
+		rec := httptest.NewRecorder()
+
+		middleware.AgentAuth("")(fn).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", method, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/embeddings"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/reembed"
+)
+
+func newTestReembedWorker() *reembed.Worker {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return reembed.New(nil, embeddings.NewSimpleProvider(), reembed.Config{Interval: time.Minute}, logger)
+}
+
+func TestReembedRequiresWarren(t *testing.T) {
+	h := api.NewAdminHandler(newTestReembedWorker(), nil)
+
+	for _, tc := range []struct {
+		method string
+		fn     http.HandlerFunc
+	}{
+		{"POST", h.Reembed},
+		{"GET", h.ReembedStatus},
+	} {
+		req := httptest.NewRequest(tc.method, "/admin/reembed", nil)
+		req.Header.Set("X-Agent-ID", "kai")
+		rec := httptest.NewRecorder()
+
+		middleware.AgentAuth("")(tc.fn).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", tc.method, rec.Code)
+		}
+	}
+}
+
+func TestReembedStatusBeforeAnyJob(t *testing.T) {
+	worker := newTestReembedWorker()
+	h := api.NewAdminHandler(worker, nil)
+
+	req := httptest.NewRequest("GET", "/admin/reembed", nil)
+	req.Header.Set("X-Agent-ID", "warren")
+	rec := httptest.NewRecorder()
+
+	middleware.AgentAuth("")(http.HandlerFunc(h.ReembedStatus)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		Data reembed.Progress `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body.Data.State != reembed.JobIdle || body.Data.Total != 0 {
+		t.Errorf("expected an idle job, got %+v", body.Data)
+	}
+	if worker.Model() != "simple" {
+		t.Errorf("expected jobs to record the provider's model, got %q", worker.Model())
+	}
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MikeSquared-Agency/Alexandria/internal/api"
+	"github.com/MikeSquared-Agency/Alexandria/internal/middleware"
+	"github.com/MikeSquared-Agency/Alexandria/internal/store"
+	"github.com/MikeSquared-Agency/Alexandria/internal/watch"
+)
+
+func cosine(v float64) *float64 { return &v }
+
+func TestSavedSearchMatchThresholds(t *testing.T) {
+	cases := []struct {
+		name   string
+		search store.SavedSearch
+		cosine *float64
+		lex    bool
+		want   bool
+	}{
+		{"vector default threshold", store.SavedSearch{Mode: store.SearchModeVector}, cosine(0.55), false, true},
+		{"vector under default", store.SavedSearch{Mode: store.SearchModeVector}, cosine(0.45), true, false},
+		{"vector custom threshold", store.SavedSearch{Mode: store.SearchModeVector, MinRelevance: 0.8}, cosine(0.7), false, false},
+		{"vector without embedding", store.SavedSearch{Mode: store.SearchModeVector}, nil, true, false},
+		{"lexical", store.SavedSearch{Mode: store.SearchModeLexical}, nil, true, true},
+		{"lexical without terms", store.SavedSearch{Mode: store.SearchModeLexical}, cosine(0.9), false, false},
+		{"hybrid on text", store.SavedSearch{Mode: store.SearchModeHybrid}, cosine(0.1), true, true},
+		{"hybrid on vector", store.SavedSearch{Mode: store.SearchModeHybrid}, cosine(0.6), false, true},
+	}
+	for _, tc := range cases {
+		_, got := store.SavedSearchCandidate{Search: tc.search, Cosine: tc.cosine, Lexical: tc.lex}.Matches()
+		if got != tc.want {
+			t.Errorf("%s: expected match=%v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSavedSearchInputValidate(t *testing.T) {
+	in := store.SavedSearchInput{Name: " outages ", Query: "prod outage"}
+	if err := in.Validate(); err != nil {
+		t.Fatalf("expected valid input, got %v", err)
+	}
+	if in.Mode != store.SearchModeVector || in.Name != "outages" {
+		t.Errorf("expected defaults to be filled in, got mode %q name %q", in.Mode, in.Name)
+	}
+
+	bad := "ftp://example.com/hook"
+	loopback := "http://127.0.0.1:8080/hook"
+	metadata := "http://169.254.169.254/latest/meta-data"
+	private := "https://[fd00::1]/hook"
+	localhost := "http://LocalHost./hook"
+	invalid := []store.SavedSearchInput{
+		{Name: "outages", Query: "q", WebhookURL: &loopback},
+		{Name: "outages", Query: "q", WebhookURL: &metadata},
+		{Name: "outages", Query: "q", WebhookURL: &private},
+		{Name: "outages", Query: "q", WebhookURL: &localhost},
+		{Query: "prod outage"},
+		{Name: "outages"},
+		{Name: "outages", Query: "q", Mode: "fuzzy"},
+		{Name: "outages", Query: "q", MinRelevance: 1.5},
+		{Name: "outages", Query: "q", Categories: []store.KnowledgeCategory{"gossip"}},
+		{Name: "outages", Query: "q", WebhookURL: &bad},
+	}
+	for i, in := range invalid {
+		if err := in.Validate(); err == nil {
+			t.Errorf("case %d: expected a validation error", i)
+		}
+	}
+}
+
+func TestWatcherDeliverSignsWebhook(t *testing.T) {
+	secret := "s3cret"
+	var gotBody []byte
+	var gotSig, gotEvent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(watch.SignatureHeader)
+		gotEvent = r.Header.Get("X-Alexandria-Event")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := watch.New(nil, nil, nil, watch.Config{AllowPrivate: true}, slog.Default())
+	search := &store.SavedSearch{ID: "s1", AgentID: "kai", Name: "outages", WebhookURL: &srv.URL, WebhookSecret: &secret}
+	err := w.Deliver(context.Background(), search, watch.Match{
+		Event:         watch.EventMatched,
+		SavedSearchID: search.ID,
+		AgentID:       search.AgentID,
+		Score:         0.8,
+		Knowledge:     &store.KnowledgeEntry{ID: "k1", Content: "prod outage in eu-west"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotEvent != watch.EventMatched {
+		t.Errorf("expected event header %q, got %q", watch.EventMatched, gotEvent)
+	}
+	if gotSig != watch.Sign(secret, gotBody) {
+		t.Errorf("signature %q does not match body", gotSig)
+	}
+	var m watch.Match
+	if err := json.Unmarshal(gotBody, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Knowledge == nil || m.Knowledge.ID != "k1" || m.SavedSearchID != "s1" {
+		t.Errorf("unexpected payload: %s", gotBody)
+	}
+}
+
+func TestWatcherDeliverReportsFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	w := watch.New(nil, nil, nil, watch.Config{AllowPrivate: true}, slog.Default())
+	search := &store.SavedSearch{ID: "s1", WebhookURL: &srv.URL}
+	if err := w.Deliver(context.Background(), search, watch.Match{}); err == nil {
+		t.Error("expected an error for a 502 response")
+	}
+}
+
+func TestWatcherDeliverRefusesPrivateAddresses(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	w := watch.New(nil, nil, nil, watch.Config{}, slog.Default())
+	search := &store.SavedSearch{ID: "s1", WebhookURL: &srv.URL}
+	if err := w.Deliver(context.Background(), search, watch.Match{}); err == nil {
+		t.Error("expected delivery to a loopback address to fail")
+	}
+	if hit {
+		t.Error("expected the loopback server not to be contacted")
+	}
+}
+
+func TestWatcherDeliverDoesNotFollowRedirects(t *testing.T) {
+	hit := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	w := watch.New(nil, nil, nil, watch.Config{AllowPrivate: true}, slog.Default())
+	search := &store.SavedSearch{ID: "s1", WebhookURL: &srv.URL}
+	if err := w.Deliver(context.Background(), search, watch.Match{}); err == nil {
+		t.Error("expected a redirect to be reported as a failure")
+	}
+	if hit {
+		t.Error("expected the redirect not to be followed")
+	}
+}
+
+func TestCreateSavedSearchValidates(t *testing.T) {
+	h := api.NewSavedSearchHandler(nil, nil, nil)
+	handler := middleware.AgentAuth("")(http.HandlerFunc(h.Create))
+
+	req := httptest.NewRequest("POST", "/saved-searches", strings.NewReader(`{"name":"outages"}`))
+	req.Header.Set("X-Agent-ID", "kai")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+}